@@ -0,0 +1,197 @@
+// Package catalog loads the bonus catalogue from data files, one per bonus.
+//
+// Files live in the directories nazionali/, regionali/ and the optional
+// comunali/, and may be JSON (.json) or YAML (.yaml, .yml) using the same
+// field names as the API.
+// The default catalogue is embedded in the binary; CATALOG_DIR points to an
+// external copy that is validated and hot-reloaded when a file changes.
+package catalog
+
+import (
+	"bonusperme/internal/logger"
+	"bonusperme/internal/models"
+	sentryutil "bonusperme/internal/sentry"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+//go:embed data
+var embedded embed.FS
+
+// Catalog is a validated snapshot of the bonus catalogue.
+type Catalog struct {
+	National []models.Bonus
+	Regional []models.Bonus
+	Source   string
+	LoadedAt time.Time
+}
+
+var (
+	mu       sync.RWMutex
+	current  *Catalog
+	initOnce sync.Once
+)
+
+// OnReload is called after a successful hot reload.
+// Set from main.go to refresh caches built on top of the catalogue.
+var OnReload func()
+
+// ValidateRules checks the rule expressions of a bonus.
+// Set by the matcher package, which owns the expression language.
+var ValidateRules func(*models.RegoleBonus) error
+
+// Embedded returns the catalogue data bundled in the binary.
+func Embedded() fs.FS {
+	sub, _ := fs.Sub(embedded, "data")
+	return sub
+}
+
+// Init loads the catalogue from dir, or from the embedded data when dir is empty.
+// On error the embedded catalogue stays in use.
+func Init(dir string) error {
+	if dir == "" {
+		ensureLoaded()
+		return nil
+	}
+	c, err := Load(os.DirFS(dir))
+	if err != nil {
+		ensureLoaded()
+		return err
+	}
+	c.Source = dir
+	initOnce.Do(func() {})
+	set(c)
+	logger.Info("catalog: loaded", map[string]interface{}{
+		"source": dir, "national": len(c.National), "regional": len(c.Regional),
+	})
+	return nil
+}
+
+// ensureLoaded lazily loads the embedded catalogue on first access.
+func ensureLoaded() {
+	initOnce.Do(func() {
+		c, err := Load(Embedded())
+		if err != nil {
+			// Embedded data is covered by tests: this only happens on a broken build
+			logger.Error("catalog: embedded catalogue invalid", map[string]interface{}{"error": err.Error()})
+			sentryutil.CaptureError(err, map[string]string{"component": "catalog"})
+			c = &Catalog{LoadedAt: time.Now()}
+		}
+		c.Source = "embedded"
+		set(c)
+	})
+}
+
+func set(c *Catalog) {
+	mu.Lock()
+	current = c
+	mu.Unlock()
+}
+
+func snapshot() *Catalog {
+	ensureLoaded()
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// National returns a copy of the national bonuses.
+func National() []models.Bonus {
+	return clone(snapshot().National)
+}
+
+// Regional returns a copy of the regional bonuses.
+func Regional() []models.Bonus {
+	return clone(snapshot().Regional)
+}
+
+// Status reports where the current catalogue was loaded from and when.
+func Status() map[string]interface{} {
+	c := snapshot()
+	return map[string]interface{}{
+		"source":    c.Source,
+		"loaded_at": c.LoadedAt,
+		"national":  len(c.National),
+		"regional":  len(c.Regional),
+	}
+}
+
+func clone(src []models.Bonus) []models.Bonus {
+	out := make([]models.Bonus, len(src))
+	copy(out, src)
+	return out
+}
+
+// StartWatcher polls dir every interval and reloads the catalogue when a file
+// is added, removed or modified. Invalid changes are logged and ignored, so
+// the last valid catalogue keeps being served.
+func StartWatcher(dir string, interval time.Duration) {
+	if dir == "" || interval <= 0 {
+		return
+	}
+	go func() {
+		last, _ := fingerprint(dir)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			fp, err := fingerprint(dir)
+			if err != nil {
+				logger.Warn("catalog: cannot scan directory", map[string]interface{}{"dir": dir, "error": err.Error()})
+				continue
+			}
+			if fp == last {
+				continue
+			}
+			last = fp
+			reload(dir)
+		}
+	}()
+}
+
+func reload(dir string) {
+	c, err := Load(os.DirFS(dir))
+	if err != nil {
+		logger.Warn("catalog: reload rejected, keeping previous catalogue", map[string]interface{}{
+			"dir": dir, "error": err.Error(),
+		})
+		sentryutil.CaptureError(err, map[string]string{"component": "catalog"})
+		return
+	}
+	c.Source = dir
+	set(c)
+	logger.Info("catalog: reloaded", map[string]interface{}{
+		"national": len(c.National), "regional": len(c.Regional),
+	})
+	if OnReload != nil {
+		OnReload()
+	}
+}
+
+// fingerprint summarises names, sizes and modification times of the catalogue files.
+func fingerprint(dir string) (string, error) {
+	var fp string
+	for _, sub := range []string{dirNational, dirRegional, dirLocal} {
+		entries, err := os.ReadDir(filepath.Join(dir, sub))
+		if sub == dirLocal && errors.Is(err, fs.ErrNotExist) {
+			// Municipal bonuses are optional
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			fp += fmt.Sprintf("%s/%s:%d:%d;", sub, e.Name(), info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return fp, nil
+}
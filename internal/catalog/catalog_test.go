@@ -0,0 +1,226 @@
+package catalog
+
+import (
+	"bonusperme/internal/deadline"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+const regole = `"regole": {"punteggio": [{"valore": "50"}]}`
+
+func bonusJSON(id, extra string) string {
+	return `{"id": "` + id + `", "nome": "Test", "categoria": "famiglia", "descrizione": "d", "importo": "€100",
+		"scadenza": "In vigore", "link_ufficiale": "https://example.org", "ente": "INPS"` + extra + `}`
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	c, err := Load(Embedded())
+	if err != nil {
+		t.Fatalf("catalogo incorporato non valido: %v", err)
+	}
+	if len(c.National) < 20 || len(c.Regional) < 20 {
+		t.Errorf("catalogo incompleto: %d nazionali, %d regionali", len(c.National), len(c.Regional))
+	}
+}
+
+// Le scadenze del catalogo sono tutte leggibili: un testo che il parser non
+// capisce va accompagnato dal campo termine
+func TestLoad_Scadenze(t *testing.T) {
+	c, err := Load(Embedded())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range append(c.National, c.Regional...) {
+		if b.Termine == nil && deadline.Parse(b.Scadenza).Tipo == "" {
+			t.Errorf("%s: scadenza %q non riconosciuta", b.ID, b.Scadenza)
+		}
+	}
+}
+
+func TestLoad_YAML(t *testing.T) {
+	fsys := fstest.MapFS{
+		"nazionali/bonus-yaml.yaml": {Data: []byte(`
+id: bonus-yaml
+nome: Bonus YAML
+categoria: casa
+descrizione: d
+importo: "€100"
+scadenza: In vigore
+link_ufficiale: https://example.org
+ente: AdE
+regole:
+  idoneita: ["affittuario"]
+  punteggio:
+    - valore: "60"
+`)},
+		"regionali/bonus-reg.json": {Data: []byte(bonusJSON("bonus-reg", `, "regioni": ["Lazio"], "soglia_isee": 20000`))},
+	}
+	c, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("errore inatteso: %v", err)
+	}
+	if len(c.National) != 1 || c.National[0].Regole == nil || c.National[0].Regole.Idoneita[0] != "affittuario" {
+		t.Errorf("bonus YAML non caricato correttamente: %+v", c.National)
+	}
+	if len(c.Regional) != 1 || c.Regional[0].SogliaISEE != 20000 {
+		t.Errorf("bonus regionale non caricato correttamente: %+v", c.Regional)
+	}
+}
+
+func TestLoad_ErroriConFileECampo(t *testing.T) {
+	fsys := fstest.MapFS{
+		"nazionali/ok.json":            {Data: []byte(bonusJSON("ok", ", "+regole))},
+		"nazionali/sconosciuto.json":   {Data: []byte(bonusJSON("sconosciuto", `, "scadenzza": "x", `+regole))},
+		"nazionali/tipo.json":          {Data: []byte(bonusJSON("tipo", `, "soglia_isee": "alta", `+regole))},
+		"nazionali/altro-id.json":      {Data: []byte(bonusJSON("diverso", ", "+regole))},
+		"nazionali/calcolato.json":     {Data: []byte(bonusJSON("calcolato", `, "compatibilita": 90, `+regole))},
+		"regionali/senza-regioni.json": {Data: []byte(bonusJSON("senza-regioni", ""))},
+		"regionali/categoria.yml":      {Data: []byte("id: categoria\nnome: x\ncategoria: boh\nregioni: [Lazio]\n")},
+		"nazionali/termine.json":       {Data: []byte(bonusJSON("termine", `, "termine": {"tipo": "data_fissa"}, `+regole))},
+		"nazionali/relazioni.json":     {Data: []byte(bonusJSON("relazioni", `, "relazioni": {"richiede": ["ok"], "esclude": ["inesistente"]}, `+regole))},
+		"nazionali/riduce.json":        {Data: []byte(bonusJSON("riduce", `, "relazioni": {"riduce": [{"bonus": "ok", "importo_massimo": -1}]}, `+regole))},
+	}
+	_, err := Load(fsys)
+	var le LoadError
+	if !errors.As(err, &le) {
+		t.Fatalf("atteso LoadError, ottenuto %v", err)
+	}
+	want := map[string]string{
+		"nazionali/sconosciuto.json":   "scadenzza",
+		"nazionali/tipo.json":          "soglia_isee",
+		"nazionali/altro-id.json":      "id",
+		"nazionali/calcolato.json":     "compatibilita",
+		"regionali/senza-regioni.json": "regioni",
+		"regionali/categoria.yml":      "categoria",
+		"nazionali/termine.json":       "termine",
+		"nazionali/relazioni.json":     "relazioni",
+		"nazionali/riduce.json":        "relazioni",
+	}
+	for file, field := range want {
+		found := false
+		for _, fe := range le {
+			if fe.File == file && fe.Field == field {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("manca errore per %s campo %s in: %v", file, field, err)
+		}
+	}
+	for _, fe := range le {
+		if fe.File == "nazionali/ok.json" {
+			t.Errorf("file valido segnalato come errato: %v", fe)
+		}
+	}
+}
+
+func TestReload_MantieneCatalogoValido(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{dirNational, dirRegional} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := filepath.Join(dir, dirNational, "ok.json")
+	write := func(s string) {
+		if err := os.WriteFile(file, []byte(s), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(bonusJSON("ok", ", "+regole))
+	if err := Init(dir); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	reloaded := 0
+	OnReload = func() { reloaded++ }
+	defer func() { OnReload = nil }()
+
+	write(strings.Replace(bonusJSON("ok", ", "+regole), `"Test"`, `"Nuovo nome"`, 1))
+	reload(dir)
+	if got := National()[0].Nome; got != "Nuovo nome" || reloaded != 1 {
+		t.Errorf("reload non applicato: nome %q, callback %d", got, reloaded)
+	}
+
+	write(`{"id": "ok"`)
+	reload(dir)
+	if got := National()[0].Nome; got != "Nuovo nome" {
+		t.Errorf("un file non valido non deve sostituire il catalogo (nome %q)", got)
+	}
+
+	before, _ := fingerprint(dir)
+	time.Sleep(10 * time.Millisecond)
+	write(bonusJSON("ok", ", "+regole))
+	after, err := fingerprint(dir)
+	if err != nil || after == before {
+		t.Errorf("la modifica di un file deve cambiare l'impronta della directory (%v)", err)
+	}
+
+	// Anche i bonus comunali, la cui directory è facoltativa
+	if err := os.MkdirAll(filepath.Join(dir, dirLocal), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, dirLocal, "tari.json"), []byte(bonusJSON("tari", `, "comuni": ["Roma"]`)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if comunali, _ := fingerprint(dir); comunali == after {
+		t.Error("un bonus comunale aggiunto deve cambiare l'impronta della directory")
+	}
+}
+
+func TestLoad_Comunali(t *testing.T) {
+	fsys := fstest.MapFS{
+		"nazionali/ok.json":            {Data: []byte(bonusJSON("ok", ", "+regole))},
+		"regionali/reg.json":           {Data: []byte(bonusJSON("reg", `, "regioni": ["Trentino-Alto Adige"], "province": ["BZ"]`))},
+		"comunali/tari-roma.yaml":      {Data: []byte("id: tari-roma\nnome: x\ncategoria: casa\ndescrizione: d\nimporto: x\nscadenza: x\nlink_ufficiale: https://example.org\nente: Roma Capitale\ncomuni: [Roma]\n")},
+		"comunali/senza-comuni.json":   {Data: []byte(bonusJSON("senza-comuni", ""))},
+		"comunali/comune-ignoto.json":  {Data: []byte(bonusJSON("comune-ignoto", `, "comuni": ["Atlantide"]`))},
+		"comunali/regione-errata.json": {Data: []byte(bonusJSON("regione-errata", `, "comuni": ["Milano"], "regioni": ["Lazio"]`))},
+	}
+	_, err := Load(fsys)
+	var le LoadError
+	if !errors.As(err, &le) {
+		t.Fatalf("atteso LoadError, ottenuto %v", err)
+	}
+	want := map[string]string{
+		"comunali/senza-comuni.json":   "comuni",
+		"comunali/comune-ignoto.json":  "comuni",
+		"comunali/regione-errata.json": "regioni",
+	}
+	if len(le) != len(want) {
+		t.Errorf("attesi %d errori, ottenuti: %v", len(want), err)
+	}
+	for _, fe := range le {
+		if want[fe.File] != fe.Field {
+			t.Errorf("errore inatteso: %v", fe)
+		}
+	}
+
+	for _, f := range []string{"comunali/senza-comuni.json", "comunali/comune-ignoto.json", "comunali/regione-errata.json"} {
+		delete(fsys, f)
+	}
+	c, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("errore inatteso: %v", err)
+	}
+	for _, b := range c.Regional {
+		switch b.ID {
+		case "reg":
+			if len(b.ProvinceApplicabili) != 1 || b.ProvinceApplicabili[0] != "021" {
+				t.Errorf("provincia non normalizzata: %v", b.ProvinceApplicabili)
+			}
+		case "tari-roma":
+			if len(b.ComuniApplicabili) != 1 || b.ComuniApplicabili[0] != "058091" {
+				t.Errorf("comune non normalizzato: %v", b.ComuniApplicabili)
+			}
+			if len(b.RegioniApplicabili) != 1 || b.RegioniApplicabili[0] != "Lazio" {
+				t.Errorf("regione non derivata dal comune: %v", b.RegioniApplicabili)
+			}
+		}
+	}
+}
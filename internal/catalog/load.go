@@ -0,0 +1,349 @@
+package catalog
+
+import (
+	"bonusperme/internal/deadline"
+	"bonusperme/internal/istat"
+	"bonusperme/internal/models"
+	"bonusperme/internal/regions"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	dirNational = "nazionali"
+	dirRegional = "regionali"
+	dirLocal    = "comunali"
+)
+
+// validCategorie lists the categories accepted in the catalogue.
+var validCategorie = map[string]bool{
+	"famiglia": true, "casa": true, "istruzione": true, "trasporti": true,
+	"salute": true, "lavoro": true, "sostegno": true, "spesa": true, "altro": true,
+}
+
+// requiredFields must be present and non-empty in every file.
+var requiredFields = []string{"id", "nome", "categoria", "descrizione", "importo", "scadenza", "link_ufficiale", "ente"}
+
+// computedFields are filled at runtime and must not be set in the catalogue.
+var computedFields = []string{
+	"compatibilita", "scaduto", "importo_reale", "link_verificato", "link_verificato_al",
+	"scadenza_domanda", "tipo_scadenza", "anno_conferma", "ultima_verifica",
+	"stato_validita", "motivo_stato", "conflitti", "fuori_combinazione", "nota_combinazione",
+}
+
+// FieldError is a validation error located in a catalogue file.
+type FieldError struct {
+	File  string
+	Field string
+	Msg   string
+}
+
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %s", e.File, e.Msg)
+	}
+	return fmt.Sprintf("%s: campo %q: %s", e.File, e.Field, e.Msg)
+}
+
+// LoadError collects every validation error found while loading a catalogue.
+type LoadError []FieldError
+
+func (e LoadError) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return fmt.Sprintf("catalogo non valido (%d errori): %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Load reads and validates all bonus files in fsys.
+// It returns a LoadError listing every invalid file and field.
+func Load(fsys fs.FS) (*Catalog, error) {
+	var errs LoadError
+	c := &Catalog{LoadedAt: time.Now()}
+	seen := make(map[string]string)
+
+	for _, dir := range []string{dirNational, dirRegional, dirLocal} {
+		entries, err := fs.ReadDir(fsys, dir)
+		if err != nil {
+			// Municipal bonuses are optional
+			if dir != dirLocal {
+				errs = append(errs, FieldError{File: dir, Msg: "directory mancante"})
+			}
+			continue
+		}
+		for _, e := range entries {
+			if e.IsDir() || !isDataFile(e.Name()) {
+				continue
+			}
+			file := path.Join(dir, e.Name())
+			b, ferrs := loadFile(fsys, file, dir)
+			errs = append(errs, ferrs...)
+			if len(ferrs) > 0 {
+				continue
+			}
+			if prev, ok := seen[b.ID]; ok {
+				errs = append(errs, FieldError{File: file, Field: "id", Msg: "duplicato di " + prev})
+				continue
+			}
+			seen[b.ID] = file
+			// Municipal and provincial bonuses are regional bonuses restricted further
+			if dir == dirNational {
+				c.National = append(c.National, b)
+			} else {
+				c.Regional = append(c.Regional, b)
+			}
+		}
+	}
+
+	errs = append(errs, checkRelazioni(append(c.National, c.Regional...), seen)...)
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return c, nil
+}
+
+// checkRelazioni reports relations to bonuses missing from the catalogue;
+// files maps each loaded id to its file.
+func checkRelazioni(bonuses []models.Bonus, files map[string]string) []FieldError {
+	var errs []FieldError
+	for _, b := range bonuses {
+		r := b.Relazioni
+		if r == nil {
+			continue
+		}
+		ids := append(append([]string{}, r.Richiede...), r.Esclude...)
+		for _, rid := range r.Riduce {
+			ids = append(ids, rid.Bonus)
+		}
+		for _, id := range ids {
+			if _, ok := files[id]; !ok {
+				errs = append(errs, FieldError{File: files[b.ID], Field: "relazioni", Msg: fmt.Sprintf("bonus %q non presente nel catalogo", id)})
+			}
+		}
+	}
+	return errs
+}
+
+func isDataFile(name string) bool {
+	switch path.Ext(name) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// loadFile parses a single bonus file from dir and checks it against the schema.
+func loadFile(fsys fs.FS, file, dir string) (models.Bonus, []FieldError) {
+	var b models.Bonus
+	fail := func(field, msg string) []FieldError {
+		return []FieldError{{File: file, Field: field, Msg: msg}}
+	}
+
+	data, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return b, fail("", err.Error())
+	}
+
+	// Decode into a generic map first so YAML and JSON share the same checks
+	var raw map[string]interface{}
+	if path.Ext(file) == ".json" {
+		err = json.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return b, fail("", "sintassi non valida: "+err.Error())
+	}
+	if raw == nil {
+		return b, fail("", "file vuoto")
+	}
+
+	var errs []FieldError
+	for _, f := range computedFields {
+		if _, ok := raw[f]; ok {
+			errs = append(errs, FieldError{File: file, Field: f, Msg: "campo calcolato, non va impostato nel catalogo"})
+		}
+	}
+
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return b, fail("", err.Error())
+	}
+	dec := json.NewDecoder(bytes.NewReader(normalized))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&b); err != nil {
+		return b, append(errs, decodeError(file, err))
+	}
+
+	for _, f := range requiredFields {
+		if s, _ := raw[f].(string); strings.TrimSpace(s) == "" {
+			errs = append(errs, FieldError{File: file, Field: f, Msg: "obbligatorio"})
+		}
+	}
+	if stem := strings.TrimSuffix(path.Base(file), path.Ext(file)); b.ID != "" && b.ID != stem {
+		errs = append(errs, FieldError{File: file, Field: "id", Msg: fmt.Sprintf("%q non corrisponde al nome del file", b.ID)})
+	}
+	if b.Categoria != "" && !validCategorie[b.Categoria] {
+		errs = append(errs, FieldError{File: file, Field: "categoria", Msg: fmt.Sprintf("%q non ammessa (valori: %s)", b.Categoria, strings.Join(sortedKeys(validCategorie), ", "))})
+	}
+	if b.LinkUfficiale != "" && !strings.HasPrefix(b.LinkUfficiale, "https://") && !strings.HasPrefix(b.LinkUfficiale, "http://") {
+		errs = append(errs, FieldError{File: file, Field: "link_ufficiale", Msg: "deve essere un URL http(s)"})
+	}
+	if b.SogliaISEE < 0 {
+		errs = append(errs, FieldError{File: file, Field: "soglia_isee", Msg: "non può essere negativa"})
+	}
+	if b.Termine != nil {
+		if err := deadline.Validate(*b.Termine); err != nil {
+			errs = append(errs, FieldError{File: file, Field: "termine", Msg: err.Error()})
+		}
+	}
+	if r := b.Relazioni; r != nil {
+		for _, id := range append(append([]string{}, r.Richiede...), r.Esclude...) {
+			if id == b.ID {
+				errs = append(errs, FieldError{File: file, Field: "relazioni", Msg: "un bonus non può riferirsi a se stesso"})
+			}
+		}
+		for _, rid := range r.Riduce {
+			switch {
+			case rid.Bonus == "" || rid.Bonus == b.ID:
+				errs = append(errs, FieldError{File: file, Field: "relazioni", Msg: "riduce: bonus mancante o uguale a se stesso"})
+			case rid.ImportoMassimo < 0:
+				errs = append(errs, FieldError{File: file, Field: "relazioni", Msg: "riduce: importo_massimo non può essere negativo"})
+			}
+		}
+	}
+	if b.TipoISEE != "" && !contains(models.TipiISEE, b.TipoISEE) {
+		errs = append(errs, FieldError{File: file, Field: "tipo_isee", Msg: fmt.Sprintf("%q non ammesso (valori: %s)", b.TipoISEE, strings.Join(models.TipiISEE, ", "))})
+	}
+	switch dir {
+	case dirNational:
+		for _, f := range []struct {
+			name string
+			v    []string
+		}{{"regioni", b.RegioniApplicabili}, {"comuni", b.ComuniApplicabili}, {"province", b.ProvinceApplicabili}} {
+			if len(f.v) > 0 {
+				errs = append(errs, FieldError{File: file, Field: f.name, Msg: "non ammesso per i bonus nazionali"})
+			}
+		}
+	case dirRegional:
+		if len(b.RegioniApplicabili) == 0 {
+			errs = append(errs, FieldError{File: file, Field: "regioni", Msg: "obbligatorio per i bonus regionali"})
+		}
+	case dirLocal:
+		if len(b.ComuniApplicabili) == 0 && len(b.ProvinceApplicabili) == 0 {
+			errs = append(errs, FieldError{File: file, Field: "comuni", Msg: "obbligatorio indicare comuni o province per i bonus comunali"})
+		}
+	}
+	for i, r := range b.RegioniApplicabili {
+		reg, ok := regions.Find(r)
+		if !ok {
+			errs = append(errs, FieldError{File: file, Field: "regioni", Msg: fmt.Sprintf("regione %q sconosciuta", r)})
+			continue
+		}
+		b.RegioniApplicabili[i] = reg.Nome
+	}
+	if dir != dirNational {
+		errs = append(errs, normalizeLocalita(file, &b)...)
+	}
+	// Regional bonuses without rules fall back to the category defaults
+	if dir == dirNational && b.Regole == nil {
+		errs = append(errs, FieldError{File: file, Field: "regole", Msg: "obbligatorio per i bonus nazionali"})
+	}
+	if b.Regole != nil && ValidateRules != nil {
+		if err := ValidateRules(b.Regole); err != nil {
+			errs = append(errs, FieldError{File: file, Field: "regole", Msg: err.Error()})
+		}
+	}
+	return b, errs
+}
+
+// normalizeLocalita replaces municipality and province names with their ISTAT
+// codes and fills regioni from them when it is not set.
+func normalizeLocalita(file string, b *models.Bonus) []FieldError {
+	var errs []FieldError
+	var regioni []string
+	addRegione := func(r string) {
+		for _, x := range regioni {
+			if x == r {
+				return
+			}
+		}
+		regioni = append(regioni, r)
+	}
+	for i, s := range b.ComuniApplicabili {
+		c, ok := istat.FindComune(s)
+		if !ok {
+			errs = append(errs, FieldError{File: file, Field: "comuni", Msg: fmt.Sprintf("comune %q non presente nella tabella ISTAT", s)})
+			continue
+		}
+		b.ComuniApplicabili[i] = c.Codice
+		addRegione(c.Regione)
+	}
+	for i, s := range b.ProvinceApplicabili {
+		p, ok := istat.FindProvincia(s)
+		if !ok {
+			errs = append(errs, FieldError{File: file, Field: "province", Msg: fmt.Sprintf("provincia %q non presente nella tabella ISTAT", s)})
+			continue
+		}
+		b.ProvinceApplicabili[i] = p.Codice
+		addRegione(p.Regione)
+	}
+	if len(b.RegioniApplicabili) == 0 {
+		b.RegioniApplicabili = regioni
+		return errs
+	}
+	for _, r := range regioni {
+		found := false
+		for _, x := range b.RegioniApplicabili {
+			if regions.Same(x, r) {
+				found = true
+			}
+		}
+		if !found {
+			errs = append(errs, FieldError{File: file, Field: "regioni", Msg: fmt.Sprintf("non include %s, regione dei comuni o delle province indicati", r)})
+		}
+	}
+	return errs
+}
+
+// decodeError maps encoding/json errors to the offending field.
+func decodeError(file string, err error) FieldError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return FieldError{File: file, Field: typeErr.Field, Msg: "tipo non valido, atteso " + typeErr.Type.String()}
+	}
+	msg := err.Error()
+	if strings.HasPrefix(msg, "json: unknown field ") {
+		return FieldError{File: file, Field: strings.Trim(strings.TrimPrefix(msg, "json: unknown field "), `"`), Msg: "campo sconosciuto"}
+	}
+	return FieldError{File: file, Msg: msg}
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -29,6 +30,8 @@ type Config struct {
 	// Scraper
 	ScraperEnabled  bool
 	ScraperInterval time.Duration
+	// Scraped changes from sources with Priority <= this value skip the review queue (0 = none)
+	ReviewAutoApprovePriority int
 
 	// Rate limiter
 	RateLimitRPS   int
@@ -39,15 +42,30 @@ type Config struct {
 	LinkCheckInterval time.Duration
 	LinkCheckDelay    time.Duration
 
-	// Data sources
-	DatasourceINPS    bool
-	DatasourceAdE     bool
-	DatasourceMISE    bool
-	DatasourceGU      bool
-	DatasourceOpenAPI bool
+	// Bonus catalogue
+	CatalogDir            string
+	CatalogReloadInterval time.Duration
+
+	// Persistent storage (empty = in memory)
+	StoragePath string
+
+	// Data sources: DATASOURCE_* variables keyed by the rest of the name
+	// ("INPS", "INPS_URLS"), read by the datasource registry
+	Datasources map[string]string
 
 	// HTTP
 	UserAgent string
+	// Crawler: politeness towards the sites we read
+	CrawlerHostDelay  time.Duration            // minimum interval between two requests to a host
+	CrawlerHostDelays map[string]time.Duration // per-host overrides, e.g. www.inps.it=5s
+	CrawlerRetries    int
+	CrawlerTimeout    time.Duration
+	CrawlerMaxBody    int // bytes
+	CrawlerRobots     bool
+	// Fixtures of third-party responses: record every response (one
+	// subdirectory per day) or replay a recorded directory without network
+	HTTPRecordDir string
+	HTTPReplayDir string
 
 	// Gzip
 	GzipEnabled bool
@@ -63,7 +81,10 @@ type Config struct {
 	ValidityCheckEnabled bool
 	NewsCheckEnabled     bool
 	NewsCheckInterval    time.Duration
-	AdminAPIKey          string
+	// Gazzetta Ufficiale watch: new acts amending the norms cited by a bonus
+	GUCheckEnabled  bool
+	GUCheckInterval time.Duration
+	AdminAPIKey     string
 }
 
 // Load reads .env (if present) and populates Cfg from environment variables.
@@ -85,6 +106,8 @@ func Load() {
 		ScraperEnabled:  envBool("SCRAPER_ENABLED", true),
 		ScraperInterval: envDuration("SCRAPER_INTERVAL", 24*time.Hour),
 
+		ReviewAutoApprovePriority: envInt("REVIEW_AUTO_APPROVE_PRIORITY", 1),
+
 		RateLimitRPS:   envInt("RATE_LIMIT_RPS", 30),
 		RateLimitBurst: envInt("RATE_LIMIT_BURST", 60),
 
@@ -92,14 +115,25 @@ func Load() {
 		LinkCheckInterval: envDuration("LINKCHECK_INTERVAL", 24*time.Hour),
 		LinkCheckDelay:    envDuration("LINKCHECK_DELAY", 5*time.Second),
 
-		DatasourceINPS:    envBool("DATASOURCE_INPS", true),
-		DatasourceAdE:     envBool("DATASOURCE_ADE", true),
-		DatasourceMISE:    envBool("DATASOURCE_MISE", true),
-		DatasourceGU:      envBool("DATASOURCE_GU", false),
-		DatasourceOpenAPI: envBool("DATASOURCE_OPENAPI", false),
+		CatalogDir:            os.Getenv("CATALOG_DIR"),
+		CatalogReloadInterval: envDuration("CATALOG_RELOAD_INTERVAL", 30*time.Second),
+
+		StoragePath: envOr("STORAGE_PATH", "bonusperme.db"),
+
+		Datasources: datasourceVars(),
 
 		UserAgent: envOr("USER_AGENT", "Mozilla/5.0 (compatible; BonusPerMeBot/1.0; +https://bonusperme.it)"),
 
+		CrawlerHostDelay:  envDuration("CRAWLER_HOST_DELAY", 2*time.Second),
+		CrawlerHostDelays: envDurations("CRAWLER_HOST_DELAYS", "www.inps.it=5s"),
+		CrawlerRetries:    envInt("CRAWLER_RETRIES", 3),
+		CrawlerTimeout:    envDuration("CRAWLER_TIMEOUT", 30*time.Second),
+		CrawlerMaxBody:    envInt("CRAWLER_MAX_BODY", 2<<20),
+		CrawlerRobots:     envBool("CRAWLER_ROBOTS", true),
+
+		HTTPRecordDir: os.Getenv("HTTP_RECORD_DIR"),
+		HTTPReplayDir: os.Getenv("HTTP_REPLAY_DIR"),
+
 		GzipEnabled: envBool("GZIP_ENABLED", true),
 
 		TurnstileSiteKey:   os.Getenv("TURNSTILE_SITE_KEY"),
@@ -110,6 +144,8 @@ func Load() {
 		ValidityCheckEnabled: envBool("VALIDITY_CHECK_ENABLED", true),
 		NewsCheckEnabled:     envBool("NEWS_CHECK_ENABLED", false),
 		NewsCheckInterval:    envDuration("NEWS_CHECK_INTERVAL", 6*time.Hour),
+		GUCheckEnabled:       envBool("GU_CHECK_ENABLED", true),
+		GUCheckInterval:      envDuration("GU_CHECK_INTERVAL", 12*time.Hour),
 		AdminAPIKey:          os.Getenv("ADMIN_API_KEY"),
 	}
 
@@ -166,3 +202,63 @@ func envDuration(key string, fallback time.Duration) time.Duration {
 	}
 	return d
 }
+
+// envDurations parses a comma-separated list of name=duration pairs.
+// Malformed pairs are skipped.
+func envDurations(key, fallback string) map[string]time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		v = fallback
+	}
+	m := make(map[string]time.Duration)
+	for _, pair := range strings.Split(v, ",") {
+		name, dur, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(dur))
+		if err != nil {
+			continue
+		}
+		m[strings.ToLower(strings.TrimSpace(name))] = d
+	}
+	return m
+}
+
+// fontiRinominate maps the old IDs of renamed data sources to the new ones.
+var fontiRinominate = map[string]string{
+	"OPENAPI": "OPENDATA", // renamed after the opendata source id
+}
+
+// datasourceVars returns the DATASOURCE_* variables. Those of renamed sources
+// still apply under the new name, with a deprecation warning, unless the new
+// variable is set too.
+func datasourceVars() map[string]string {
+	vars := envPrefixed("DATASOURCE_")
+	for name, v := range vars {
+		for vecchio, nuovo := range fontiRinominate {
+			rest, ok := strings.CutPrefix(name, vecchio)
+			if !ok || (rest != "" && rest[0] != '_') {
+				continue
+			}
+			log.Printf("config: DATASOURCE_%s is deprecated, use DATASOURCE_%s%s", name, nuovo, rest)
+			if _, set := vars[nuovo+rest]; !set {
+				vars[nuovo+rest] = v
+			}
+		}
+	}
+	return vars
+}
+
+// envPrefixed returns the variables starting with prefix, keyed by the rest
+// of their name.
+func envPrefixed(prefix string) map[string]string {
+	vars := make(map[string]string)
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if name, ok := strings.CutPrefix(k, prefix); ok && name != "" {
+			vars[name] = v
+		}
+	}
+	return vars
+}
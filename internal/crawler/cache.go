@@ -0,0 +1,40 @@
+package crawler
+
+import (
+	"bonusperme/internal/logger"
+	"bonusperme/internal/storage"
+	"net/http"
+	"time"
+)
+
+// cacheEntry is a page kept for conditional requests, with its validators.
+type cacheEntry struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Body         []byte    `json:"body"`
+	Saved        time.Time `json:"saved"`
+}
+
+// loadEntry returns the cached page for url, or nil.
+func loadEntry(url string) *cacheEntry {
+	var v cacheEntry
+	if found, err := storage.Get(storage.BucketCrawler, url, &v); err != nil || !found {
+		return nil
+	}
+	return &v
+}
+
+// storeEntry caches a page served with validators; a page without them is
+// forgotten, as it can no longer be revalidated.
+func storeEntry(url string, hdr http.Header, body []byte) {
+	v := cacheEntry{ETag: hdr.Get("ETag"), LastModified: hdr.Get("Last-Modified"), Body: body, Saved: time.Now()}
+	var err error
+	if v.ETag == "" && v.LastModified == "" {
+		err = storage.Delete(storage.BucketCrawler, url)
+	} else {
+		err = storage.Put(storage.BucketCrawler, url, v)
+	}
+	if err != nil {
+		logger.Warn("crawler: cannot update validator cache", map[string]interface{}{"url": url, "error": err.Error()})
+	}
+}
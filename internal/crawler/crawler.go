@@ -0,0 +1,309 @@
+// Package crawler is the HTTP client shared by every component that reads
+// third-party sites: scraper, official data sources, regional portals, news
+// feeds, Gazzetta Ufficiale and link checker.
+//
+// Every request goes through the same policy: robots.txt is honoured, requests
+// to a host are spaced by a minimum delay (longer if the host asks for it with
+// Crawl-delay or Retry-After), transient failures are retried with jittered
+// exponential backoff, bodies are size-limited, and GET responses carrying an
+// ETag or Last-Modified are revalidated with a conditional request, so an
+// unchanged page costs the site a 304.
+package crawler
+
+import (
+	"bonusperme/internal/config"
+	"bonusperme/internal/logger"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	defaultMaxBody = 2 << 20
+	// maxRetryAfter caps the wait asked by a Retry-After header.
+	maxRetryAfter = 2 * time.Minute
+)
+
+var (
+	// ErrDisallowed is returned for URLs excluded by the site's robots.txt.
+	ErrDisallowed = errors.New("crawler: disallowed by robots.txt")
+	// ErrTooLarge is returned for bodies over the configured size limit.
+	ErrTooLarge = errors.New("crawler: response too large")
+)
+
+// StatusError is returned for responses other than 200 (or 304 for a cached page).
+type StatusError struct {
+	URL  string
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTP %d from %s", e.Code, e.URL)
+}
+
+// backoffBase is the wait before the first retry, doubled at each attempt.
+var backoffBase = time.Second
+
+var client = &http.Client{
+	Transport: delegate{},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("too many redirects")
+		}
+		return nil
+	},
+}
+
+var (
+	transportMu sync.RWMutex
+	transport   http.RoundTripper = http.DefaultTransport
+)
+
+// Use replaces the transport of every request, e.g. with a replay.Player in
+// tests. nil restores the network.
+func Use(rt http.RoundTripper) {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	transportMu.Lock()
+	transport = rt
+	transportMu.Unlock()
+}
+
+// delegate sends requests through the transport set by Use.
+type delegate struct{}
+
+func (delegate) RoundTrip(req *http.Request) (*http.Response, error) {
+	transportMu.RLock()
+	rt := transport
+	transportMu.RUnlock()
+	return rt.RoundTrip(req)
+}
+
+// host is the politeness state of a site.
+type host struct {
+	mu   sync.Mutex
+	next time.Time // earliest start of the next request
+
+	robotsMu sync.Mutex
+	robots   *robotsRules
+	fetched  time.Time // when robots was fetched
+}
+
+var hosts sync.Map // host name -> *host
+
+func hostOf(u *url.URL) *host {
+	h, _ := hosts.LoadOrStore(strings.ToLower(u.Host), &host{})
+	return h.(*host)
+}
+
+// waitTurn reserves the next slot for a request to the host and sleeps until it.
+func (h *host) waitTurn(ctx context.Context, delay time.Duration) error {
+	h.mu.Lock()
+	now := time.Now()
+	at := h.next
+	if at.Before(now) {
+		at = now
+	}
+	h.next = at.Add(delay)
+	h.mu.Unlock()
+
+	if wait := time.Until(at); wait > 0 {
+		t := time.NewTimer(wait)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// slowDown keeps the host idle for d, after a 429 or 503.
+func (h *host) slowDown(d time.Duration) {
+	h.mu.Lock()
+	if t := time.Now().Add(d); t.After(h.next) {
+		h.next = t
+	}
+	h.mu.Unlock()
+}
+
+// Get downloads url. A page whose validators are cached is requested
+// conditionally; on 304 the cached body is returned.
+func Get(rawURL string) ([]byte, error) {
+	return fetch(context.Background(), http.MethodGet, rawURL)
+}
+
+// Head returns the status code of url, following redirects.
+func Head(rawURL string) (int, error) {
+	_, err := fetch(context.Background(), http.MethodHead, rawURL)
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Code, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return http.StatusOK, nil
+}
+
+func fetch(ctx context.Context, method, rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("crawler: unsupported URL %q", rawURL)
+	}
+	h := hostOf(u)
+
+	r := h.rules(ctx, u)
+	if !r.allowed(u) {
+		return nil, ErrDisallowed
+	}
+	delay := hostDelay(u.Hostname())
+	if r != nil && r.delay > delay {
+		delay = r.delay
+	}
+
+	var cached *cacheEntry
+	if method == http.MethodGet {
+		cached = loadEntry(rawURL)
+	}
+
+	attempts := config.Cfg.CrawlerRetries + 1
+	for i := 0; ; i++ {
+		if err := h.waitTurn(ctx, delay); err != nil {
+			return nil, err
+		}
+		body, retry, err := attempt(ctx, method, rawURL, cached, h)
+		if err == nil || retry < 0 || i+1 >= attempts {
+			return body, err
+		}
+		wait := backoffBase << i
+		wait = wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+		if retry > wait {
+			wait = retry
+		}
+		logger.Warn("crawler: retrying", map[string]interface{}{
+			"url": rawURL, "attempt": i + 1, "wait": wait.String(), "error": err.Error(),
+		})
+		h.slowDown(wait)
+	}
+}
+
+// attempt makes a single request. retry is negative when the error is final,
+// otherwise the minimum wait asked by the server (0 if none).
+func attempt(ctx context.Context, method, rawURL string, cached *cacheEntry, h *host) (body []byte, retry time.Duration, err error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, -1, err
+	}
+	req.Header.Set("User-Agent", config.Cfg.UserAgent)
+	req.Header.Set("Accept-Language", "it-IT,it;q=0.9")
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	switch code := resp.StatusCode; {
+	case code == http.StatusOK:
+		if method == http.MethodHead {
+			return nil, 0, nil
+		}
+		body, err := readBody(resp.Body)
+		if err != nil {
+			return nil, -1, err
+		}
+		storeEntry(rawURL, resp.Header, body)
+		return body, 0, nil
+	case code == http.StatusNotModified && cached != nil:
+		return cached.Body, 0, nil
+	case code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable:
+		wait := retryAfter(resp.Header.Get("Retry-After"))
+		h.slowDown(wait)
+		return nil, wait, &StatusError{URL: rawURL, Code: code}
+	case code == http.StatusInternalServerError || code == http.StatusBadGateway || code == http.StatusGatewayTimeout:
+		return nil, 0, &StatusError{URL: rawURL, Code: code}
+	default:
+		return nil, -1, &StatusError{URL: rawURL, Code: code}
+	}
+}
+
+func readBody(r io.Reader) ([]byte, error) {
+	limit := int64(config.Cfg.CrawlerMaxBody)
+	if limit <= 0 {
+		limit = defaultMaxBody
+	}
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, ErrTooLarge
+	}
+	return body, nil
+}
+
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date.
+func retryAfter(v string) time.Duration {
+	var d time.Duration
+	if s, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+		d = time.Duration(s) * time.Second
+	} else if t, err := http.ParseTime(v); err == nil {
+		d = time.Until(t)
+	}
+	if d < 0 {
+		return 0
+	}
+	if d > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return d
+}
+
+func timeout() time.Duration {
+	if config.Cfg.CrawlerTimeout > 0 {
+		return config.Cfg.CrawlerTimeout
+	}
+	return defaultTimeout
+}
+
+// hostDelay is the configured delay between two requests to a host: the
+// per-host override if any (also matching the parent domain), else the default.
+func hostDelay(name string) time.Duration {
+	name = strings.ToLower(name)
+	for n := name; n != ""; {
+		if d, ok := config.Cfg.CrawlerHostDelays[n]; ok {
+			return d
+		}
+		i := strings.IndexByte(n, '.')
+		if i < 0 {
+			break
+		}
+		n = n[i+1:]
+	}
+	return config.Cfg.CrawlerHostDelay
+}
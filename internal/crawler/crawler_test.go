@@ -0,0 +1,171 @@
+package crawler
+
+import (
+	"bonusperme/internal/config"
+	"bonusperme/internal/storage"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// setup configures a fast, polite crawler on an empty store.
+func setup(t *testing.T) {
+	t.Helper()
+	prev := config.Cfg
+	storage.Use(storage.NewMemory())
+	config.Cfg.UserAgent = "Mozilla/5.0 (compatible; BonusPerMeBot/1.0)"
+	config.Cfg.CrawlerRobots = true
+	config.Cfg.CrawlerRetries = 2
+	config.Cfg.CrawlerHostDelay = 0
+	config.Cfg.CrawlerMaxBody = 1024
+	backoffBase = time.Millisecond
+	t.Cleanup(func() {
+		config.Cfg = prev
+		backoffBase = time.Second
+		storage.Use(storage.NewMemory())
+	})
+}
+
+func TestGetConditional(t *testing.T) {
+	setup(t)
+	var richieste, nonModificate int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(&richieste, 1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&nonModificate, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("pagina"))
+	}))
+	defer srv.Close()
+
+	for i := 0; i < 2; i++ {
+		body, err := Get(srv.URL + "/bonus")
+		if err != nil || string(body) != "pagina" {
+			t.Fatalf("richiesta %d: %q, %v", i+1, body, err)
+		}
+	}
+	if richieste != 2 || nonModificate != 1 {
+		t.Errorf("richieste = %d, 304 = %d; attese 2 e 1", richieste, nonModificate)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	setup(t)
+	var richieste int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		switch atomic.AddInt32(&richieste, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer srv.Close()
+
+	if body, err := Get(srv.URL + "/a"); err != nil || string(body) != "ok" {
+		t.Fatalf("dopo 429 e 502: %q, %v", body, err)
+	}
+
+	// Un 404 non si ritenta
+	atomic.StoreInt32(&richieste, 10)
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&richieste, 1)
+		http.NotFound(w, r)
+	})
+	_, err := Get(srv.URL + "/b")
+	var se *StatusError
+	if !errors.As(err, &se) || se.Code != http.StatusNotFound || richieste != 11 {
+		t.Errorf("404: err = %v, richieste = %d", err, richieste-10)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	setup(t)
+	config.Cfg.CrawlerHostDelay = 50 * time.Millisecond
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/grande" {
+			w.Write([]byte(strings.Repeat("x", 2048)))
+			return
+		}
+		w.Write([]byte("User-agent: *\nDisallow: /privato\n"))
+	}))
+	defer srv.Close()
+
+	// robots.txt, /a e /b: tre richieste distanziate
+	start := time.Now()
+	Get(srv.URL + "/a")
+	Get(srv.URL + "/b")
+	if d := time.Since(start); d < 100*time.Millisecond {
+		t.Errorf("tre richieste in %v, attese distanziate di 50ms", d)
+	}
+	if _, err := Get(srv.URL + "/privato/pagina"); err != ErrDisallowed {
+		t.Errorf("pagina esclusa da robots.txt: err = %v", err)
+	}
+	if _, err := Get(srv.URL + "/grande"); err != ErrTooLarge {
+		t.Errorf("risposta oltre il limite: err = %v", err)
+	}
+}
+
+func TestRobots(t *testing.T) {
+	robots := `# regole di prova
+User-agent: Googlebot
+Disallow: /
+
+User-agent: *
+Disallow: /cerca
+Crawl-delay: 5
+
+User-agent: bonuspermebot
+User-agent: altrobot
+Disallow: /area-riservata/
+Allow: /area-riservata/pubblica
+Disallow: /*.pdf$
+Crawl-delay: 3
+`
+	r := parseRobots(robots, productToken("Mozilla/5.0 (compatible; BonusPerMeBot/1.0; +https://bonusperme.it)"))
+	if r.delay != 3*time.Second {
+		t.Errorf("Crawl-delay = %v, atteso quello del nostro gruppo (3s)", r.delay)
+	}
+	casi := map[string]bool{
+		"/":                               true,
+		"/cerca?q=bonus":                  true, // regola del gruppo *, non nostra
+		"/area-riservata/dati":            false,
+		"/area-riservata/pubblica/bonus":  true,
+		"/moduli/domanda.pdf":             false,
+		"/moduli/domanda.pdf?download=1":  true,
+		"/it/dettaglio-scheda.bonus-nido": true,
+		"/area-riservata":                 true,
+	}
+	for path, want := range casi {
+		u, _ := url.Parse("https://www.example.it" + path)
+		if got := r.allowed(u); got != want {
+			t.Errorf("%s: allowed = %v, atteso %v", path, got, want)
+		}
+	}
+
+	// Senza un gruppo per il nostro agente valgono le regole di *
+	r = parseRobots(robots, "AltroCrawler")
+	u, _ := url.Parse("https://www.example.it/cerca")
+	if r.allowed(u) || r.delay != 5*time.Second {
+		t.Errorf("gruppo *: allowed /cerca = %v, delay = %v", r.allowed(u), r.delay)
+	}
+}
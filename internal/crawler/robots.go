@@ -0,0 +1,213 @@
+package crawler
+
+import (
+	"bonusperme/internal/config"
+	"bonusperme/internal/logger"
+	"bufio"
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// robotsTTL is how long a robots.txt is trusted.
+	robotsTTL = 24 * time.Hour
+	// robotsRetry is how long an unreachable robots.txt keeps the site
+	// closed before it is asked again.
+	robotsRetry = time.Hour
+	// maxCrawlDelay caps the Crawl-delay a site can ask for.
+	maxCrawlDelay = time.Minute
+)
+
+// robotsRules are the robots.txt rules that apply to our user agent.
+type robotsRules struct {
+	allow, disallow []string
+	delay           time.Duration
+	closed          bool // robots.txt unreachable: nothing may be fetched
+}
+
+// allowed reports whether u may be fetched: the longest matching rule
+// wins, Allow on ties (RFC 9309).
+func (r *robotsRules) allowed(u *url.URL) bool {
+	if r == nil {
+		return true
+	}
+	if r.closed {
+		return false
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	best, allow := -1, true
+	for _, p := range r.disallow {
+		if len(p) > best && matchPattern(p, path) {
+			best, allow = len(p), false
+		}
+	}
+	for _, p := range r.allow {
+		if len(p) >= best && matchPattern(p, path) {
+			best, allow = len(p), true
+		}
+	}
+	return allow
+}
+
+// matchPattern matches a robots.txt path pattern, with * and a final $.
+func matchPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	last := parts[len(parts)-1]
+	if len(parts) > 1 {
+		for _, p := range parts[1 : len(parts)-1] {
+			i := strings.Index(path[pos:], p)
+			if i < 0 {
+				return false
+			}
+			pos += i + len(p)
+		}
+		if !anchored {
+			return strings.Contains(path[pos:], last)
+		}
+		return len(path)-len(last) >= pos && strings.HasSuffix(path, last)
+	}
+	return !anchored || pos == len(path)
+}
+
+// rules returns the robots.txt rules of the host of u, fetching them when
+// missing or stale.
+func (h *host) rules(ctx context.Context, u *url.URL) *robotsRules {
+	if !config.Cfg.CrawlerRobots {
+		return nil
+	}
+	// Concurrent requests to a new host wait for a single fetch
+	h.robotsMu.Lock()
+	defer h.robotsMu.Unlock()
+	ttl := robotsTTL
+	if h.robots != nil && h.robots.closed {
+		ttl = robotsRetry
+	}
+	if h.robots == nil || time.Since(h.fetched) >= ttl {
+		h.robots, h.fetched = fetchRobots(ctx, h, u), time.Now()
+	}
+	return h.robots
+}
+
+// fetchRobots fetches a robots.txt. A missing file allows everything; an
+// unreachable one (5xx, network error) disallows everything.
+func fetchRobots(ctx context.Context, h *host, u *url.URL) *robotsRules {
+	robotsURL := u.Scheme + "://" + u.Host + "/robots.txt"
+	if err := h.waitTurn(ctx, hostDelay(u.Hostname())); err != nil {
+		return &robotsRules{closed: true}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return &robotsRules{}
+	}
+	req.Header.Set("User-Agent", config.Cfg.UserAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.Warn("crawler: robots.txt unreachable", map[string]interface{}{"url": robotsURL, "error": err.Error()})
+		return &robotsRules{closed: true}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		logger.Warn("crawler: robots.txt unreachable", map[string]interface{}{"url": robotsURL, "status": resp.StatusCode})
+		return &robotsRules{closed: true}
+	case resp.StatusCode != http.StatusOK:
+		return &robotsRules{}
+	}
+	body, err := readBody(resp.Body)
+	if err != nil {
+		return &robotsRules{}
+	}
+	return parseRobots(string(body), productToken(config.Cfg.UserAgent))
+}
+
+var reProduct = regexp.MustCompile(`([A-Za-z][A-Za-z0-9_-]*)/[0-9]`)
+
+// productToken returns the token robots.txt groups are matched against:
+// "BonusPerMeBot" for "Mozilla/5.0 (compatible; BonusPerMeBot/1.0; ...)".
+func productToken(ua string) string {
+	for _, m := range reProduct.FindAllStringSubmatch(ua, -1) {
+		if !strings.EqualFold(m[1], "Mozilla") {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// parseRobots returns the rules of the groups naming agent, or of the "*"
+// groups if none does.
+func parseRobots(body, agent string) *robotsRules {
+	var ours, all robotsRules
+	found := false
+	var group []string // user agents of the current group
+	inRules := false
+
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		k, v = strings.ToLower(strings.TrimSpace(k)), strings.TrimSpace(v)
+
+		if k == "user-agent" {
+			if inRules {
+				group, inRules = nil, false
+			}
+			group = append(group, strings.ToLower(v))
+			continue
+		}
+		inRules = true
+		for _, a := range group {
+			var r *robotsRules
+			switch {
+			case agent != "" && a == strings.ToLower(agent):
+				r, found = &ours, true
+			case a == "*":
+				r = &all
+			default:
+				continue
+			}
+			switch k {
+			case "allow":
+				if v != "" {
+					r.allow = append(r.allow, v)
+				}
+			case "disallow":
+				if v != "" {
+					r.disallow = append(r.disallow, v)
+				}
+			case "crawl-delay":
+				if s, err := strconv.ParseFloat(v, 64); err == nil && s > 0 {
+					r.delay = min(time.Duration(s*float64(time.Second)), maxCrawlDelay)
+				}
+			}
+		}
+	}
+	if found {
+		return &ours
+	}
+	return &all
+}
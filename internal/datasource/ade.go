@@ -1,38 +1,45 @@
 package datasource
 
 import (
+	"bonusperme/internal/crawler"
+	"bonusperme/internal/extract"
 	"bonusperme/internal/models"
 	"fmt"
-	"net/http"
 	"strings"
 	"time"
 
 	"golang.org/x/net/html"
 )
 
-var adeURLs = []string{
-	"https://www.agenziaentrate.gov.it/portale/web/guest/aree-tematiche/casa/agevolazioni",
-	"https://www.agenziaentrate.gov.it/portale/web/guest/agevolazioni",
+func init() {
+	Register("ade", &AdESource{}, Config{
+		Enabled: true,
+		URLs: []string{
+			"https://www.agenziaentrate.gov.it/portale/web/guest/aree-tematiche/casa/agevolazioni",
+			"https://www.agenziaentrate.gov.it/portale/web/guest/agevolazioni",
+		},
+		Trust: 1,
+	})
 }
 
 // AdESource scrapes bonus data from Agenzia delle Entrate.
-type AdESource struct {
-	client *http.Client
-}
+// Option "dettagli" (default true) also reads the detail page of each bonus.
+type AdESource struct{}
 
 func (s *AdESource) Name() string    { return "AdE" }
-func (s *AdESource) Enabled() bool   { return true }
 
-func (s *AdESource) Fetch() ([]models.Bonus, error) {
+func (s *AdESource) Fetch(cfg Config) ([]models.Bonus, error) {
 	var all []models.Bonus
-	for _, url := range adeURLs {
-		body, err := fetchURL(s.client, url)
+	for _, url := range cfg.URLs {
+		body, err := crawler.Get(url)
 		if err != nil {
 			return nil, fmt.Errorf("AdE fetch %s: %w", url, err)
 		}
 		bonuses := parseAdEPage(body, url)
+		if cfg.OptionBool("dettagli", true) {
+			extract.Enrich(bonuses, crawler.Get)
+		}
 		all = append(all, bonuses...)
-		time.Sleep(2 * time.Second)
 	}
 	return all, nil
 }
@@ -1,63 +1,33 @@
 package datasource
 
 import (
-	"bonusperme/internal/config"
 	"bonusperme/internal/logger"
 	"bonusperme/internal/models"
 	"fmt"
-	"io"
-	"net/http"
 	"sync"
 	"time"
 )
 
-// DataSource defines the interface for an official data source.
+// DataSource defines the interface for an official data source. Sources
+// register themselves with Register; cfg is their current configuration.
 type DataSource interface {
 	Name() string
-	Enabled() bool
-	Fetch() ([]models.Bonus, error)
+	Fetch(cfg Config) ([]models.Bonus, error)
 }
 
-// Manager coordinates all data sources.
+// Manager coordinates the registered data sources.
 type Manager struct {
-	sources []DataSource
-	client  *http.Client
+	sources []*entry
 }
 
-// NewManager creates a Manager with all configured data sources.
+// NewManager creates a Manager with all registered data sources, enabled or not.
 func NewManager() *Manager {
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= 3 {
-				return http.ErrUseLastResponse
-			}
-			return nil
-		},
-	}
-
-	m := &Manager{client: client}
-
-	if config.Cfg.DatasourceINPS {
-		m.sources = append(m.sources, &INPSSource{client: client})
-	}
-	if config.Cfg.DatasourceAdE {
-		m.sources = append(m.sources, &AdESource{client: client})
-	}
-	if config.Cfg.DatasourceMISE {
-		m.sources = append(m.sources, &MISESource{client: client})
-	}
-	if config.Cfg.DatasourceGU {
-		m.sources = append(m.sources, &GURSSSource{client: client})
-	}
-	if config.Cfg.DatasourceOpenAPI {
-		m.sources = append(m.sources, &OpenDataSource{client: client})
-	}
-
-	return m
+	return &Manager{sources: registered()}
 }
 
-// FetchAll runs all enabled sources concurrently and returns combined results.
+// FetchAll runs the enabled sources that are due concurrently and returns
+// combined results. Sources fetched less than their Interval ago contribute
+// their last result.
 func (m *Manager) FetchAll() []models.Bonus {
 	var (
 		mu     sync.Mutex
@@ -65,71 +35,89 @@ func (m *Manager) FetchAll() []models.Bonus {
 		result []models.Bonus
 	)
 
-	for _, src := range m.sources {
-		if !src.Enabled() {
+	now := time.Now()
+	for _, e := range m.sources {
+		cfg := e.config()
+		if !cfg.Enabled {
+			continue
+		}
+		if !e.due(cfg, now) {
+			e.mu.Lock()
+			result = append(result, e.bonuses...)
+			e.mu.Unlock()
 			continue
 		}
 		wg.Add(1)
-		go func(s DataSource) {
+		go func(e *entry, cfg Config) {
 			defer wg.Done()
-			defer func() {
-				if r := recover(); r != nil {
-					logger.Warn("datasource: panic", map[string]interface{}{
-						"source": s.Name(), "error": fmt.Sprintf("%v", r),
-					})
-				}
-			}()
-
-			logger.Info("datasource: fetching", map[string]interface{}{"source": s.Name()})
-			bonuses, err := s.Fetch()
-			if err != nil {
-				logger.Warn("datasource: error", map[string]interface{}{
-					"source": s.Name(), "error": err.Error(),
-				})
-				return
-			}
-			logger.Info("datasource: done", map[string]interface{}{
-				"source": s.Name(), "count": len(bonuses),
-			})
-
+			bonuses := e.run(cfg)
 			mu.Lock()
 			result = append(result, bonuses...)
 			mu.Unlock()
-		}(src)
+		}(e, cfg)
 	}
 
 	wg.Wait()
 	return result
 }
 
-// Status returns a map of source name -> status info.
-func (m *Manager) Status() map[string]interface{} {
-	info := make(map[string]interface{})
-	for _, s := range m.sources {
-		info[s.Name()] = map[string]interface{}{
-			"enabled": s.Enabled(),
-		}
+// run fetches a source within its timeout and records the outcome. A fetch
+// that times out is abandoned; the crawler timeout ends it eventually.
+func (e *entry) run(cfg Config) []models.Bonus {
+	type esito struct {
+		bonuses []models.Bonus
+		err     error
 	}
-	return info
-}
-
-// fetchURL is a shared helper for all sources.
-func fetchURL(client *http.Client, url string) ([]byte, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
+	done := make(chan esito, 1)
+	start := time.Now()
+	logger.Info("datasource: fetching", map[string]interface{}{"source": e.src.Name()})
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- esito{err: fmt.Errorf("panic: %v", r)}
+			}
+		}()
+		bonuses, err := e.src.Fetch(cfg)
+		done <- esito{bonuses, err}
+	}()
+
+	var res esito
+	if cfg.Timeout > 0 {
+		select {
+		case res = <-done:
+		case <-time.After(cfg.Timeout):
+			res.err = fmt.Errorf("timeout after %s", cfg.Timeout)
+		}
+	} else {
+		res = <-done
 	}
-	req.Header.Set("User-Agent", config.Cfg.UserAgent)
-	req.Header.Set("Accept-Language", "it-IT,it;q=0.9")
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lastRun = start
+	e.duration = time.Since(start)
+	if res.err != nil {
+		e.lastError = res.err.Error()
+		logger.Warn("datasource: error", map[string]interface{}{
+			"source": e.src.Name(), "error": res.err.Error(),
+		})
+		return nil
 	}
-	defer resp.Body.Close()
+	e.lastSuccess = start
+	e.lastError = ""
+	e.lastCount = len(res.bonuses)
+	e.bonuses = res.bonuses
+	logger.Info("datasource: done", map[string]interface{}{
+		"source": e.src.Name(), "count": len(res.bonuses),
+	})
+	return res.bonuses
+}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP %d from %s", resp.StatusCode, url)
+// Status returns the configuration and the last run of every source, sorted by id.
+func (m *Manager) Status() []Status {
+	info := make([]Status, len(m.sources))
+	for i, e := range m.sources {
+		info[i] = e.status()
 	}
-	return io.ReadAll(io.LimitReader(resp.Body, 2*1024*1024))
+	return info
 }
@@ -0,0 +1,62 @@
+package datasource
+
+import (
+	"bonusperme/internal/crawler"
+	"bonusperme/internal/models"
+	"bonusperme/internal/replay"
+	"bonusperme/internal/storage"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSourcesReplay runs every data source on the recorded responses in
+// testdata/replay and compares the bonuses with testdata/golden. After an
+// intended change of a parser: go test ./internal/datasource -update
+func TestSourcesReplay(t *testing.T) {
+	crawler.Use(replay.NewPlayer("../../testdata/replay"))
+	defer crawler.Use(nil)
+
+	for _, e := range registered() {
+		s := e.src
+		t.Run(s.Name(), func(t *testing.T) {
+			bonuses, err := s.Fetch(e.defaults)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(bonuses) == 0 {
+				t.Fatal("nessun bonus")
+			}
+			confrontaGolden(t, strings.ToLower(s.Name()), bonuses)
+		})
+	}
+}
+
+func confrontaGolden(t *testing.T, nome string, bonuses []models.Bonus) {
+	t.Helper()
+	for i := range bonuses {
+		bonuses[i].UltimoAggiornamento = ""
+		if f := bonuses[i].Fondi; f != nil {
+			f.Aggiornato = time.Time{}
+		}
+	}
+	replay.GoldenJSON(t, filepath.Join("testdata", "golden", nome+".json"), bonuses)
+}
+
+// Il Manager raccoglie i risultati di tutte le fonti abilitate
+func TestManagerReplay(t *testing.T) {
+	crawler.Use(replay.NewPlayer("../../testdata/replay"))
+	defer crawler.Use(nil)
+
+	storage.Use(storage.NewMemory())
+	defer storage.Use(storage.NewMemory())
+	if err := SetEnabled("gu", true); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Manager{sources: []*entry{registry["mise"], registry["gu"]}}
+	if got := m.FetchAll(); len(got) != 5 {
+		t.Errorf("FetchAll = %d bonus, attesi 5 (3 MIMIT + 2 Gazzetta)", len(got))
+	}
+}
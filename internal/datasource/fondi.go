@@ -0,0 +1,83 @@
+package datasource
+
+import (
+	"bonusperme/internal/models"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // click days are announced in Italian time
+)
+
+var roma, _ = time.LoadLocation("Europe/Rome")
+
+const reMese = `(gennaio|febbraio|marzo|aprile|maggio|giugno|luglio|agosto|settembre|ottobre|novembre|dicembre)`
+
+var mesi = map[string]time.Month{
+	"gennaio": time.January, "febbraio": time.February, "marzo": time.March,
+	"aprile": time.April, "maggio": time.May, "giugno": time.June,
+	"luglio": time.July, "agosto": time.August, "settembre": time.September,
+	"ottobre": time.October, "novembre": time.November, "dicembre": time.December,
+}
+
+var (
+	// "click day 18 novembre 2025 ore 10:00", "apertura sportello dal 3 marzo 2026",
+	// "chiusura 31 dicembre 2025 alle 18:00"
+	reFondiData = regexp.MustCompile(`(click day|apertura|chiusura|dal|fino al|al)\D{0,20}?(\d{1,2})°?\s+` + reMese + `\s+(\d{4})(?:,?\s*(?:ore|alle ore|alle)\s*(\d{1,2})[:.](\d{2}))?`)
+	// "dotazione € 50.000.000", "risorse utilizzate: € 12,5 milioni"
+	reFondiImporto = regexp.MustCompile(`(dotazione|stanziat\w*|risorse disponibili|utilizzat\w*|prenotat\w*|impegnat\w*)\D{0,20}?€\s*([\d.]+(?:,\d+)?)(\s*milion[ie])?`)
+)
+
+// parseFondi reads the funding status a listing shows next to an incentive:
+// budget, amount used, window and click day. It returns nil when the text
+// says nothing about funding.
+func parseFondi(testo string, now time.Time) *models.Fondi {
+	lower := strings.ToLower(strings.Join(strings.Fields(testo), " "))
+	f := models.Fondi{
+		ClickDay: strings.Contains(lower, "click day") || strings.Contains(lower, "click-day"),
+		Esauriti: strings.Contains(lower, "esaurit") || strings.Contains(lower, "esaurimento delle risorse"),
+	}
+	lower = strings.ReplaceAll(lower, "click-day", "click day")
+
+	for _, m := range reFondiData.FindAllStringSubmatch(lower, -1) {
+		g, _ := strconv.Atoi(m[2])
+		a, _ := strconv.Atoi(m[4])
+		h, _ := strconv.Atoi(m[5])
+		min, _ := strconv.Atoi(m[6])
+		t := time.Date(a, mesi[m[3]], g, h, min, 0, 0, roma)
+		if t.Day() != g {
+			continue
+		}
+		switch m[1] {
+		case "chiusura", "fino al", "al":
+			if m[5] == "" {
+				t = t.Add(24*time.Hour - time.Second) // the whole closing day
+			}
+			f.Chiusura = t
+		default:
+			f.Apertura = t
+		}
+	}
+
+	for _, m := range reFondiImporto.FindAllStringSubmatch(lower, -1) {
+		v, err := strconv.ParseFloat(strings.ReplaceAll(strings.ReplaceAll(m[2], ".", ""), ",", "."), 64)
+		if err != nil {
+			continue
+		}
+		if m[3] != "" {
+			v *= 1e6
+		}
+		switch {
+		case strings.HasPrefix(m[1], "utilizzat"), strings.HasPrefix(m[1], "prenotat"), strings.HasPrefix(m[1], "impegnat"):
+			f.Utilizzati = v
+		default:
+			f.Stanziati = v
+		}
+	}
+
+	if f == (models.Fondi{}) {
+		return nil
+	}
+	f.Aggiornato = now
+	return &f
+}
@@ -1,21 +1,28 @@
 package datasource
 
 import (
+	"bonusperme/internal/crawler"
 	"bonusperme/internal/models"
+	"bonusperme/internal/normattiva"
 	"encoding/xml"
 	"fmt"
-	"net/http"
 	"strings"
 	"time"
 )
 
-// GURSSSource monitors Gazzetta Ufficiale RSS feed for new bonus-related legislation.
-type GURSSSource struct {
-	client *http.Client
+// The feed only gives the titles of the acts: changes from it always go
+// through review.
+func init() {
+	Register("gu", &GURSSSource{}, Config{
+		URLs:  []string{"https://www.gazzettaufficiale.it/rss/SG"},
+		Trust: 3,
+	})
 }
 
+// GURSSSource monitors Gazzetta Ufficiale RSS feed for new bonus-related legislation.
+type GURSSSource struct{}
+
 func (s *GURSSSource) Name() string    { return "GazzettaUfficiale" }
-func (s *GURSSSource) Enabled() bool   { return true }
 
 type rssChannel struct {
 	Items []rssItem `xml:"channel>item"`
@@ -28,13 +35,23 @@ type rssItem struct {
 	PubDate     string `xml:"pubDate"`
 }
 
-func (s *GURSSSource) Fetch() ([]models.Bonus, error) {
-	url := "https://www.gazzettaufficiale.it/rss/SG"
-	body, err := fetchURL(s.client, url)
-	if err != nil {
-		return nil, fmt.Errorf("GU RSS fetch: %w", err)
+func (s *GURSSSource) Fetch(cfg Config) ([]models.Bonus, error) {
+	var bonuses []models.Bonus
+	for _, url := range cfg.URLs {
+		body, err := crawler.Get(url)
+		if err != nil {
+			return nil, fmt.Errorf("GU RSS fetch: %w", err)
+		}
+		found, err := parseGUFeed(body, url)
+		if err != nil {
+			return nil, err
+		}
+		bonuses = append(bonuses, found...)
 	}
+	return bonuses, nil
+}
 
+func parseGUFeed(body []byte, url string) ([]models.Bonus, error) {
 	var rss rssChannel
 	if err := xml.Unmarshal(body, &rss); err != nil {
 		return nil, fmt.Errorf("GU RSS parse: %w", err)
@@ -60,7 +77,7 @@ func (s *GURSSSource) Fetch() ([]models.Bonus, error) {
 				Fonte:                "gu",
 				FonteURL:             url,
 				FonteNome:            "Gazzetta Ufficiale della Repubblica Italiana",
-				RiferimentiNormativi: []string{item.Title},
+				RiferimentiNormativi: riferimentiGU(item.Title),
 				UltimoAggiornamento:  now,
 				Stato:                "attivo",
 			}
@@ -70,3 +87,13 @@ func (s *GURSSSource) Fetch() ([]models.Bonus, error) {
 
 	return bonuses, nil
 }
+
+// riferimentiGU cites the act in canonical form ("Decreto-legge 27 marzo 2026,
+// n. 41"), so it is recognised when matched against the catalogue references.
+func riferimentiGU(title string) []string {
+	refs := normattiva.Parse(title)
+	if len(refs) == 0 {
+		return []string{title}
+	}
+	return []string{refs[0].String()}
+}
@@ -1,9 +1,10 @@
 package datasource
 
 import (
+	"bonusperme/internal/crawler"
+	"bonusperme/internal/extract"
 	"bonusperme/internal/models"
 	"fmt"
-	"net/http"
 	"regexp"
 	"strings"
 	"time"
@@ -11,29 +12,35 @@ import (
 	"golang.org/x/net/html"
 )
 
-var inpsURLs = []string{
-	"https://www.inps.it/it/it/sostegni-sussidi-indennita/per-genitori.html",
-	"https://www.inps.it/it/it/sostegni-sussidi-indennita/per-famiglie.html",
+func init() {
+	Register("inps", &INPSSource{}, Config{
+		Enabled: true,
+		URLs: []string{
+			"https://www.inps.it/it/it/sostegni-sussidi-indennita/per-genitori.html",
+			"https://www.inps.it/it/it/sostegni-sussidi-indennita/per-famiglie.html",
+		},
+		Trust: 1,
+	})
 }
 
 // INPSSource scrapes bonus data from INPS official pages.
-type INPSSource struct {
-	client *http.Client
-}
+// Option "dettagli" (default true) also reads the detail page of each bonus.
+type INPSSource struct{}
 
 func (s *INPSSource) Name() string    { return "INPS" }
-func (s *INPSSource) Enabled() bool   { return true }
 
-func (s *INPSSource) Fetch() ([]models.Bonus, error) {
+func (s *INPSSource) Fetch(cfg Config) ([]models.Bonus, error) {
 	var all []models.Bonus
-	for _, url := range inpsURLs {
-		body, err := fetchURL(s.client, url)
+	for _, url := range cfg.URLs {
+		body, err := crawler.Get(url)
 		if err != nil {
 			return nil, fmt.Errorf("INPS fetch %s: %w", url, err)
 		}
 		bonuses := parseINPSPage(body, url)
+		if cfg.OptionBool("dettagli", true) {
+			extract.Enrich(bonuses, crawler.Get)
+		}
 		all = append(all, bonuses...)
-		time.Sleep(2 * time.Second) // polite delay
 	}
 	return all, nil
 }
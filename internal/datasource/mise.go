@@ -1,30 +1,39 @@
 package datasource
 
 import (
+	"bonusperme/internal/crawler"
 	"bonusperme/internal/models"
 	"fmt"
-	"net/http"
 	"strings"
 	"time"
 
 	"golang.org/x/net/html"
 )
 
-// MISESource scrapes bonus data from Ministero delle Imprese e del Made in Italy.
-type MISESource struct {
-	client *http.Client
+// The listing is read by headings only: changes from it go through review.
+func init() {
+	Register("mise", &MISESource{}, Config{
+		Enabled: true,
+		URLs:    []string{"https://www.mimit.gov.it/it/incentivi"},
+		Trust:   2,
+	})
 }
 
+// MISESource scrapes bonus data from Ministero delle Imprese e del Made in Italy.
+type MISESource struct{}
+
 func (s *MISESource) Name() string    { return "MISE" }
-func (s *MISESource) Enabled() bool   { return true }
 
-func (s *MISESource) Fetch() ([]models.Bonus, error) {
-	url := "https://www.mimit.gov.it/it/incentivi"
-	body, err := fetchURL(s.client, url)
-	if err != nil {
-		return nil, fmt.Errorf("MISE fetch: %w", err)
+func (s *MISESource) Fetch(cfg Config) ([]models.Bonus, error) {
+	var all []models.Bonus
+	for _, url := range cfg.URLs {
+		body, err := crawler.Get(url)
+		if err != nil {
+			return nil, fmt.Errorf("MISE fetch: %w", err)
+		}
+		all = append(all, parseMISEPage(body, url)...)
 	}
-	return parseMISEPage(body, url), nil
+	return all, nil
 }
 
 func parseMISEPage(body []byte, sourceURL string) []models.Bonus {
@@ -66,6 +75,13 @@ func parseMISEPage(body []byte, sourceURL string) []models.Bonus {
 					UltimoAggiornamento: now,
 					Stato:               "attivo",
 				}
+				// The status of the incentive follows the link in the same item
+				if n.Data == "a" && n.Parent != nil && n.Parent.Data == "li" {
+					if f := parseFondi(getTextContent(n.Parent), time.Now()); f != nil {
+						f.Fonte = "mise"
+						bonus.Fondi = f
+					}
+				}
 				bonuses = append(bonuses, bonus)
 			}
 		}
@@ -1,31 +1,40 @@
 package datasource
 
 import (
+	"bonusperme/internal/crawler"
+	"bonusperme/internal/logger"
 	"bonusperme/internal/models"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"strings"
 	"time"
 )
 
-// OpenDataSource fetches bonus data from Italian open data APIs (dati.gov.it, INPS Open Data).
-type OpenDataSource struct {
-	client *http.Client
+// Datasets are published by third parties: changes from them go through review.
+func init() {
+	Register("opendata", &OpenDataSource{}, Config{
+		URLs:  []string{"https://www.dati.gov.it/opendata/api/3/action/package_search?q=bonus+famiglia&rows=20"},
+		Trust: 3,
+	})
 }
 
+// OpenDataSource fetches bonus data from Italian open data APIs (dati.gov.it, INPS Open Data).
+// The URLs are CKAN package_search queries.
+type OpenDataSource struct{}
+
 func (s *OpenDataSource) Name() string    { return "OpenData" }
-func (s *OpenDataSource) Enabled() bool   { return true }
 
-func (s *OpenDataSource) Fetch() ([]models.Bonus, error) {
+func (s *OpenDataSource) Fetch(cfg Config) ([]models.Bonus, error) {
 	var all []models.Bonus
 
 	// dati.gov.it CKAN API — search for "bonus" datasets
-	datiGov, err := s.fetchDatiGov()
-	if err != nil {
-		// Non-fatal: log and continue
-		_ = err
-	} else {
+	for _, url := range cfg.URLs {
+		datiGov, err := s.fetchDatiGov(url)
+		if err != nil {
+			// Non-fatal: log and continue
+			logger.Warn("datasource: open data query failed", map[string]interface{}{"url": url, "error": err.Error()})
+			continue
+		}
 		all = append(all, datiGov...)
 	}
 
@@ -45,9 +54,8 @@ type ckanSearchResult struct {
 	} `json:"result"`
 }
 
-func (s *OpenDataSource) fetchDatiGov() ([]models.Bonus, error) {
-	url := "https://www.dati.gov.it/opendata/api/3/action/package_search?q=bonus+famiglia&rows=20"
-	body, err := fetchURL(s.client, url)
+func (s *OpenDataSource) fetchDatiGov(url string) ([]models.Bonus, error) {
+	body, err := crawler.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("dati.gov.it fetch: %w", err)
 	}
@@ -0,0 +1,291 @@
+package datasource
+
+import (
+	"bonusperme/internal/config"
+	"bonusperme/internal/logger"
+	"bonusperme/internal/models"
+	"bonusperme/internal/storage"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnknown is returned for an ID no source registered with.
+var ErrUnknown = errors.New("datasource: fonte sconosciuta")
+
+// Config is the configuration block of a data source. The defaults are given
+// at registration; DATASOURCE_<ID>_* variables override them, so a new source
+// needs no change to config.Config:
+//
+//	DATASOURCE_INPS=false                     Enabled
+//	DATASOURCE_INPS_URLS=https://a,https://b  pages or feeds to read
+//	DATASOURCE_INPS_INTERVAL=6h               minimum time between fetches (0 = every scrape cycle)
+//	DATASOURCE_INPS_TIMEOUT=2m                time allowed for a whole fetch (0 = none)
+//	DATASOURCE_INPS_TRUST=1                   same scale as scraper Source.Priority
+//	DATASOURCE_INPS_OPTIONS=dettagli=false    parser options, comma-separated
+//
+// Enabled can also be changed at runtime with SetEnabled, which wins over both
+// until DATASOURCE_<ID> changes or the switch is cleared with ResetEnabled.
+type Config struct {
+	Enabled  bool
+	URLs     []string
+	Interval time.Duration
+	Timeout  time.Duration
+	Trust    int
+	Options  map[string]string
+}
+
+// Option returns the parser option key, or fallback when it is not set.
+func (c Config) Option(key, fallback string) string {
+	if v, ok := c.Options[key]; ok {
+		return v
+	}
+	return fallback
+}
+
+// OptionBool returns the parser option key as a bool.
+func (c Config) OptionBool(key string, fallback bool) bool {
+	b, err := strconv.ParseBool(c.Option(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return b
+}
+
+// entry is a registered source with the state of its last runs.
+type entry struct {
+	id       string
+	src      DataSource
+	defaults Config
+
+	mu          sync.Mutex
+	lastRun     time.Time
+	lastSuccess time.Time
+	lastError   string
+	lastCount   int
+	duration    time.Duration
+	bonuses     []models.Bonus // last result, served until the source is due again
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]*entry)
+)
+
+// Register adds a source under id, the code its bonuses carry in Fonte
+// ("inps", "gu"). Sources register themselves from init.
+func Register(id string, src DataSource, defaults Config) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, dup := registry[id]; dup {
+		panic("datasource: " + id + " registered twice")
+	}
+	registry[id] = &entry{id: id, src: src, defaults: defaults}
+}
+
+// registered returns the sources sorted by id.
+func registered() []*entry {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	entries := make([]*entry, 0, len(registry))
+	for _, e := range registry {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].id < entries[j].id })
+	return entries
+}
+
+func lookup(id string) (*entry, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	e, ok := registry[strings.ToLower(id)]
+	if !ok {
+		return nil, ErrUnknown
+	}
+	return e, nil
+}
+
+// config returns the defaults of the source with the environment and the
+// runtime switch applied. Malformed values keep the default.
+func (e *entry) config() Config {
+	cfg := e.defaults
+	if v, ok := e.env(""); ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.Enabled = b
+		}
+	}
+	if v, ok := e.env("_URLS"); ok {
+		cfg.URLs = nil
+		for _, u := range strings.Split(v, ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				cfg.URLs = append(cfg.URLs, u)
+			}
+		}
+	}
+	if v, ok := e.env("_INTERVAL"); ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Interval = d
+		}
+	}
+	if v, ok := e.env("_TIMEOUT"); ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.Timeout = d
+		}
+	}
+	if v, ok := e.env("_TRUST"); ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.Trust = n
+		}
+	}
+	if v, ok := e.env("_OPTIONS"); ok {
+		opts := make(map[string]string, len(cfg.Options))
+		for k, o := range cfg.Options {
+			opts[k] = o
+		}
+		for _, pair := range strings.Split(v, ",") {
+			if k, o, ok := strings.Cut(pair, "="); ok {
+				opts[strings.TrimSpace(k)] = strings.TrimSpace(o)
+			}
+		}
+		cfg.Options = opts
+	}
+
+	if sw, ok := e.switchRuntime(); ok {
+		cfg.Enabled = sw.Enabled
+	}
+	return cfg
+}
+
+// env returns the DATASOURCE_<ID><name> variable, if set and not blank.
+func (e *entry) env(name string) (string, bool) {
+	v, ok := config.Cfg.Datasources[strings.ToUpper(e.id)+name]
+	return strings.TrimSpace(v), ok && strings.TrimSpace(v) != ""
+}
+
+// runtimeSwitch is a stored SetEnabled choice, with the DATASOURCE_<ID>
+// value it was made against.
+type runtimeSwitch struct {
+	Enabled bool   `json:"enabled"`
+	Env     string `json:"env"`
+}
+
+// switchRuntime returns the stored switch while DATASOURCE_<ID> still has the
+// value it was made against: an operator changing the variable wins again.
+func (e *entry) switchRuntime() (runtimeSwitch, bool) {
+	var sw runtimeSwitch
+	found, err := storage.Get(storage.BucketDatasource, e.id, &sw)
+	if err != nil {
+		// Switches stored before the variable was recorded are plain bools
+		var on bool
+		if found, err = storage.Get(storage.BucketDatasource, e.id, &on); err != nil {
+			return sw, false
+		}
+		sw = runtimeSwitch{Enabled: on}
+	}
+	if !found {
+		return sw, false
+	}
+	v, _ := e.env("")
+	return sw, sw.Env == v
+}
+
+// due reports whether the source should be fetched again.
+func (e *entry) due(cfg Config, now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return cfg.Interval <= 0 || e.lastSuccess.IsZero() || now.Sub(e.lastSuccess) >= cfg.Interval
+}
+
+// SetEnabled switches a source on or off at runtime. The choice is stored and
+// survives restarts, overriding DATASOURCE_<ID> until that variable changes.
+func SetEnabled(id string, on bool) error {
+	e, err := lookup(id)
+	if err != nil {
+		return err
+	}
+	v, _ := e.env("")
+	if err := storage.Put(storage.BucketDatasource, e.id, runtimeSwitch{Enabled: on, Env: v}); err != nil {
+		return err
+	}
+	logger.Info("datasource: switched at runtime", map[string]interface{}{"source": e.id, "enabled": on})
+	return nil
+}
+
+// ResetEnabled clears the runtime switch of a source, so DATASOURCE_<ID> or
+// the default applies again.
+func ResetEnabled(id string) error {
+	e, err := lookup(id)
+	if err != nil {
+		return err
+	}
+	if err := storage.Delete(storage.BucketDatasource, e.id); err != nil {
+		return err
+	}
+	logger.Info("datasource: runtime switch cleared", map[string]interface{}{"source": e.id})
+	return nil
+}
+
+// Trust returns the trust of the source whose bonuses carry fonte in Fonte,
+// 0 when no source has that id.
+func Trust(fonte string) int {
+	e, err := lookup(fonte)
+	if err != nil {
+		return 0
+	}
+	return e.config().Trust
+}
+
+// Status is the configuration and the last run of a source.
+type Status struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Enabled     bool              `json:"enabled"`
+	URLs        []string          `json:"urls"`
+	Interval    string            `json:"interval,omitempty"`
+	Timeout     string            `json:"timeout,omitempty"`
+	Trust       int               `json:"trust"`
+	Options     map[string]string `json:"options,omitempty"`
+	LastRun     time.Time         `json:"last_run"`
+	LastSuccess time.Time         `json:"last_success"`
+	LastError   string            `json:"last_error,omitempty"`
+	LastCount   int               `json:"last_count"`
+	DurationMS  int64             `json:"duration_ms"`
+	NextRun     time.Time         `json:"next_run,omitempty"`
+}
+
+func (e *entry) status() Status {
+	cfg := e.config()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s := Status{
+		ID:          e.id,
+		Name:        e.src.Name(),
+		Enabled:     cfg.Enabled,
+		URLs:        cfg.URLs,
+		Trust:       cfg.Trust,
+		Options:     cfg.Options,
+		LastRun:     e.lastRun,
+		LastSuccess: e.lastSuccess,
+		LastError:   e.lastError,
+		LastCount:   e.lastCount,
+		DurationMS:  e.duration.Milliseconds(),
+	}
+	if cfg.Interval > 0 {
+		s.Interval = cfg.Interval.String()
+		if !e.lastSuccess.IsZero() {
+			s.NextRun = e.lastSuccess.Add(cfg.Interval)
+		}
+	}
+	if cfg.Timeout > 0 {
+		s.Timeout = cfg.Timeout.String()
+	}
+	return s
+}
+
+// Statuses returns the status of every registered source.
+func Statuses() []Status {
+	return NewManager().Status()
+}
@@ -0,0 +1,153 @@
+package datasource
+
+import (
+	"bonusperme/internal/config"
+	"bonusperme/internal/models"
+	"bonusperme/internal/storage"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fonteProva returns one bonus per URL, or err.
+type fonteProva struct {
+	chiamate int
+	err      error
+	attesa   time.Duration
+	cfg      Config
+}
+
+func (f *fonteProva) Name() string { return "Prova" }
+
+func (f *fonteProva) Fetch(cfg Config) ([]models.Bonus, error) {
+	f.chiamate++
+	f.cfg = cfg
+	time.Sleep(f.attesa)
+	if f.err != nil {
+		return nil, f.err
+	}
+	var bonuses []models.Bonus
+	for _, u := range cfg.URLs {
+		bonuses = append(bonuses, models.Bonus{ID: u, Fonte: "prova"})
+	}
+	return bonuses, nil
+}
+
+func TestConfigFonte(t *testing.T) {
+	storage.Use(storage.NewMemory())
+	defer storage.Use(storage.NewMemory())
+	prev := config.Cfg
+	defer func() { config.Cfg = prev }()
+	config.Cfg.Datasources = map[string]string{
+		"PROVA":          "false",
+		"PROVA_URLS":     "https://a.example, https://b.example",
+		"PROVA_INTERVAL": "6h",
+		"PROVA_TRUST":    "non-un-numero",
+		"PROVA_OPTIONS":  "dettagli=false, lingua=it",
+		"PROVAX_TRUST":   "1",
+	}
+
+	e := &entry{id: "prova", src: &fonteProva{}, defaults: Config{
+		Enabled: true, URLs: []string{"https://default.example"}, Trust: 2, Options: map[string]string{"righe": "20"},
+	}}
+	cfg := e.config()
+	if cfg.Enabled || len(cfg.URLs) != 2 || cfg.URLs[1] != "https://b.example" || cfg.Interval != 6*time.Hour || cfg.Trust != 2 {
+		t.Errorf("config = %+v", cfg)
+	}
+	if cfg.OptionBool("dettagli", true) || cfg.Option("lingua", "") != "it" || cfg.Option("righe", "") != "20" {
+		t.Errorf("opzioni = %v", cfg.Options)
+	}
+	if e.defaults.Options["lingua"] != "" {
+		t.Error("le opzioni da ambiente non devono modificare i default")
+	}
+
+	// L'interruttore a runtime vince sulla variabile d'ambiente con cui è stato
+	// dato, finché la variabile non cambia o l'interruttore non viene tolto
+	storage.Put(storage.BucketDatasource, "prova", runtimeSwitch{Enabled: true, Env: "false"})
+	if !e.config().Enabled {
+		t.Error("fonte non riattivata a runtime")
+	}
+	config.Cfg.Datasources["PROVA"] = "0"
+	if e.config().Enabled {
+		t.Error("una nuova DATASOURCE_PROVA deve prevalere sull'interruttore")
+	}
+	config.Cfg.Datasources["PROVA"] = "false"
+	storage.Delete(storage.BucketDatasource, "prova")
+	if e.config().Enabled {
+		t.Error("senza interruttore vale la variabile d'ambiente")
+	}
+
+	// Interruttori salvati come semplice bool valgono solo senza variabile
+	storage.Put(storage.BucketDatasource, "prova", true)
+	if e.config().Enabled {
+		t.Error("un vecchio interruttore non deve prevalere su DATASOURCE_PROVA")
+	}
+	if err := SetEnabled("inesistente", true); !errors.Is(err, ErrUnknown) {
+		t.Errorf("SetEnabled su fonte sconosciuta: %v", err)
+	}
+}
+
+func TestResetEnabled(t *testing.T) {
+	storage.Use(storage.NewMemory())
+	defer storage.Use(storage.NewMemory())
+	prev := config.Cfg
+	defer func() { config.Cfg = prev }()
+	config.Cfg.Datasources = map[string]string{"GU": "true"}
+
+	e, _ := lookup("gu")
+	if err := SetEnabled("gu", false); err != nil {
+		t.Fatal(err)
+	}
+	if e.config().Enabled {
+		t.Error("fonte non disattivata a runtime")
+	}
+	if err := ResetEnabled("gu"); err != nil {
+		t.Fatal(err)
+	}
+	if !e.config().Enabled {
+		t.Error("dopo il reset deve valere DATASOURCE_GU")
+	}
+	if err := ResetEnabled("inesistente"); !errors.Is(err, ErrUnknown) {
+		t.Errorf("ResetEnabled su fonte sconosciuta: %v", err)
+	}
+}
+
+func TestManagerStato(t *testing.T) {
+	storage.Use(storage.NewMemory())
+	defer storage.Use(storage.NewMemory())
+
+	ok := &fonteProva{}
+	ko := &fonteProva{err: errors.New("HTTP 503")}
+	lenta := &fonteProva{attesa: 200 * time.Millisecond}
+	m := &Manager{sources: []*entry{
+		{id: "ok", src: ok, defaults: Config{Enabled: true, URLs: []string{"a", "b"}, Interval: time.Hour}},
+		{id: "ko", src: ko, defaults: Config{Enabled: true, URLs: []string{"c"}}},
+		{id: "lenta", src: lenta, defaults: Config{Enabled: true, URLs: []string{"d"}, Timeout: 20 * time.Millisecond}},
+		{id: "spenta", src: &fonteProva{}, defaults: Config{URLs: []string{"e"}}},
+	}}
+
+	if got := m.FetchAll(); len(got) != 2 {
+		t.Fatalf("FetchAll = %d bonus, attesi 2", len(got))
+	}
+	// Entro l'intervallo la fonte non viene riletta ma i suoi bonus restano
+	if got := m.FetchAll(); len(got) != 2 || ok.chiamate != 1 || ko.chiamate != 2 {
+		t.Errorf("secondo ciclo: %d bonus, chiamate ok = %d, ko = %d", len(got), ok.chiamate, ko.chiamate)
+	}
+
+	stati := make(map[string]Status)
+	for _, s := range m.Status() {
+		stati[s.ID] = s
+	}
+	if s := stati["ok"]; !s.Enabled || s.LastCount != 2 || s.LastRun.IsZero() || s.LastError != "" || s.NextRun.Sub(s.LastSuccess) != time.Hour {
+		t.Errorf("stato ok = %+v", s)
+	}
+	if s := stati["ko"]; s.LastError != "HTTP 503" || !s.LastSuccess.IsZero() {
+		t.Errorf("stato ko = %+v", s)
+	}
+	if s := stati["lenta"]; s.LastError != "timeout after 20ms" || s.Timeout != "20ms" {
+		t.Errorf("stato lenta = %+v", s)
+	}
+	if s := stati["spenta"]; s.Enabled || !s.LastRun.IsZero() {
+		t.Errorf("stato spenta = %+v", s)
+	}
+}
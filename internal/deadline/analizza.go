@@ -0,0 +1,158 @@
+package deadline
+
+import (
+	"bonusperme/internal/models"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var mesi = map[string]time.Month{
+	"gennaio": time.January, "febbraio": time.February, "marzo": time.March,
+	"aprile": time.April, "maggio": time.May, "giugno": time.June,
+	"luglio": time.July, "agosto": time.August, "settembre": time.September,
+	"ottobre": time.October, "novembre": time.November, "dicembre": time.December,
+}
+
+const reMese = `(gennaio|febbraio|marzo|aprile|maggio|giugno|luglio|agosto|settembre|ottobre|novembre|dicembre)`
+
+var (
+	// "31 dicembre 2025", "1° marzo 2026", "31/12/2025"
+	reData = regexp.MustCompile(`(\d{1,2})°?\s+` + reMese + `\s+(\d{4})|(\d{1,2})/(\d{1,2})/(\d{4})`)
+	// "28 febbraio" without a year
+	reGiornoMese = regexp.MustCompile(`(\d{1,2})°?\s+` + reMese + `(?:\s+(?:di\s+ogni\s+anno|dell'anno))?`)
+	// "luglio-settembre", "da luglio a settembre"
+	reMesi = regexp.MustCompile(reMese + `\s*(?:-|–|a)\s*` + reMese)
+	// "entro 60 giorni dalla nascita"
+	reGiorniEvento = regexp.MustCompile(`(\d+)\s+giorni\s+(?:dalla|dallo|dalle|dall'|dai|dal)\s*(.+)`)
+	// "dell'anno successivo ai 18 anni"
+	reAnnoSuccessivo = regexp.MustCompile(`anno\s+successivo\s+(?:alla|allo|all'|ai|al|a)\s*(.+)`)
+	// "dal 1 marzo 2026": the date opens a window
+	reDal  = regexp.MustCompile(`(?:^|\s)(?:dal|dall'|a\s+partire\s+dal)\s*$`)
+	reAnno = regexp.MustCompile(`(?:^|\D)(20\d{2})(?:\D|$)`)
+)
+
+// Parse reads a deadline written in Italian prose. Text it cannot read
+// gives a Termine with an empty Tipo, which never expires.
+func Parse(testo string) models.Termine {
+	lower := strings.ToLower(strings.Join(strings.Fields(testo), " "))
+	lower = strings.ReplaceAll(lower, "’", "'")
+	if lower == "" {
+		return models.Termine{}
+	}
+
+	// Fondi esauriti / fino ad esaurimento fondi
+	if strings.Contains(lower, "esaurit") {
+		return models.Termine{Tipo: TipoEsaurimento, Esaurito: true}
+	}
+	if strings.Contains(lower, "esaurimento") {
+		return models.Termine{Tipo: TipoEsaurimento}
+	}
+
+	// Date complete: una chiude il termine, due aprono e chiudono una finestra
+	if date := reData.FindAllStringSubmatchIndex(lower, -1); len(date) > 0 {
+		var giorni []string
+		for _, m := range date {
+			if d, ok := dataTesto(lower, m); ok {
+				giorni = append(giorni, d.Format(layoutData))
+			}
+		}
+		switch {
+		case len(giorni) >= 2:
+			return models.Termine{Tipo: TipoFinestra, Apertura: giorni[0], Chiusura: giorni[len(giorni)-1]}
+		case len(giorni) == 1 && reDal.MatchString(lower[:date[0][0]]):
+			return models.Termine{Tipo: TipoFinestra, Apertura: giorni[0]}
+		case len(giorni) == 1:
+			return models.Termine{Tipo: TipoDataFissa, Chiusura: giorni[0]}
+		}
+	}
+
+	// Termini legati a un evento della vita del richiedente
+	if m := reGiorniEvento.FindStringSubmatch(lower); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return models.Termine{Tipo: TipoEvento, Giorni: n, Evento: evento(m[2])}
+	}
+	if m := reAnnoSuccessivo.FindStringSubmatch(lower); m != nil {
+		if gm := reGiornoMese.FindStringSubmatch(lower); gm != nil {
+			if d, ok := annuaTesto(gm[1], gm[2]); ok {
+				return models.Termine{Tipo: TipoEvento, ChiusuraAnnua: d, Anni: 1, Evento: evento(m[1])}
+			}
+		}
+	}
+
+	// Scadenze che si ripetono ogni anno
+	tipoAnnuo := TipoAnnuale
+	if strings.Contains(lower, "bando") {
+		tipoAnnuo = TipoBando
+	}
+	if m := reMesi.FindStringSubmatch(lower); m != nil {
+		da, a := mesi[m[1]], mesi[m[2]]
+		return models.Termine{
+			Tipo:          tipoAnnuo,
+			AperturaAnnua: fmt.Sprintf("%02d-01", da),
+			ChiusuraAnnua: time.Date(2000, a+1, 0, 0, 0, 0, 0, time.UTC).Format(layoutAnnua),
+		}
+	}
+	if m := reGiornoMese.FindStringSubmatch(lower); m != nil {
+		if d, ok := annuaTesto(m[1], m[2]); ok {
+			return models.Termine{Tipo: tipoAnnuo, ChiusuraAnnua: d}
+		}
+	}
+	if tipoAnnuo == TipoBando {
+		return models.Termine{Tipo: TipoBando}
+	}
+
+	for _, p := range []string{"in vigore", "permanente", "erogazione automatica", "annuale", "sempre"} {
+		if strings.Contains(lower, p) {
+			return models.Termine{Tipo: TipoPermanente}
+		}
+	}
+
+	// Solo l'anno: il termine è la fine di quell'anno
+	if m := reAnno.FindStringSubmatch(lower); m != nil {
+		return models.Termine{Tipo: TipoDataFissa, Chiusura: m[1] + "-12-31"}
+	}
+	return models.Termine{}
+}
+
+// dataTesto reads the date matched by reData at m.
+func dataTesto(s string, m []int) (time.Time, bool) {
+	num := func(i int) int {
+		n, _ := strconv.Atoi(s[m[2*i]:m[2*i+1]])
+		return n
+	}
+	var (
+		g, a int
+		mese time.Month
+	)
+	if m[2] >= 0 {
+		g, mese, a = num(1), mesi[s[m[4]:m[5]]], num(3)
+	} else {
+		g, mese, a = num(4), time.Month(num(5)), num(6)
+	}
+	d := time.Date(a, mese, g, 0, 0, 0, 0, time.UTC)
+	if mese < time.January || mese > time.December || d.Day() != g {
+		return time.Time{}, false
+	}
+	return d, true
+}
+
+func annuaTesto(g, mese string) (string, bool) {
+	n, _ := strconv.Atoi(g)
+	d := time.Date(2000, mesi[mese], n, 0, 0, 0, 0, time.UTC) // anno bisestile
+	if d.Day() != n {
+		return "", false
+	}
+	return d.Format(layoutAnnua), true
+}
+
+// evento trims the description of an event to its first words ("nascita").
+func evento(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, ",;.("); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s
+}
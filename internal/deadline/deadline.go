@@ -0,0 +1,257 @@
+// Package deadline evaluates the deadlines of the bonuses. models.Termine is
+// the structured form of the free text in Bonus.Scadenza: Parse derives it
+// from the text, and the other functions answer the questions of the matcher,
+// the validity checker, the calendar and the PDF report, so they all agree.
+//
+// Dates are days, without a time: a deadline is met until the end of its day.
+package deadline
+
+import (
+	"bonusperme/internal/models"
+	"fmt"
+	"time"
+)
+
+// Termine types.
+const (
+	TipoDataFissa   = "data_fissa"
+	TipoFinestra    = "finestra"
+	TipoEvento      = "da_evento"
+	TipoAnnuale     = "annuale"
+	TipoBando       = "bando_annuale"
+	TipoEsaurimento = "esaurimento_fondi"
+	TipoPermanente  = "permanente"
+)
+
+const (
+	layoutData  = "2006-01-02"
+	layoutAnnua = "01-02"
+)
+
+// Of returns the deadline of b: the one set in the catalogue, else the one
+// read from Scadenza.
+func Of(b models.Bonus) models.Termine {
+	if b.Termine != nil {
+		return *b.Termine
+	}
+	return Parse(b.Scadenza)
+}
+
+// Populate sets Termine from Scadenza when missing, and the legacy fields
+// TipoScadenza and ScadenzaDomanda from Termine.
+func Populate(b *models.Bonus) {
+	if b.Termine == nil {
+		t := Parse(b.Scadenza)
+		b.Termine = &t
+	}
+	b.TipoScadenza = b.Termine.Tipo
+	b.ScadenzaDomanda = time.Time{}
+	switch b.Termine.Tipo {
+	case TipoDataFissa, TipoFinestra:
+		if c, ok := data(b.Termine.Chiusura); ok {
+			b.ScadenzaDomanda = c.Add(24*time.Hour - time.Second)
+		}
+	}
+}
+
+// Expired reports whether applications are closed for good at now: the last
+// day of a fixed deadline has passed, or the funds are exhausted. Recurring
+// deadlines never expire.
+func Expired(t models.Termine, now time.Time) bool {
+	switch t.Tipo {
+	case TipoDataFissa, TipoFinestra:
+		c, ok := data(t.Chiusura)
+		return ok && giorno(now).After(c)
+	case TipoEsaurimento:
+		return t.Esaurito
+	}
+	return false
+}
+
+// Recurring reports whether the deadline comes back, for every year or every
+// event, so the bonus does not expire with it.
+func Recurring(t models.Termine) bool {
+	switch t.Tipo {
+	case TipoPermanente, TipoAnnuale, TipoEvento:
+		return true
+	}
+	return false
+}
+
+// Closing returns the next closing day at now: the fixed closing date, even
+// if passed, or the next occurrence of a yearly one. It is zero when the
+// deadline has no date of its own (da_evento, permanente, esaurimento_fondi).
+func Closing(t models.Termine, now time.Time) time.Time {
+	switch t.Tipo {
+	case TipoDataFissa, TipoFinestra:
+		c, _ := data(t.Chiusura)
+		return c
+	case TipoAnnuale, TipoBando:
+		return prossima(t.ChiusuraAnnua, giorno(now))
+	}
+	return time.Time{}
+}
+
+// Opening returns the opening day of the window that closes at
+// Closing(t, now), zero when applications are not bound to open.
+func Opening(t models.Termine, now time.Time) time.Time {
+	switch t.Tipo {
+	case TipoFinestra:
+		a, _ := data(t.Apertura)
+		return a
+	case TipoAnnuale, TipoBando:
+		c := Closing(t, now)
+		if c.IsZero() || t.AperturaAnnua == "" {
+			return time.Time{}
+		}
+		a := annua(t.AperturaAnnua, c.Year())
+		if a.After(c) { // window across the new year
+			a = annua(t.AperturaAnnua, c.Year()-1)
+		}
+		return a
+	}
+	return time.Time{}
+}
+
+// IsOpen reports whether applications are accepted at now, as far as the
+// deadline tells.
+func IsOpen(t models.Termine, now time.Time) bool {
+	if Expired(t, now) {
+		return false
+	}
+	a := Opening(t, now)
+	return a.IsZero() || !giorno(now).Before(a)
+}
+
+// EventDeadline returns the closing day of a da_evento deadline for an event
+// on the day evento ("60 giorni dalla nascita" of a child born on evento).
+func EventDeadline(t models.Termine, evento time.Time) time.Time {
+	if t.Tipo != TipoEvento || evento.IsZero() {
+		return time.Time{}
+	}
+	evento = giorno(evento)
+	if t.ChiusuraAnnua != "" {
+		return annua(t.ChiusuraAnnua, evento.Year()+t.Anni)
+	}
+	if t.Giorni > 0 {
+		return evento.AddDate(0, 0, t.Giorni)
+	}
+	return time.Time{}
+}
+
+// DaysLeft returns the days from now to the closing day, 0 on the day
+// itself; ok is false when the deadline has no date or has passed.
+func DaysLeft(t models.Termine, now time.Time) (int, bool) {
+	c := Closing(t, now)
+	if c.IsZero() || Expired(t, now) {
+		return 0, false
+	}
+	return int(c.Sub(giorno(now)).Hours() / 24), true
+}
+
+// Label completes the text of a deadline with what it means at now: the
+// days left when the closing day is near, the date of the next occurrence of a
+// yearly deadline. The text is returned as is when there is nothing to add.
+func Label(t models.Termine, testo string, now time.Time) string {
+	giorni, ok := DaysLeft(t, now)
+	switch {
+	case !ok:
+		return testo
+	case giorni == 0:
+		return testo + " (scade oggi)"
+	case t.Tipo == TipoAnnuale || t.Tipo == TipoBando:
+		return testo + " (prossima scadenza " + Closing(t, now).Format("02/01/2006") + ")"
+	case giorni == 1:
+		return testo + " (scade domani)"
+	case giorni <= 30:
+		return fmt.Sprintf("%s (tra %d giorni)", testo, giorni)
+	}
+	return testo
+}
+
+// Validate checks a deadline written in the catalogue.
+func Validate(t models.Termine) error {
+	date := func(campi ...string) error {
+		for _, s := range campi {
+			if _, ok := data(s); s != "" && !ok {
+				return fmt.Errorf("data %q non valida (AAAA-MM-GG)", s)
+			}
+		}
+		return nil
+	}
+	annue := func(campi ...string) error {
+		for _, s := range campi {
+			if _, err := time.Parse(layoutAnnua, s); s != "" && err != nil {
+				return fmt.Errorf("data annuale %q non valida (MM-GG)", s)
+			}
+		}
+		return nil
+	}
+	if err := date(t.Apertura, t.Chiusura); err != nil {
+		return err
+	}
+	if err := annue(t.AperturaAnnua, t.ChiusuraAnnua); err != nil {
+		return err
+	}
+	switch t.Tipo {
+	case TipoDataFissa:
+		if t.Chiusura == "" {
+			return fmt.Errorf("data_fissa richiede chiusura")
+		}
+	case TipoFinestra:
+		if t.Apertura == "" && t.Chiusura == "" {
+			return fmt.Errorf("finestra richiede apertura o chiusura")
+		}
+		if t.Apertura != "" && t.Chiusura != "" && t.Chiusura < t.Apertura {
+			return fmt.Errorf("chiusura precedente all'apertura")
+		}
+	case TipoEvento:
+		if t.Evento == "" || (t.Giorni <= 0 && t.ChiusuraAnnua == "") {
+			return fmt.Errorf("da_evento richiede evento e giorni o chiusura_annua")
+		}
+	case TipoAnnuale:
+		if t.ChiusuraAnnua == "" {
+			return fmt.Errorf("annuale richiede chiusura_annua")
+		}
+	case TipoBando, TipoEsaurimento, TipoPermanente:
+	default:
+		return fmt.Errorf("tipo %q sconosciuto", t.Tipo)
+	}
+	return nil
+}
+
+// giorno is the day of t, at midnight UTC.
+func giorno(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func data(s string) (time.Time, bool) {
+	t, err := time.Parse(layoutData, s)
+	return t, err == nil
+}
+
+// annua is the yearly date mmdd ("02-28") in year; 29 February is the 28th
+// in common years.
+func annua(mmdd string, year int) time.Time {
+	t, err := time.Parse(layoutAnnua, mmdd)
+	if err != nil {
+		return time.Time{}
+	}
+	d := time.Date(year, t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if d.Month() != t.Month() {
+		d = d.AddDate(0, 0, -d.Day())
+	}
+	return d
+}
+
+// prossima is the first occurrence of the yearly date mmdd from day on.
+func prossima(mmdd string, day time.Time) time.Time {
+	if mmdd == "" {
+		return time.Time{}
+	}
+	d := annua(mmdd, day.Year())
+	if !d.IsZero() && d.Before(day) {
+		d = annua(mmdd, day.Year()+1)
+	}
+	return d
+}
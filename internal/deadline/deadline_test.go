@@ -0,0 +1,143 @@
+package deadline
+
+import (
+	"bonusperme/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	casi := map[string]models.Termine{
+		"31 dicembre 2025":                                {Tipo: TipoDataFissa, Chiusura: "2025-12-31"},
+		"Entro il 31/01/2026":                             {Tipo: TipoDataFissa, Chiusura: "2026-01-31"},
+		"Dal 1° marzo 2026 al 30 aprile 2026":             {Tipo: TipoFinestra, Apertura: "2026-03-01", Chiusura: "2026-04-30"},
+		"Domande dal 15 settembre 2026":                   {Tipo: TipoFinestra, Apertura: "2026-09-15"},
+		"Entro 60 giorni dalla nascita":                   {Tipo: TipoEvento, Giorni: 60, Evento: "nascita"},
+		"Entro 30 giugno dell'anno successivo ai 18 anni": {Tipo: TipoEvento, ChiusuraAnnua: "06-30", Anni: 1, Evento: "18 anni"},
+		"Domanda entro il 28 febbraio per arretrati":      {Tipo: TipoAnnuale, ChiusuraAnnua: "02-28"},
+		"Bando regionale (luglio-settembre)":              {Tipo: TipoBando, AperturaAnnua: "07-01", ChiusuraAnnua: "09-30"},
+		"Bando annuale":                                   {Tipo: TipoBando},
+		"Fino ad esaurimento fondi":                       {Tipo: TipoEsaurimento},
+		"Fondi esauriti (2024)":                           {Tipo: TipoEsaurimento, Esaurito: true},
+		"In vigore (annuale)":                             {Tipo: TipoPermanente},
+		"Erogazione automatica":                           {Tipo: TipoPermanente},
+		"Entro il 2024":                                   {Tipo: TipoDataFissa, Chiusura: "2024-12-31"},
+		"Verificare sul sito ufficiale":                   {},
+		"":                                                {},
+	}
+	for testo, want := range casi {
+		if got := Parse(testo); !reflect.DeepEqual(got, want) {
+			t.Errorf("Parse(%q) = %+v, atteso %+v", testo, got, want)
+		}
+	}
+}
+
+func TestValuta(t *testing.T) {
+	now := time.Date(2026, time.February, 20, 15, 0, 0, 0, time.UTC)
+	giorno := func(s string) time.Time { d, _ := time.Parse(layoutData, s); return d }
+
+	fissa := models.Termine{Tipo: TipoDataFissa, Chiusura: "2026-02-20"}
+	if Expired(fissa, now) || !Expired(fissa, now.AddDate(0, 0, 1)) {
+		t.Error("un termine fisso vale fino alla fine del suo giorno")
+	}
+	if n, ok := DaysLeft(fissa, now); !ok || n != 0 {
+		t.Errorf("giorni mancanti il giorno della scadenza = %d, %v", n, ok)
+	}
+
+	arretrati := Parse("Domanda entro il 28 febbraio per arretrati")
+	if c := Closing(arretrati, now); !c.Equal(giorno("2026-02-28")) {
+		t.Errorf("prossima scadenza annuale = %v", c)
+	}
+	if c := Closing(arretrati, now.AddDate(0, 1, 0)); !c.Equal(giorno("2027-02-28")) {
+		t.Errorf("dopo il 28 febbraio la scadenza passa all'anno dopo: %v", c)
+	}
+	if Expired(arretrati, now.AddDate(1, 0, 0)) || !Recurring(arretrati) {
+		t.Error("una scadenza annuale non scade mai")
+	}
+
+	bando := Parse("Bando regionale (luglio-settembre)")
+	if IsOpen(bando, now) || !IsOpen(bando, giorno("2026-08-10")) {
+		t.Error("il bando è aperto solo da luglio a settembre")
+	}
+	if a := Opening(bando, now); !a.Equal(giorno("2026-07-01")) {
+		t.Errorf("apertura del bando = %v", a)
+	}
+
+	nascita := Parse("Entro 60 giorni dalla nascita")
+	if !Closing(nascita, now).IsZero() || Expired(nascita, now) {
+		t.Error("un termine da evento non ha una data propria")
+	}
+	if d := EventDeadline(nascita, giorno("2026-01-10")); !d.Equal(giorno("2026-03-11")) {
+		t.Errorf("60 giorni dal 10 gennaio = %v", d)
+	}
+	diciottesimo := Parse("Entro 30 giugno dell'anno successivo ai 18 anni")
+	if d := EventDeadline(diciottesimo, giorno("2025-05-04")); !d.Equal(giorno("2026-06-30")) {
+		t.Errorf("30 giugno dopo i 18 anni = %v", d)
+	}
+
+	if !Expired(Parse("Fondi esauriti (2024)"), now) || Expired(Parse("Fino ad esaurimento fondi"), now) {
+		t.Error("esaurimento fondi")
+	}
+
+	if e := Label(Parse("10 marzo 2026"), "10 marzo 2026", now); e != "10 marzo 2026 (tra 18 giorni)" {
+		t.Errorf("etichetta = %q", e)
+	}
+	if e := Label(arretrati, "Entro il 28 febbraio", now); e != "Entro il 28 febbraio (prossima scadenza 28/02/2026)" {
+		t.Errorf("etichetta annuale = %q", e)
+	}
+	if e := Label(nascita, "Entro 60 giorni dalla nascita", now); e != "Entro 60 giorni dalla nascita" {
+		t.Errorf("etichetta da evento = %q", e)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	validi := []models.Termine{
+		{Tipo: TipoDataFissa, Chiusura: "2026-12-31"},
+		{Tipo: TipoEvento, Giorni: 60, Evento: "nascita"},
+		{Tipo: TipoAnnuale, ChiusuraAnnua: "02-29"},
+		{Tipo: TipoBando},
+	}
+	for _, v := range validi {
+		if err := Validate(v); err != nil {
+			t.Errorf("Validate(%+v) = %v", v, err)
+		}
+	}
+	errati := []models.Termine{
+		{Tipo: TipoDataFissa},
+		{Tipo: TipoDataFissa, Chiusura: "31/12/2026"},
+		{Tipo: TipoFinestra, Apertura: "2026-05-01", Chiusura: "2026-04-01"},
+		{Tipo: TipoEvento, Giorni: 60},
+		{Tipo: TipoAnnuale, ChiusuraAnnua: "13-01"},
+		{Tipo: "quando capita"},
+	}
+	for _, e := range errati {
+		if Validate(e) == nil {
+			t.Errorf("Validate(%+v) senza errore", e)
+		}
+	}
+}
+
+func TestPersonal(t *testing.T) {
+	now := time.Date(2026, time.February, 20, 15, 0, 0, 0, time.UTC)
+	p := models.UserProfile{DataContrattoAffitto: "2026-01-31", DataNascitaFiglio: "non-una-data"}
+
+	affitto := models.Termine{Tipo: TipoEvento, Giorni: 30, Evento: "registrazione del contratto di locazione"}
+	if sp := Personal(affitto, p, now); sp == nil || *sp != (models.ScadenzaPersonale{Data: "2026-03-02", GiorniMancanti: 10, Evento: EventoContratto}) {
+		t.Errorf("30 giorni dal contratto = %+v", sp)
+	}
+	if sp := Personal(Parse("Entro 60 giorni dalla nascita"), p, now); sp != nil {
+		t.Errorf("data di nascita non valida: %+v", sp)
+	}
+	if Event(Parse("Entro 30 giugno dell'anno successivo ai 18 anni")) != "" {
+		t.Error("i 18 anni non sono un evento del profilo")
+	}
+
+	// Senza evento vale la prossima chiusura, per tutti uguale
+	if sp := Personal(Parse("Domanda entro il 28 febbraio per arretrati"), p, now); sp == nil || sp.GiorniMancanti != 8 || sp.Evento != "" {
+		t.Errorf("scadenza annuale = %+v", sp)
+	}
+	if Personal(Parse("31 dicembre 2025"), p, now) != nil {
+		t.Error("un termine scaduto per tutti non è una scadenza personale")
+	}
+}
@@ -0,0 +1,87 @@
+package deadline
+
+import (
+	"bonusperme/internal/models"
+	"strings"
+	"time"
+)
+
+// Eventi della vita del richiedente da cui decorrono i termini.
+const (
+	EventoNascita   = "nascita"   // nascita o adozione di un figlio
+	EventoContratto = "contratto" // decorrenza del contratto di affitto
+	EventoRogito    = "rogito"    // atto di acquisto della casa
+	EventoLavori    = "lavori"    // inizio dei lavori di ristrutturazione
+)
+
+// parole maps the words of Termine.Evento to the events of the profile.
+var parole = []struct {
+	evento string
+	parole []string
+}{
+	{EventoNascita, []string{"nascita", "adozione", "affidamento", "ingresso in famiglia"}},
+	{EventoContratto, []string{"contratto", "locazione", "affitto"}},
+	{EventoRogito, []string{"rogito", "acquisto", "compravendita"}},
+	{EventoLavori, []string{"lavori", "ristrutturazione"}},
+}
+
+// Event returns the profile event a da_evento deadline counts from, "" when
+// the profile cannot tell (e.g. "18 anni").
+func Event(t models.Termine) string {
+	if t.Tipo != TipoEvento {
+		return ""
+	}
+	testo := strings.ToLower(t.Evento)
+	for _, p := range parole {
+		for _, w := range p.parole {
+			if strings.Contains(testo, w) {
+				return p.evento
+			}
+		}
+	}
+	return ""
+}
+
+// EventDate returns the day of evento in the profile, zero when not given or
+// not a valid AAAA-MM-GG date.
+func EventDate(p models.UserProfile, evento string) time.Time {
+	var s string
+	switch evento {
+	case EventoNascita:
+		s = p.DataNascitaFiglio
+	case EventoContratto:
+		s = p.DataContrattoAffitto
+	case EventoRogito:
+		s = p.DataRogito
+	case EventoLavori:
+		s = p.DataInizioLavori
+	}
+	d, _ := data(s)
+	return d
+}
+
+// Personal returns the deadline of t for the user of profile p at now: the
+// day counted from the user's own event for a da_evento deadline, the next
+// closing day otherwise. It is nil when there is no concrete day, and for
+// deadlines already expired for everyone. GiorniMancanti is negative once
+// the day has passed.
+func Personal(t models.Termine, p models.UserProfile, now time.Time) *models.ScadenzaPersonale {
+	var (
+		c      time.Time
+		evento string
+	)
+	if t.Tipo == TipoEvento {
+		evento = Event(t)
+		c = EventDeadline(t, EventDate(p, evento))
+	} else if !Expired(t, now) {
+		c = Closing(t, now)
+	}
+	if c.IsZero() {
+		return nil
+	}
+	return &models.ScadenzaPersonale{
+		Data:           c.Format(layoutData),
+		GiorniMancanti: int(c.Sub(giorno(now)).Hours() / 24),
+		Evento:         evento,
+	}
+}
@@ -0,0 +1,242 @@
+// Package dsu reads the ISEE attestation (attestazione ISEE) that INPS issues
+// for a Dichiarazione Sostitutiva Unica, either as PDF or as extracted text.
+package dsu
+
+import (
+	"bonusperme/internal/models"
+	"bytes"
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// ErrISEENotFound is returned when the document contains no ISEE value.
+var ErrISEENotFound = errors.New("nessun valore ISEE trovato nel documento")
+
+// Attestazione holds the values read from an ISEE attestation.
+// Amounts are in euro; zero means the value is not in the document.
+type Attestazione struct {
+	Protocollo   string    `json:"protocollo,omitempty"`
+	DataRilascio time.Time `json:"data_rilascio,omitempty"`
+	DataScadenza time.Time `json:"data_scadenza,omitempty"`
+
+	ISEEOrdinario      float64 `json:"isee_ordinario,omitempty"`
+	ISEEMinorenni      float64 `json:"isee_minorenni,omitempty"`
+	ISEEUniversita     float64 `json:"isee_universita,omitempty"`
+	ISEESociosanitario float64 `json:"isee_sociosanitario,omitempty"`
+
+	// Indicatori dell'ISEE ordinario
+	ISR              float64 `json:"isr,omitempty"`
+	ISP              float64 `json:"isp,omitempty"`
+	ScalaEquivalenza float64 `json:"scala_equivalenza,omitempty"`
+	Componenti       int     `json:"componenti,omitempty"`
+}
+
+// Expired reports whether the attestation is no longer valid at t.
+func (a Attestazione) Expired(t time.Time) bool {
+	return !a.DataScadenza.IsZero() && t.After(a.DataScadenza.AddDate(0, 0, 1))
+}
+
+// Fill fills the ISEE variants of the profile found in the attestation.
+func (a Attestazione) Fill(p *models.UserProfile) {
+	for tipo, v := range map[string]float64{
+		models.ISEEOrdinario:      a.ISEEOrdinario,
+		models.ISEEMinorenni:      a.ISEEMinorenni,
+		models.ISEEUniversitario:  a.ISEEUniversita,
+		models.ISEESociosanitario: a.ISEESociosanitario,
+	} {
+		if v > 0 {
+			p.SetISEE(tipo, v)
+		}
+	}
+}
+
+// ParsePDF extracts the text of an attestation PDF and parses it.
+func ParsePDF(data []byte) (Attestazione, error) {
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return Attestazione{}, err
+	}
+	var sb strings.Builder
+	for i := 1; i <= r.NumPage(); i++ {
+		p := r.Page(i)
+		if p.V.IsNull() {
+			continue
+		}
+		text, err := p.GetPlainText(nil)
+		if err != nil {
+			continue
+		}
+		sb.WriteString(text)
+		sb.WriteString(" ")
+	}
+	return Parse(sb.String())
+}
+
+// numero matches an amount in Italian format: 12.345,67 / 12345,67 / 12.345 / 2,46
+const numero = `([0-9]{1,3}(?:\.[0-9]{3})+(?:,[0-9]{1,2})?|[0-9]+(?:,[0-9]{1,4})?)`
+
+// Variant headings. The attestation has one section per ISEE variant; values
+// are looked up inside the section of their variant.
+var (
+	reMinorenni      = regexp.MustCompile(`(?i)ISEE\s+(?:per\s+(?:le\s+)?prestazioni\s+(?:rivolte\s+)?a(?:i|gli)\s+)?minorenni`)
+	reUniversita     = regexp.MustCompile(`(?i)ISEE\s+(?:per\s+(?:le\s+)?prestazioni\s+per\s+il\s+diritto\s+allo\s+studio\s+)?universit(?:à|a'|a|ario|aria)`)
+	reSociosanitario = regexp.MustCompile(`(?i)ISEE\s+socio[\s-]*sanitari[oa](\s*[-–]?\s*residenz[ae])?`)
+	reOrdinario      = regexp.MustCompile(`(?i)ISEE\s+ordinario`)
+)
+
+// reValore matches the ISEE value: the word ISEE followed only by words of
+// a variant heading, so that ISR/ISP amounts of the same table are skipped.
+var reValore = regexp.MustCompile(`(?i)(?:\bISEE\b|Indicatore\s+della\s+Situazione\s+Economica\s+Equivalente)` +
+	`(?:[\s:€=()-]|\bvalore\b|\bordinario\b|\bper\b|\ble\b|\bprestazioni\b|\brivolte\b|\bai\b|\bagli\b|\bminorenni\b|` +
+	`\bil\b|\bdiritto\b|\ballo\b|\bstudio\b|universit\S*|socio[\s-]*sanitari[oa]|è|\bpari\b|\ba\b|\beuro\b)*` + numero)
+
+var (
+	reISR        = regexp.MustCompile(`(?i)(?:\bISR\b|situazione\s+reddituale)[^0-9]{0,60}?` + numero)
+	reISP        = regexp.MustCompile(`(?i)(?:\bISP\b|situazione\s+patrimoniale)[^0-9]{0,60}?` + numero)
+	reScala      = regexp.MustCompile(`(?i)scala\s+di\s+equivalenza[^0-9]{0,20}?([0-9]+(?:[.,][0-9]{1,4})?)`)
+	reComponenti = regexp.MustCompile(`(?i)(?:nucleo\s+familiare\s+(?:è\s+)?composto\s+da|numero\s+(?:dei\s+)?componenti(?:\s+del\s+nucleo(?:\s+familiare)?)?)[\s:]*([0-9]{1,2})\b`)
+	reProtocollo = regexp.MustCompile(`(?i)INPS-ISEE-[0-9]{4}-[0-9A-Z]+-[0-9]{2}`)
+	reRilascio   = regexp.MustCompile(`(?i)(?:rilasciat[ao](?:\s+(?:in\s+data|il))?|data\s+(?:di\s+)?rilascio|data\s+(?:di\s+)?presentazione(?:\s+della\s+DSU)?|sottoscritta\s+(?:in\s+data|il))[^0-9]{0,20}?([0-9]{2}/[0-9]{2}/[0-9]{4})`)
+	reScadenza   = regexp.MustCompile(`(?i)(?:valid[ao]\s+fino\s+al|data\s+(?:di\s+)?scadenza|scade\s+il)[^0-9]{0,20}?([0-9]{2}/[0-9]{2}/[0-9]{4})`)
+)
+
+// sezione is the span of the text describing one ISEE variant.
+type sezione struct {
+	variante   string
+	start, end int
+}
+
+// sezioni splits text at the variant headings.
+func sezioni(text string) []sezione {
+	var out []sezione
+	for _, h := range []struct {
+		variante string
+		re       *regexp.Regexp
+	}{
+		{"ordinario", reOrdinario},
+		{"minorenni", reMinorenni},
+		{"universita", reUniversita},
+		{"sociosanitario", reSociosanitario},
+	} {
+		for _, m := range h.re.FindAllStringSubmatchIndex(text, -1) {
+			v := h.variante
+			// The ISEE for residential care is a different value
+			if v == "sociosanitario" && m[2] >= 0 {
+				v = "residenze"
+			}
+			out = append(out, sezione{variante: v, start: m[0]})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].start < out[j].start })
+	for i := range out {
+		out[i].end = len(text)
+		if i+1 < len(out) {
+			out[i].end = out[i+1].start
+		}
+	}
+	return out
+}
+
+// Parse reads an attestation from its text.
+func Parse(text string) (Attestazione, error) {
+	text = strings.Join(strings.Fields(text), " ")
+	var a Attestazione
+
+	secs := sezioni(text)
+	// Text before the first heading belongs to the ordinary ISEE, which is the
+	// only value in attestations without variants.
+	ordinario := text
+	if len(secs) > 0 {
+		ordinario = text[:secs[0].start]
+	}
+	valori := map[string]*float64{
+		"ordinario":      &a.ISEEOrdinario,
+		"minorenni":      &a.ISEEMinorenni,
+		"universita":     &a.ISEEUniversita,
+		"sociosanitario": &a.ISEESociosanitario,
+	}
+	for _, s := range secs {
+		dst, ok := valori[s.variante]
+		if !ok || *dst > 0 {
+			continue
+		}
+		*dst = primoNumero(reValore, text[s.start:s.end])
+		if s.variante == "ordinario" {
+			ordinario = text[s.start:s.end]
+		}
+	}
+	if a.ISEEOrdinario == 0 {
+		a.ISEEOrdinario = primoNumero(reValore, ordinario)
+	}
+	if a.ISEEOrdinario == 0 && a.ISEEMinorenni == 0 && a.ISEEUniversita == 0 && a.ISEESociosanitario == 0 {
+		return a, ErrISEENotFound
+	}
+
+	// Indicators are read from the ordinary section first, then from the whole text
+	for _, f := range []struct {
+		re  *regexp.Regexp
+		dst *float64
+	}{{reISR, &a.ISR}, {reISP, &a.ISP}, {reScala, &a.ScalaEquivalenza}} {
+		if *f.dst = primoNumero(f.re, ordinario); *f.dst == 0 {
+			*f.dst = primoNumero(f.re, text)
+		}
+	}
+	if m := reComponenti.FindStringSubmatch(text); m != nil {
+		a.Componenti, _ = strconv.Atoi(m[1])
+	}
+	a.Protocollo = strings.ToUpper(reProtocollo.FindString(text))
+	a.DataRilascio = primaData(reRilascio, text)
+	a.DataScadenza = primaData(reScadenza, text)
+	// Since 2020 every attestation expires on 31 December of the year it is issued
+	if a.DataScadenza.IsZero() && !a.DataRilascio.IsZero() {
+		a.DataScadenza = time.Date(a.DataRilascio.Year(), time.December, 31, 0, 0, 0, 0, time.Local)
+	}
+	return a, nil
+}
+
+// reAnno matches a bare year, as in "Attestazione ISEE 2025" or in the protocol.
+var reAnno = regexp.MustCompile(`^(?:19|20)[0-9]{2}$`)
+
+func primoNumero(re *regexp.Regexp, text string) float64 {
+	for _, m := range re.FindAllStringSubmatch(text, -1) {
+		if n := m[len(m)-1]; !reAnno.MatchString(n) {
+			return ParseAmount(n)
+		}
+	}
+	return 0
+}
+
+func primaData(re *regexp.Regexp, text string) time.Time {
+	m := re.FindStringSubmatch(text)
+	if m == nil {
+		return time.Time{}
+	}
+	t, err := time.ParseInLocation("02/01/2006", m[1], time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// ParseAmount converts an amount in Italian format ("18.432,00") to float64.
+// A dot followed by exactly three digits is a thousands separator.
+func ParseAmount(s string) float64 {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, ",") {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.Replace(s, ",", ".", 1)
+	} else if i := strings.LastIndex(s, "."); i >= 0 && len(s)-i-1 == 3 {
+		s = strings.ReplaceAll(s, ".", "")
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
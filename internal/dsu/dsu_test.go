@@ -0,0 +1,93 @@
+package dsu
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// attestazione is the text extracted from an attestation with all variants,
+// laid out as in the INPS PDF (one section per ISEE variant).
+const attestazione = `ATTESTAZIONE ISEE 2025
+Protocollo DSU: INPS-ISEE-2025-00123456G-00
+Data di presentazione della DSU: 14/01/2025
+La presente attestazione è valida fino al 31/12/2025
+Il nucleo familiare è composto da 4 componenti
+ISEE ORDINARIO
+ISR Indicatore della situazione reddituale 24.310,50
+ISP Indicatore della situazione patrimoniale 8.000,00
+ISE 25.910,50 Scala di equivalenza 2,46
+ISEE 10.532,72
+ISEE per prestazioni rivolte ai minorenni
+ISEE 9.870,15
+ISEE per prestazioni per il diritto allo studio universitario
+ISEE 11.200,00
+ISEE socio-sanitario - residenze
+ISEE 14.000,00
+ISEE socio sanitario
+ISEE 12.345,00`
+
+func TestParse_Varianti(t *testing.T) {
+	a, err := Parse(attestazione)
+	if err != nil {
+		t.Fatalf("errore inatteso: %v", err)
+	}
+	checks := []struct {
+		nome      string
+		got, want float64
+	}{
+		{"ISEE ordinario", a.ISEEOrdinario, 10532.72},
+		{"ISEE minorenni", a.ISEEMinorenni, 9870.15},
+		{"ISEE università", a.ISEEUniversita, 11200},
+		{"ISEE sociosanitario", a.ISEESociosanitario, 12345},
+		{"ISR", a.ISR, 24310.50},
+		{"ISP", a.ISP, 8000},
+		{"scala di equivalenza", a.ScalaEquivalenza, 2.46},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s: atteso %v, ottenuto %v", c.nome, c.want, c.got)
+		}
+	}
+	if a.Componenti != 4 {
+		t.Errorf("componenti: atteso 4, ottenuto %d", a.Componenti)
+	}
+	if a.Protocollo != "INPS-ISEE-2025-00123456G-00" {
+		t.Errorf("protocollo inatteso: %q", a.Protocollo)
+	}
+	if a.DataRilascio.Format("2006-01-02") != "2025-01-14" || a.DataScadenza.Format("2006-01-02") != "2025-12-31" {
+		t.Errorf("date inattese: rilascio %v, scadenza %v", a.DataRilascio, a.DataScadenza)
+	}
+	if a.Expired(time.Date(2025, 12, 31, 18, 0, 0, 0, time.Local)) || !a.Expired(time.Date(2026, 1, 2, 0, 0, 0, 0, time.Local)) {
+		t.Error("validità dell'attestazione non calcolata correttamente")
+	}
+}
+
+func TestParse_SoloOrdinario(t *testing.T) {
+	a, err := Parse("Attestazione rilasciata il 03/02/2025. Valore ISEE: € 18.432,00 Numero componenti del nucleo: 2")
+	if err != nil {
+		t.Fatalf("errore inatteso: %v", err)
+	}
+	if a.ISEEOrdinario != 18432 || a.Componenti != 2 {
+		t.Errorf("valori inattesi: %+v", a)
+	}
+	// Without an explicit expiry the attestation lasts until the end of the year
+	if a.DataScadenza.Format("2006-01-02") != "2025-12-31" {
+		t.Errorf("scadenza predefinita inattesa: %v", a.DataScadenza)
+	}
+}
+
+func TestParse_DocumentoNonValido(t *testing.T) {
+	if _, err := Parse("Ricevuta di presentazione della DSU 2025"); !errors.Is(err, ErrISEENotFound) {
+		t.Errorf("atteso ErrISEENotFound, ottenuto %v", err)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	cases := map[string]float64{"18.432,00": 18432, "18432,5": 18432.5, "18.432": 18432, "2,46": 2.46, "950": 950}
+	for in, want := range cases {
+		if got := ParseAmount(in); got != want {
+			t.Errorf("ParseAmount(%q) = %v, atteso %v", in, got, want)
+		}
+	}
+}
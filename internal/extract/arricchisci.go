@@ -0,0 +1,49 @@
+package extract
+
+import (
+	"bonusperme/internal/logger"
+	"bonusperme/internal/models"
+)
+
+// maxPagine bounds the detail pages opened per listing, to stay polite.
+const maxPagine = 25
+
+// Enrich opens the detail page linked by each bonus of a listing on an
+// official site and fills the fields found. fetch downloads a page and is
+// expected to space requests to the same site, as crawler.Get does. It returns the number of bonuses enriched.
+func Enrich(bonuses []models.Bonus, fetch func(url string) ([]byte, error)) int {
+	letti := make(map[string]*Dettaglio)
+	aperte, arricchiti := 0, 0
+	for i := range bonuses {
+		b := &bonuses[i]
+		estrai := ForURL(b.LinkUfficiale)
+		if estrai == nil || b.LinkUfficiale == b.FonteURL {
+			continue
+		}
+		d, ok := letti[b.LinkUfficiale]
+		if !ok {
+			if aperte >= maxPagine {
+				continue
+			}
+			aperte++
+			letti[b.LinkUfficiale] = nil
+			body, err := fetch(b.LinkUfficiale)
+			if err != nil {
+				logger.Warn("extract: cannot fetch detail page", map[string]interface{}{"url": b.LinkUfficiale, "error": err.Error()})
+				continue
+			}
+			det, err := estrai(body)
+			if err != nil || det.Empty() {
+				logger.Warn("extract: no fields found in detail page", map[string]interface{}{"url": b.LinkUfficiale})
+				continue
+			}
+			d = &det
+			letti[b.LinkUfficiale] = d
+		}
+		if d != nil {
+			d.Apply(b)
+			arricchiti++
+		}
+	}
+	return arricchiti
+}
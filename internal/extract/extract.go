@@ -0,0 +1,407 @@
+// Package extract reads the detail page of a bonus on an official site (INPS,
+// Agenzia delle Entrate) and pulls out the fields the listing pages lack:
+// amount, deadline, requirements, documents, how to apply and normative
+// references.
+//
+// Detail pages of both sites are a sequence of sections introduced by a
+// heading ("A chi è rivolto", "Quanto spetta", "Normativa e prassi"...).
+// Each extractor maps the headings of its site to fields; content is read
+// from list items, paragraphs and table rows of the section.
+package extract
+
+import (
+	"bonusperme/internal/deadline"
+	"bonusperme/internal/models"
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Dettaglio holds the fields read from a detail page. Empty fields were not
+// found and must not overwrite existing data.
+type Dettaglio struct {
+	Titolo               string    `json:"titolo,omitempty"`
+	Descrizione          string    `json:"descrizione,omitempty"`
+	Importo              string    `json:"importo,omitempty"`
+	Scadenza             string    `json:"scadenza,omitempty"`
+	ScadenzaData         time.Time `json:"scadenza_data,omitzero"`
+	Requisiti            []string  `json:"requisiti,omitempty"`
+	Documenti            []string  `json:"documenti,omitempty"`
+	ComeRichiederlo      []string  `json:"come_richiederlo,omitempty"`
+	RiferimentiNormativi []string  `json:"riferimenti_normativi,omitempty"`
+}
+
+// Empty reports whether nothing useful was found.
+func (d Dettaglio) Empty() bool {
+	return d.Importo == "" && d.Scadenza == "" && len(d.Requisiti) == 0 &&
+		len(d.Documenti) == 0 && len(d.ComeRichiederlo) == 0 && len(d.RiferimentiNormativi) == 0
+}
+
+// Apply copies the fields found into b, replacing the listing placeholders.
+func (d Dettaglio) Apply(b *models.Bonus) {
+	if d.Descrizione != "" {
+		b.Descrizione = d.Descrizione
+	}
+	if d.Importo != "" {
+		b.Importo = d.Importo
+	}
+	if d.Scadenza != "" {
+		// The deadline package reads the new text, as for the scraped listings
+		b.Scadenza = d.Scadenza
+		b.Termine = nil
+		deadline.Populate(b)
+	}
+	if len(d.Requisiti) > 0 {
+		b.Requisiti = d.Requisiti
+	}
+	if len(d.Documenti) > 0 {
+		b.Documenti = d.Documenti
+	}
+	if len(d.ComeRichiederlo) > 0 {
+		b.ComeRichiederlo = d.ComeRichiederlo
+	}
+	if len(d.RiferimentiNormativi) > 0 {
+		b.RiferimentiNormativi = d.RiferimentiNormativi
+	}
+}
+
+// Section kinds.
+const (
+	sezDescrizione = "descrizione"
+	sezRequisiti   = "requisiti"
+	sezImporto     = "importo"
+	sezScadenza    = "scadenza"
+	sezDomanda     = "domanda"
+	sezDocumenti   = "documenti"
+	sezNormativa   = "normativa"
+)
+
+// heading maps a section kind to the heading prefixes that introduce it,
+// lower case and without accents.
+type heading struct {
+	sezione  string
+	prefissi []string
+}
+
+// sito describes the detail pages of one site. The first heading that
+// matches wins, so specific prefixes come before generic ones.
+type sito struct {
+	headings []heading
+}
+
+// INPS extracts a "scheda servizio" page of inps.it.
+func INPS(body []byte) (Dettaglio, error) {
+	return inps.estrai(body)
+}
+
+// AdE extracts a bonus page of agenziaentrate.gov.it.
+func AdE(body []byte) (Dettaglio, error) {
+	return ade.estrai(body)
+}
+
+// ForURL returns the extractor for the detail pages of the site of url, or nil.
+func ForURL(url string) func([]byte) (Dettaglio, error) {
+	switch {
+	case strings.Contains(url, "inps.it/"):
+		return INPS
+	case strings.Contains(url, "agenziaentrate.gov.it/"):
+		return AdE
+	}
+	return nil
+}
+
+var inps = sito{headings: []heading{
+	{sezDocumenti, []string{"documenti", "documentazione", "cosa serve", "allegati"}},
+	{sezScadenza, []string{"quando fare domanda", "quando presentare", "scadenz", "termini", "decorrenza e durata", "quando"}},
+	{sezImporto, []string{"quanto spetta", "cosa spetta", "importo", "misura", "come funziona"}},
+	{sezDomanda, []string{"come fare domanda", "come presentare", "come richiedere", "modalita", "come"}},
+	{sezRequisiti, []string{"a chi e rivolto", "requisiti", "chi puo", "beneficiari", "destinatari"}},
+	{sezNormativa, []string{"normativa", "riferimenti normativi", "fonti normative"}},
+	{sezDescrizione, []string{"descrizione", "che cos", "cos e", "cosa e"}},
+}}
+
+var ade = sito{headings: []heading{
+	{sezDocumenti, []string{"documenti", "documentazione", "cosa conservare", "modulistica"}},
+	{sezScadenza, []string{"scadenz", "termini", "quando"}},
+	{sezDomanda, []string{"come si richiede", "come richiedere", "come fare", "come ottenere", "come si ottiene", "adempimenti"}},
+	{sezImporto, []string{"in cosa consiste", "a quanto ammonta", "importo", "misura", "cosa spetta", "detrazione", "credito"}},
+	{sezRequisiti, []string{"chi puo usufruir", "chi ne ha diritto", "a chi spetta", "beneficiari", "requisiti", "chi puo"}},
+	{sezNormativa, []string{"normativa", "riferimenti normativi", "prassi"}},
+	{sezDescrizione, []string{"cos e", "che cos", "descrizione"}},
+}}
+
+func (s sito) sezione(titolo string) string {
+	t := pulisci(titolo)
+	for _, h := range s.headings {
+		for _, p := range h.prefissi {
+			if strings.HasPrefix(t, p) {
+				return h.sezione
+			}
+		}
+	}
+	return ""
+}
+
+// blocco is a unit of text of the page: a heading, a list item, a paragraph
+// or a table row.
+type blocco struct {
+	titolo bool
+	lista  bool
+	testo  string
+}
+
+const (
+	maxVoci      = 15
+	maxVoce      = 400
+	maxImporto   = 150
+	minParagrafo = 40
+)
+
+func (s sito) estrai(body []byte) (Dettaglio, error) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return Dettaglio{}, err
+	}
+	var d Dettaglio
+	if h1 := trova(doc, "h1"); h1 != nil {
+		d.Titolo = testo(h1)
+	}
+	root := trova(doc, "main")
+	if root == nil {
+		root = trova(doc, "article")
+	}
+	if root == nil {
+		root = doc
+	}
+	blocchi := leggiBlocchi(root)
+
+	sezioni := make(map[string][]blocco)
+	corrente := sezDescrizione
+	for _, b := range blocchi {
+		if b.titolo {
+			corrente = s.sezione(b.testo)
+			continue
+		}
+		if corrente != "" {
+			sezioni[corrente] = append(sezioni[corrente], b)
+		}
+	}
+
+	for _, b := range sezioni[sezDescrizione] {
+		if !b.lista && len(b.testo) >= minParagrafo {
+			d.Descrizione = tronca(b.testo, maxVoce)
+			break
+		}
+	}
+	d.Requisiti = voci(sezioni[sezRequisiti])
+	d.Documenti = voci(sezioni[sezDocumenti])
+	d.ComeRichiederlo = voci(sezioni[sezDomanda])
+	d.Importo = importo(sezioni[sezImporto])
+	d.Scadenza, d.ScadenzaData = scadenza(sezioni[sezScadenza])
+
+	var all strings.Builder
+	for _, b := range blocchi {
+		all.WriteString(b.testo)
+		all.WriteString("\n")
+	}
+	d.RiferimentiNormativi = References(all.String())
+	return d, nil
+}
+
+// leggiBlocchi flattens the content under n into blocks, in document order.
+func leggiBlocchi(n *html.Node) []blocco {
+	var out []blocco
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "nav", "header", "footer", "aside", "form", "button":
+				return
+			case "h2", "h3", "h4", "h5":
+				if t := testo(n); t != "" {
+					out = append(out, blocco{titolo: true, testo: t})
+				}
+				return
+			case "li":
+				if t := testo(n); t != "" {
+					out = append(out, blocco{lista: true, testo: t})
+				}
+				return
+			case "p", "dd":
+				if t := testo(n); t != "" {
+					out = append(out, blocco{testo: t})
+				}
+				return
+			case "tr":
+				var celle []string
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					if c.Type == html.ElementNode && (c.Data == "td" || c.Data == "th") {
+						if t := testo(c); t != "" {
+							celle = append(celle, t)
+						}
+					}
+				}
+				if len(celle) > 0 {
+					out = append(out, blocco{lista: true, testo: strings.Join(celle, " – ")})
+				}
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(n)
+	return out
+}
+
+// voci returns the list items of a section or, when it has none, its paragraphs.
+func voci(bs []blocco) []string {
+	var lista, paragrafi []string
+	for _, b := range bs {
+		if b.lista {
+			lista = append(lista, tronca(b.testo, maxVoce))
+		} else {
+			paragrafi = append(paragrafi, tronca(b.testo, maxVoce))
+		}
+	}
+	out := lista
+	if len(out) == 0 {
+		out = paragrafi
+	}
+	if len(out) > maxVoci {
+		out = out[:maxVoci]
+	}
+	return out
+}
+
+var (
+	reImporto = regexp.MustCompile(`(?i)(?:€\s*[0-9][0-9.]*(?:,[0-9]{1,2})?|[0-9][0-9.]*(?:,[0-9]{1,2})?\s*(?:€|euro\b)|[0-9]{1,3}(?:,[0-9]+)?\s*%)`)
+)
+
+// importo returns the first sentence of the section stating an amount or a
+// percentage; long sentences are reduced to the amount itself.
+func importo(bs []blocco) string {
+	for _, b := range bs {
+		loc := reImporto.FindStringIndex(b.testo)
+		if loc == nil {
+			continue
+		}
+		// The sentence, else its clause, else the amount alone
+		for _, virgola := range []bool{false, true} {
+			if frase := strings.TrimSpace(fraseAttorno(b.testo, loc[0], loc[1], virgola)); len(frase) <= maxImporto {
+				return frase
+			}
+		}
+		return strings.TrimSpace(b.testo[loc[0]:loc[1]])
+	}
+	return ""
+}
+
+// fraseAttorno returns the sentence of text containing [start, end), or its
+// clause when virgola is set. Separators between digits (12.345,67) do not
+// split the text.
+func fraseAttorno(text string, start, end int, virgola bool) string {
+	i := start
+	for i > 0 && !separatore(text, i-1, virgola) {
+		i--
+	}
+	j := end
+	for j < len(text) && !separatore(text, j, virgola) {
+		j++
+	}
+	return text[i:j]
+}
+
+func separatore(text string, i int, virgola bool) bool {
+	digit := func(k int) bool { return k >= 0 && k < len(text) && text[k] >= '0' && text[k] <= '9' }
+	switch text[i] {
+	case ';', '!', '?', '\n':
+		return true
+	case '.':
+		return !(digit(i-1) && digit(i+1))
+	case ',':
+		return virgola && !(digit(i-1) && digit(i+1))
+	}
+	return false
+}
+
+var mesi = map[string]time.Month{
+	"gennaio": time.January, "febbraio": time.February, "marzo": time.March, "aprile": time.April,
+	"maggio": time.May, "giugno": time.June, "luglio": time.July, "agosto": time.August,
+	"settembre": time.September, "ottobre": time.October, "novembre": time.November, "dicembre": time.December,
+}
+
+var nomiMesi = [...]string{"", "gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno",
+	"luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"}
+
+var (
+	reData = regexp.MustCompile(`(?i)\b([0-9]{1,2})(?:°|º)?\s+(gennaio|febbraio|marzo|aprile|maggio|giugno|luglio|agosto|settembre|ottobre|novembre|dicembre)\s+([0-9]{4})\b|\b([0-9]{1,2})/([0-9]{1,2})/([0-9]{4})\b`)
+	// A date introduced by these words is the deadline
+	reTermine = regexp.MustCompile(`(?i)\b(?:entro|fino al|scade|scadenza|termine|non oltre|al)\s*(?:il\s+|del\s+)?$`)
+	// Dates in these sentences do not close the applications: the arrears
+	// cut-off of a bonus that can be requested at any time
+	reNonTermine = regexp.MustCompile(`(?i)arretrat|in qualsiasi momento`)
+)
+
+// scadenza returns the deadline stated in the section: the first date
+// introduced by "entro", "fino al"... or else the last date. Dates in
+// sentences about arrears or open-ended applications are skipped.
+func scadenza(bs []blocco) (string, time.Time) {
+	var ultima time.Time
+	for _, b := range bs {
+		for _, m := range reData.FindAllStringSubmatchIndex(b.testo, -1) {
+			t, ok := data(b.testo, m)
+			if !ok {
+				continue
+			}
+			if reNonTermine.MatchString(fraseAttorno(b.testo, m[0], m[1], false)) {
+				continue
+			}
+			if reTermine.MatchString(b.testo[:m[0]]) {
+				return formatta(t), t
+			}
+			ultima = t
+		}
+	}
+	if ultima.IsZero() {
+		return "", time.Time{}
+	}
+	return formatta(ultima), ultima
+}
+
+func data(text string, m []int) (time.Time, bool) {
+	num := func(i int) int {
+		n := 0
+		for _, c := range text[m[2*i]:m[2*i+1]] {
+			n = n*10 + int(c-'0')
+		}
+		return n
+	}
+	var (
+		g, a int
+		mese time.Month
+	)
+	if m[2] >= 0 {
+		g, mese, a = num(1), mesi[strings.ToLower(text[m[4]:m[5]])], num(3)
+	} else {
+		g, mese, a = num(4), time.Month(num(5)), num(6)
+	}
+	if mese < time.January || mese > time.December || g < 1 || g > 31 {
+		return time.Time{}, false
+	}
+	t := time.Date(a, mese, g, 0, 0, 0, 0, time.UTC)
+	if t.Day() != g {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// formatta writes a date as the catalogue does: "28 febbraio 2026".
+func formatta(t time.Time) string {
+	return strconv.Itoa(t.Day()) + " " + nomiMesi[t.Month()] + " " + strconv.Itoa(t.Year())
+}
@@ -0,0 +1,97 @@
+package extract
+
+import (
+	"bonusperme/internal/models"
+	"bonusperme/internal/replay"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGolden runs the extractors on the saved pages in testdata and compares
+// the result with the .golden.json files. After an intended change of the
+// extractors, regenerate them with: go test ./internal/extract -update
+func TestGolden(t *testing.T) {
+	pages, _ := filepath.Glob(filepath.Join("testdata", "*.html"))
+	if len(pages) == 0 {
+		t.Fatal("nessuna pagina in testdata")
+	}
+	for _, page := range pages {
+		name := strings.TrimSuffix(filepath.Base(page), ".html")
+		t.Run(name, func(t *testing.T) {
+			estrai := INPS
+			if strings.HasPrefix(name, "ade_") {
+				estrai = AdE
+			}
+			body, err := os.ReadFile(page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, err := estrai(body)
+			if err != nil {
+				t.Fatalf("estrazione: %v", err)
+			}
+			replay.GoldenJSON(t, filepath.Join("testdata", name+".golden.json"), d)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	b := models.Bonus{
+		Importo:   "Vedi sito ufficiale",
+		Scadenza:  "Verificare sul sito ufficiale",
+		Requisiti: []string{"Consultare il sito ufficiale per i requisiti aggiornati"},
+		Documenti: []string{"Documento esistente"},
+	}
+	Dettaglio{Importo: "€3.600", Scadenza: "31 dicembre 2025", Requisiti: []string{"Figli sotto i 3 anni"}}.Apply(&b)
+	if b.Importo != "€3.600" || b.Scadenza != "31 dicembre 2025" || b.Requisiti[0] != "Figli sotto i 3 anni" {
+		t.Errorf("campi non applicati: %+v", b)
+	}
+	if len(b.Documenti) != 1 {
+		t.Errorf("campi vuoti del dettaglio non devono sovrascrivere: %v", b.Documenti)
+	}
+	if b.Termine == nil || b.Termine.Chiusura != "2025-12-31" || b.TipoScadenza != b.Termine.Tipo {
+		t.Errorf("termine non ricalcolato dal testo: %+v", b.Termine)
+	}
+}
+
+func TestScadenza_Arretrati(t *testing.T) {
+	bs := []blocco{{testo: "La domanda può essere presentata in qualsiasi momento dell'anno. Per ottenere gli arretrati, la domanda deve essere presentata entro il 30 giugno 2025."}}
+	if s, d := scadenza(bs); s != "" || !d.IsZero() {
+		t.Errorf("la data degli arretrati non è una scadenza: %q %v", s, d)
+	}
+	bs = append(bs, blocco{testo: "Le domande si presentano entro il 31 dicembre 2025."})
+	if s, _ := scadenza(bs); s != "31 dicembre 2025" {
+		t.Errorf("scadenza = %q, atteso 31 dicembre 2025", s)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	got := References("ai sensi del decreto-legge 4 maggio 2023, n. 48 e del D.Lgs. 230/2021, come chiarito dal messaggio INPS n. 526 del 13/02/2025; v. d.lgs. 230/2021")
+	want := []string{"Decreto-legge 4 maggio 2023, n. 48", "D.Lgs. 230/2021", "Messaggio INPS n. 526 del 13/02/2025"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Riferimenti = %q, atteso %q", got, want)
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	pagina, _ := os.ReadFile(filepath.Join("testdata", "inps_bonus_nido.html"))
+	link := "https://www.inps.it/it/it/dettaglio-scheda.bonus-asilo-nido.html"
+	aperte := 0
+	fetch := func(url string) ([]byte, error) {
+		aperte++
+		return pagina, nil
+	}
+	bonuses := []models.Bonus{
+		{Nome: "Bonus asilo nido", LinkUfficiale: link, Importo: "Vedi sito ufficiale"},
+		{Nome: "Bonus nido (domanda)", LinkUfficiale: link},
+		{Nome: "Altro sito", LinkUfficiale: "https://www.example.com/bonus"},
+	}
+	if n := Enrich(bonuses, fetch); n != 2 || aperte != 1 {
+		t.Errorf("arricchiti %d con %d pagine aperte, attesi 2 con 1", n, aperte)
+	}
+	if bonuses[0].Scadenza != "31 dicembre 2025" || bonuses[1].Importo == "" || bonuses[2].Scadenza != "" {
+		t.Errorf("bonus = %+v", bonuses)
+	}
+}
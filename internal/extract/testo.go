@@ -0,0 +1,115 @@
+package extract
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// trova returns the first element named tag under n.
+func trova(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if f := trova(c, tag); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// testo returns the text under n with whitespace collapsed.
+func testo(n *html.Node) string {
+	var sb strings.Builder
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+		case n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style"):
+			return
+		case n.Type == html.ElementNode && n.Data == "br":
+			sb.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+var accenti = strings.NewReplacer("à", "a", "è", "e", "é", "e", "ì", "i", "ò", "o", "ù", "u")
+
+// pulisci reduces a heading to lower-case words without accents and
+// punctuation: "A chi è rivolto?" -> "a chi e rivolto".
+func pulisci(s string) string {
+	s = accenti.Replace(strings.ToLower(s))
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// tronca cuts s to at most n bytes at a word boundary.
+func tronca(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n]
+	if i := strings.LastIndex(s, " "); i > 0 {
+		s = s[:i]
+	}
+	return s + "…"
+}
+
+const mese = `(?:gennaio|febbraio|marzo|aprile|maggio|giugno|luglio|agosto|settembre|ottobre|novembre|dicembre)`
+
+// Normative references: acts with date and number ("legge 30 dicembre 2024,
+// n. 207"), acts with number/year ("D.Lgs. 230/2021") and INPS circulars
+// and messages ("circolare INPS n. 33 del 2025").
+var (
+	tipoAtto = `(?:legge|decreto[\s-]+legge|decreto\s+legislativo|decreto\s+ministeriale|decreto\s+interministeriale|` +
+		`decreto\s+del\s+presidente\s+del\s+consiglio\s+dei\s+ministri|decreto\s+del\s+presidente\s+della\s+repubblica|` +
+		`l\.|d\.\s?l\.|dl|d\.\s?lgs\.?|dlgs|d\.\s?m\.|dm|dpcm|d\.p\.c\.m\.|dpr|d\.p\.r\.)`
+	reAttoData  = regexp.MustCompile(`(?i)\b` + tipoAtto + `\s+(?:del\s+)?[0-9]{1,2}°?\s+` + mese + `\s+[0-9]{4},?\s+(?:n\.|n°|numero)\s*[0-9]+`)
+	reAttoNum   = regexp.MustCompile(`(?i)\b` + tipoAtto + `\s+(?:n\.\s*)?[0-9]+/[0-9]{4}`)
+	reCircolare = regexp.MustCompile(`(?i)\b(?:circolare|messaggio)\s+(?:inps\s+)?(?:n\.|n°|numero)\s*[0-9]+` +
+		`(?:\s+del\s+(?:[0-9]{1,2}(?:/[0-9]{1,2}/|\s+` + mese + `\s+)[0-9]{4}|[0-9]{4}))?`)
+)
+
+// References returns the normative references cited in text, in order of
+// appearance and without duplicates.
+func References(text string) []string {
+	type match struct {
+		start int
+		text  string
+	}
+	var all []match
+	for _, re := range []*regexp.Regexp{reAttoData, reAttoNum, reCircolare} {
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			all = append(all, match{loc[0], text[loc[0]:loc[1]]})
+		}
+	}
+	// Document order; a reference inside a longer one is dropped
+	sort.SliceStable(all, func(i, j int) bool { return all[i].start < all[j].start })
+	var out []string
+	seen := make(map[string]bool)
+	fine := -1
+	for _, m := range all {
+		if m.start < fine {
+			continue
+		}
+		fine = m.start + len(m.text)
+		t := strings.Join(strings.Fields(m.text), " ")
+		t = strings.ToUpper(t[:1]) + t[1:]
+		if k := strings.ToLower(t); !seen[k] {
+			seen[k] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
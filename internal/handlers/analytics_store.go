@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"bonusperme/internal/logger"
+	"bonusperme/internal/storage"
+	"sync/atomic"
+	"time"
+)
+
+const analyticsKey = "totals"
+
+// analyticsSnapshot is the persisted form of the aggregate analytics.
+// It holds counters only, never anything tied to a visitor.
+type analyticsSnapshot struct {
+	PageViews  int64            `json:"page_views"`
+	APICalls   int64            `json:"api_calls"`
+	MatchCalls int64            `json:"match_calls"`
+	DailyViews map[string]int64 `json:"daily_views"`
+}
+
+// InitAnalytics restores the analytics saved by a previous run and stores
+// them periodically.
+func InitAnalytics() {
+	var s analyticsSnapshot
+	found, err := storage.Get(storage.BucketAnalytics, analyticsKey, &s)
+	if err != nil {
+		logger.Error("analytics: cannot restore", map[string]interface{}{"error": err.Error()})
+	} else if found {
+		atomic.StoreInt64(&analytics.pageViews, s.PageViews)
+		atomic.StoreInt64(&analytics.apiCalls, s.APICalls)
+		atomic.StoreInt64(&analytics.matchCalls, s.MatchCalls)
+		analytics.mu.Lock()
+		for k, v := range s.DailyViews {
+			analytics.dailyViews[k] = v
+		}
+		analytics.mu.Unlock()
+	}
+
+	go func() {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			FlushAnalytics()
+		}
+	}()
+}
+
+// FlushAnalytics stores the current analytics.
+func FlushAnalytics() {
+	s := analyticsSnapshot{
+		PageViews:  atomic.LoadInt64(&analytics.pageViews),
+		APICalls:   atomic.LoadInt64(&analytics.apiCalls),
+		MatchCalls: atomic.LoadInt64(&analytics.matchCalls),
+	}
+	analytics.mu.Lock()
+	s.DailyViews = make(map[string]int64, len(analytics.dailyViews))
+	for k, v := range analytics.dailyViews {
+		s.DailyViews[k] = v
+	}
+	analytics.mu.Unlock()
+
+	if err := storage.Put(storage.BucketAnalytics, analyticsKey, s); err != nil {
+		logger.Warn("analytics: cannot store", map[string]interface{}{"error": err.Error()})
+	}
+}
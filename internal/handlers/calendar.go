@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"bonusperme/internal/deadline"
+	"bonusperme/internal/history"
+	"bonusperme/internal/ical"
+	"bonusperme/internal/matcher"
+	"bonusperme/internal/models"
+	"bonusperme/internal/scraper"
+	"bonusperme/internal/validity"
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// feedRefresh is the polling interval suggested to subscribed calendars.
+const feedRefresh = 12 * time.Hour
+
+// voceCalendario is a bonus deadline to put in a calendar.
+type voceCalendario struct {
+	uid      string // stable across regenerations: the bonus id when known
+	nome     string
+	url      string
+	termine  models.Termine
+	chiusura time.Time // closing day, zero when unknown
+	fondi    *models.Fondi
+	sequenza int
+}
+
+// eventi returns the events of v at now: the closing day and, when
+// applications have not opened yet, the opening day or click day.
+func (v voceCalendario) eventi(now time.Time) []ical.Event {
+	// A published window or click day belongs to one edition only
+	annuale := (v.termine.Tipo == deadline.TipoAnnuale || v.termine.Tipo == deadline.TipoBando) &&
+		(v.fondi == nil || (v.fondi.Apertura.IsZero() && v.fondi.Chiusura.IsZero()))
+	oggi := giornoUTC(now)
+	var out []ical.Event
+
+	apertura := deadline.Opening(v.termine, now)
+	orario := ""
+	if f := v.fondi; f != nil && !f.Apertura.IsZero() {
+		apertura = giornoUTC(f.Apertura)
+		if f.Apertura.Hour() != 0 || f.Apertura.Minute() != 0 {
+			orario = " alle " + f.Apertura.Format("15:04")
+		}
+	}
+	if !apertura.IsZero() && (annuale || !apertura.Before(oggi)) {
+		e := ical.Event{
+			UID:         v.uid + "-apertura@bonusperme.it",
+			Sequence:    v.sequenza,
+			Day:         apertura,
+			Summary:     "Apertura domande: " + v.nome,
+			Description: "Da oggi si può presentare domanda per " + v.nome + ".",
+			URL:         v.url,
+			Yearly:      annuale,
+			Alarms:      []ical.Alarm{{Days: 1, Description: "Domani aprono le domande: " + v.nome}},
+		}
+		if v.fondi != nil && v.fondi.ClickDay {
+			e.Summary = "Click day: " + v.nome
+			e.Description = "Click day" + orario + ": i fondi vanno a chi fa domanda per primo. Prepara SPID e documenti."
+		}
+		out = append(out, e)
+	}
+
+	if !v.chiusura.IsZero() {
+		out = append(out, ical.Event{
+			UID:         v.uid + "-chiusura@bonusperme.it",
+			Sequence:    v.sequenza,
+			Day:         v.chiusura,
+			Summary:     "Scadenza: " + v.nome,
+			Description: "Ricorda di presentare domanda per " + v.nome + " prima della scadenza. Verifica requisiti su BonusPerMe.",
+			URL:         v.url,
+			Yearly:      annuale,
+			Alarms: []ical.Alarm{
+				{Days: 7, Description: "Scadenza tra 7 giorni: " + v.nome},
+				{Days: 1, Description: "Scadenza domani: " + v.nome},
+			},
+		})
+	}
+	return out
+}
+
+// giornoUTC is the calendar day of t, in its own time zone, at midnight UTC.
+func giornoUTC(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// sequenza is the SEQUENCE of the events of a bonus: the number of times its
+// deadline changed, so calendars replace the events when it moves.
+func sequenza(bonusID string) int {
+	storia, err := history.Revisions(bonusID)
+	if err != nil {
+		return 0
+	}
+	cicli := make(map[time.Time]bool)
+	for _, r := range storia {
+		if r.Campo == "scadenza" {
+			cicli[r.Data] = true
+		}
+	}
+	return len(cicli)
+}
+
+// scriviCalendario writes cal, or 204 when it has no events.
+func scriviCalendario(w http.ResponseWriter, cal ical.Calendar, disposition string) {
+	if len(cal.Events) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	var buf bytes.Buffer
+	cal.WriteTo(&buf, time.Now())
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", disposition)
+	w.Write(buf.Bytes())
+}
+
+// CalendarFeedHandler serves GET /api/calendar/{code}.ics: the deadlines of
+// the bonuses matching a profile code, as a calendar to subscribe to with
+// webcal://. Nothing is stored: the feed is rebuilt from the code at every
+// fetch, so a deadline that moves moves in the subscribed calendar too. The
+// code carries no event dates, so deadlines counted from a birth or a
+// contract are left to the one-off download of CalendarHandler.
+func CalendarFeedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	code, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/calendar/"), ".ics")
+	if !ok {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	profile, msg, ok := decodeProfileCode(code)
+	if !ok {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	result := matcher.MatchBonus(profile, scraper.GetCachedBonus())
+	validity.ApplyStatus(result.Bonus)
+
+	cal := ical.Calendar{Name: "BonusPerMe — Scadenze", Refresh: feedRefresh}
+	for _, b := range result.Bonus {
+		if b.Scaduto {
+			continue
+		}
+		v := voceCalendario{
+			uid: b.ID, nome: b.Nome, url: b.LinkUfficiale,
+			termine: deadline.Of(b), fondi: b.Fondi, sequenza: sequenza(b.ID),
+		}
+		if sp := b.ScadenzaPersonale; sp != nil && sp.GiorniMancanti >= 0 {
+			v.chiusura, _ = time.Parse("2006-01-02", sp.Data)
+		} else if b.Fondi != nil && !b.Fondi.Chiusura.IsZero() && b.Fondi.Chiusura.After(now) {
+			v.chiusura = giornoUTC(b.Fondi.Chiusura)
+		}
+		cal.Events = append(cal.Events, v.eventi(now)...)
+	}
+
+	// The code is the only key to the feed: keep it out of shared caches
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	scriviCalendario(w, cal, `inline; filename="bonusperme-scadenze.ics"`)
+}
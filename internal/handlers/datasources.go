@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"bonusperme/internal/datasource"
+	"bonusperme/internal/logger"
+	"bonusperme/internal/validity"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// AdminDatasourcesHandler serves the official data sources:
+//
+//	GET  /api/admin/datasources               configuration and last run of every source
+//	POST /api/admin/datasources/{id}/enable   switch a source on, until switched off again
+//	POST /api/admin/datasources/{id}/disable
+//	POST /api/admin/datasources/{id}/reset    clear the switch, back to DATASOURCE_<ID>
+//
+// The switch is stored and wins over DATASOURCE_<ID> from the next scrape
+// cycle, until the variable is changed.
+func AdminDatasourcesHandler(w http.ResponseWriter, r *http.Request) {
+	if !validity.CheckAdminKey(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/datasources"), "/")
+	if path == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeReviewJSON(w, datasource.Statuses())
+		return
+	}
+
+	id, action, ok := strings.Cut(path, "/")
+	if !ok || (action != "enable" && action != "disable" && action != "reset") {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var err error
+	if action == "reset" {
+		err = datasource.ResetEnabled(id)
+	} else {
+		err = datasource.SetEnabled(id, action == "enable")
+	}
+	switch {
+	case errors.Is(err, datasource.ErrUnknown):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		logger.Error("datasource: cannot switch source", map[string]interface{}{"id": id, "error": err.Error()})
+		http.Error(w, "Configurazione non disponibile", http.StatusInternalServerError)
+	default:
+		for _, s := range datasource.Statuses() {
+			if s.ID == strings.ToLower(id) {
+				writeReviewJSON(w, s)
+				return
+			}
+		}
+	}
+}
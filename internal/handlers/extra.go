@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"bonusperme/internal/deadline"
 	"bonusperme/internal/linkcheck"
 	"bonusperme/internal/matcher"
+	"bonusperme/internal/ical"
+	"bonusperme/internal/istat"
 	"bonusperme/internal/models"
+	"bonusperme/internal/regions"
 	"bonusperme/internal/scraper"
 	sentryutil "bonusperme/internal/sentry"
 	"bonusperme/internal/validity"
@@ -22,47 +26,6 @@ import (
 
 // ---------- helpers ----------
 
-var italianMonths = map[string]int{
-	"gennaio":   1,
-	"febbraio":  2,
-	"marzo":     3,
-	"aprile":    4,
-	"maggio":    5,
-	"giugno":    6,
-	"luglio":    7,
-	"agosto":    8,
-	"settembre": 9,
-	"ottobre":   10,
-	"novembre":  11,
-	"dicembre":  12,
-}
-
-var italianDateRe = regexp.MustCompile(
-	`(\d{1,2})\s+(gennaio|febbraio|marzo|aprile|maggio|giugno|luglio|agosto|settembre|ottobre|novembre|dicembre)\s+(\d{4})`,
-)
-
-var slashDateRe = regexp.MustCompile(`(\d{2})/(\d{2})/(\d{4})`)
-
-func parseItalianDate(s string) time.Time {
-	lower := strings.ToLower(s)
-
-	if m := italianDateRe.FindStringSubmatch(lower); len(m) == 4 {
-		day, _ := strconv.Atoi(m[1])
-		month := italianMonths[m[2]]
-		year, _ := strconv.Atoi(m[3])
-		return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
-	}
-
-	if m := slashDateRe.FindStringSubmatch(s); len(m) == 4 {
-		day, _ := strconv.Atoi(m[1])
-		month, _ := strconv.Atoi(m[2])
-		year, _ := strconv.Atoi(m[3])
-		return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
-	}
-
-	return time.Date(time.Now().Year(), 12, 31, 0, 0, 0, 0, time.UTC)
-}
-
 func slugifyName(s string) string {
 	s = strings.ToLower(s)
 	s = strings.Map(func(r rune) rune {
@@ -103,21 +66,29 @@ func parseEuroAmount(s string) float64 {
 // ---------- validateProfile ----------
 
 // Whitelists for enum fields
-var validResidenza = map[string]bool{
-	"": true, "Abruzzo": true, "Basilicata": true, "Calabria": true,
-	"Campania": true, "Emilia-Romagna": true, "Friuli-Venezia Giulia": true,
-	"Lazio": true, "Liguria": true, "Lombardia": true, "Marche": true,
-	"Molise": true, "Piemonte": true, "Puglia": true, "Sardegna": true,
-	"Sicilia": true, "Toscana": true, "Trentino-Alto Adige": true,
-	"Umbria": true, "Valle d'Aosta": true, "Veneto": true,
-}
-
 var validStatoCivile = map[string]bool{
 	"": true, "celibe/nubile": true, "coniugato/a": true,
 	"separato/a": true, "divorziato/a": true, "vedovo/a": true,
 	"unione civile": true,
 }
 
+var validDisabilita = map[string]bool{
+	"": true, models.DisabilitaMedia: true, models.DisabilitaGrave: true,
+	models.DisabilitaNonAutosufficiente: true,
+}
+
+var validTipoISEE = map[string]bool{
+	"": true, models.ISEEOrdinario: true, models.ISEEMinorenni: true,
+	models.ISEEUniversitario: true, models.ISEESociosanitario: true,
+	models.ISEECorrente: true,
+}
+
+var validRelazione = map[string]bool{
+	models.RelazioneRichiedente: true, models.RelazioneConiuge: true,
+	models.RelazioneFiglio: true, models.RelazioneGenitore: true,
+	models.RelazioneAltro: true,
+}
+
 var validOccupazione = map[string]bool{
 	"": true, "dipendente": true, "autonomo": true,
 	"disoccupato": true, "pensionato": true, "studente": true,
@@ -131,6 +102,14 @@ func validateProfile(p models.UserProfile) (string, bool) {
 	if p.ISEE < 0 || p.ISEE > 500000 {
 		return "ISEE non valido (0-500000)", false
 	}
+	for _, v := range []float64{p.ISEESimulato, p.ISEEMinorenni, p.ISEEUniversitario, p.ISEESociosanitario, p.ISEECorrente} {
+		if v < 0 || v > 500000 {
+			return "ISEE non valido (0-500000)", false
+		}
+	}
+	if !validTipoISEE[p.TipoISEESimulato] {
+		return "Tipo di ISEE non valido", false
+	}
 	if p.RedditoAnnuo < 0 || p.RedditoAnnuo > 1000000 {
 		return "Reddito annuo non valido (0-1000000)", false
 	}
@@ -146,6 +125,48 @@ func validateProfile(p models.UserProfile) (string, bool) {
 	if p.Over65 < 0 || p.Over65 > 10 {
 		return "Over 65 non valido (0-10)", false
 	}
+	if len(p.Componenti) > 20 {
+		return "Componenti del nucleo non validi (max 20)", false
+	}
+	anno := time.Now().Year()
+	richiedenti := 0
+	for _, c := range p.Componenti {
+		if c.Eta == nil && c.AnnoNascita == 0 {
+			return "Indicare eta o anno di nascita di ogni componente", false
+		}
+		if c.Eta != nil && (*c.Eta < 0 || *c.Eta > 120) {
+			return "Eta componente non valida (0-120)", false
+		}
+		if c.AnnoNascita != 0 && (c.AnnoNascita < anno-120 || c.AnnoNascita > anno) {
+			return "Anno di nascita componente non valido", false
+		}
+		if !validRelazione[c.Relazione] {
+			return "Relazione componente non valida", false
+		}
+		if !validDisabilita[c.Disabilita] {
+			return "Grado di disabilita componente non valido", false
+		}
+		if c.Relazione == models.RelazioneRichiedente {
+			richiedenti++
+		}
+	}
+	if richiedenti > 1 {
+		return "Il nucleo puo avere un solo richiedente", false
+	}
+	for _, d := range []string{p.DataNascitaFiglio, p.DataContrattoAffitto, p.DataRogito, p.DataInizioLavori} {
+		if d == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", d)
+		if err != nil || t.Year() < anno-120 || t.Year() > anno+1 {
+			return "Data evento non valida (AAAA-MM-GG)", false
+		}
+	}
+	// Counters derived from Componenti must respect the same limits
+	p.NormalizeHousehold()
+	if p.NumeroFigli > 20 || p.Over65 > 10 {
+		return "Componenti del nucleo non validi", false
+	}
 	// Cross-field checks
 	if p.FigliMinorenni > p.NumeroFigli {
 		return "Figli minorenni non puo superare numero figli", false
@@ -154,9 +175,32 @@ func validateProfile(p models.UserProfile) (string, bool) {
 		return "Figli under 3 non puo superare figli minorenni", false
 	}
 	// Whitelist checks
-	if !validResidenza[p.Residenza] {
+	if _, ok := regions.Find(p.Residenza); p.Residenza != "" && !ok {
 		return "Regione non valida", false
 	}
+	// Comune and provincia must agree with each other and with the region.
+	// An unknown comune is rejected only when the ISTAT table lists them all
+	if len(p.Comune) > 100 || len(p.Provincia) > 100 {
+		return "Comune o provincia non validi", false
+	}
+	var provincia istat.Provincia
+	var provinciaOK bool
+	if p.Provincia != "" {
+		if provincia, provinciaOK = istat.FindProvincia(p.Provincia); !provinciaOK {
+			return "Provincia non valida", false
+		}
+	}
+	if c, ok := istat.FindComune(p.Comune); ok {
+		if provinciaOK && c.Provincia != provincia.Codice {
+			return "Il comune non appartiene alla provincia indicata", false
+		}
+		provincia, provinciaOK = istat.FindProvincia(c.Provincia)
+	} else if p.Comune != "" && istat.Complete() {
+		return "Comune non valido", false
+	}
+	if provinciaOK && p.Residenza != "" && !regions.Same(provincia.Regione, p.Residenza) {
+		return "Comune o provincia non appartengono alla regione indicata", false
+	}
 	if !validStatoCivile[p.StatoCivile] {
 		return "Stato civile non valido", false
 	}
@@ -168,6 +212,10 @@ func validateProfile(p models.UserProfile) (string, bool) {
 
 // ---------- 1. CalendarHandler ----------
 
+// CalendarHandler exports the deadlines of the given bonuses as iCalendar.
+// Each item may carry the bonus id, so the catalogue deadline is used, and
+// data_evento (AAAA-MM-GG) for deadlines counted from an event of the user
+// ("entro 60 giorni dalla nascita"). Items without a certain day are left out.
 func CalendarHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -181,8 +229,11 @@ func CalendarHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var items []struct {
-		Nome     string `json:"nome"`
-		Scadenza string `json:"scadenza"`
+		ID         string          `json:"id"`
+		Nome       string          `json:"nome"`
+		Scadenza   string          `json:"scadenza"`
+		Termine    *models.Termine `json:"termine"`
+		DataEvento string          `json:"data_evento"`
 	}
 	if err := json.Unmarshal([]byte(raw), &items); err != nil {
 		http.Error(w, "Invalid bonuses JSON", http.StatusBadRequest)
@@ -194,55 +245,46 @@ func CalendarHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	now := time.Now().UTC().Format("20060102T150405Z")
-
-	var sb strings.Builder
-	sb.WriteString("BEGIN:VCALENDAR\r\n")
-	sb.WriteString("VERSION:2.0\r\n")
-	sb.WriteString("PRODID:-//BonusPerMe//IT\r\n")
-	sb.WriteString("CALSCALE:GREGORIAN\r\n")
-	sb.WriteString("METHOD:PUBLISH\r\n")
-
-	validCount := 0
+	var catalogo map[string]models.Bonus
+	now := time.Now()
+	var cal ical.Calendar
 	for _, item := range items {
 		if item.Nome == "" {
 			continue
 		}
-		validCount++
-		dt := parseItalianDate(item.Scadenza)
-		dtStr := dt.Format("20060102")
-		uid := slugifyName(item.Nome) + "@bonusperme.it"
-
-		sb.WriteString("BEGIN:VEVENT\r\n")
-		sb.WriteString("UID:" + uid + "\r\n")
-		sb.WriteString("DTSTAMP:" + now + "\r\n")
-		sb.WriteString("DTSTART;VALUE=DATE:" + dtStr + "\r\n")
-		sb.WriteString("DTEND;VALUE=DATE:" + dtStr + "\r\n")
-		sb.WriteString("SUMMARY:Scadenza: " + item.Nome + "\r\n")
-		sb.WriteString("DESCRIPTION:Ricorda di presentare domanda per " + item.Nome + " prima della scadenza. Verifica requisiti su BonusPerMe.\r\n")
-		sb.WriteString("BEGIN:VALARM\r\n")
-		sb.WriteString("TRIGGER:-P7D\r\n")
-		sb.WriteString("ACTION:DISPLAY\r\n")
-		sb.WriteString("DESCRIPTION:Scadenza tra 7 giorni: " + item.Nome + "\r\n")
-		sb.WriteString("END:VALARM\r\n")
-		sb.WriteString("BEGIN:VALARM\r\n")
-		sb.WriteString("TRIGGER:-P1D\r\n")
-		sb.WriteString("ACTION:DISPLAY\r\n")
-		sb.WriteString("DESCRIPTION:Scadenza domani: " + item.Nome + "\r\n")
-		sb.WriteString("END:VALARM\r\n")
-		sb.WriteString("END:VEVENT\r\n")
-	}
-
-	sb.WriteString("END:VCALENDAR\r\n")
-
-	if validCount == 0 {
-		w.WriteHeader(http.StatusNoContent)
-		return
+		v := voceCalendario{uid: slugifyName(item.Nome), nome: item.Nome}
+
+		// Il bonus del catalogo vince sul termine e sul testo inviati dal client
+		if item.ID != "" && catalogo == nil {
+			catalogo = make(map[string]models.Bonus)
+			for _, b := range scraper.GetCachedBonus() {
+				catalogo[b.ID] = b
+			}
+		}
+		if b, ok := catalogo[item.ID]; ok {
+			v.uid, v.url, v.fondi = b.ID, b.LinkUfficiale, b.Fondi
+			v.termine = deadline.Of(b)
+			v.sequenza = sequenza(b.ID)
+		} else if item.Termine != nil {
+			v.termine = *item.Termine
+		} else {
+			v.termine = deadline.Parse(item.Scadenza)
+		}
+
+		// Nessuna data inventata: senza un giorno certo il bonus resta fuori
+		if v.termine.Tipo == deadline.TipoEvento {
+			evento, err := time.Parse("2006-01-02", item.DataEvento)
+			if err != nil {
+				continue
+			}
+			v.chiusura = deadline.EventDeadline(v.termine, evento)
+		} else if !deadline.Expired(v.termine, now) {
+			v.chiusura = deadline.Closing(v.termine, now)
+		}
+		cal.Events = append(cal.Events, v.eventi(now)...)
 	}
 
-	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
-	w.Header().Set("Content-Disposition", `attachment; filename="bonusperme-scadenze.ics"`)
-	w.Write([]byte(sb.String()))
+	scriviCalendario(w, cal, `attachment; filename="bonusperme-scadenze.ics"`)
 }
 
 // ---------- 2. SimulateHandler ----------
@@ -273,7 +315,12 @@ func SimulateHandler(w http.ResponseWriter, r *http.Request) {
 	reale.Avvisi = validity.GenerateAvvisi(reale.Bonus)
 
 	simProfile := profile
-	simProfile.ISEE = profile.ISEESimulato
+	simProfile.SetISEE(profile.TipoISEESimulato, profile.ISEESimulato)
+	// The ISEE corrente takes the place of the ordinary one: drop it, or the
+	// simulated ordinary value would be ignored
+	if profile.TipoISEESimulato == "" || profile.TipoISEESimulato == models.ISEEOrdinario {
+		simProfile.ISEECorrente = 0
+	}
 	simulato := matcher.MatchBonus(simProfile, cachedBonus)
 	linkcheck.ApplyStatus(simulato.Bonus)
 	validity.ApplyStatus(simulato.Bonus)
@@ -978,20 +1025,21 @@ func drawBonusCardActive(pdf *gofpdf.Fpdf, b models.Bonus, profile models.UserPr
 	drawPill(pdf, pillX, y, pillText, pillBg, pillFg)
 
 	// Ente + Scadenza line
+	scad := deadline.Label(deadline.Of(b), b.Scadenza, time.Now())
 	y += 8
 	pdf.SetXY(cardInner+7, y)
 	pdf.SetFont("Helvetica", "", 8)
 	setText(pdf, cInk50)
 	enteScad := transliterate(b.Ente)
-	if b.Scadenza != "" {
+	if scad != "" {
 		enteScad += " -- Scad: "
 	}
 	pdf.CellFormat(0, 4.5, enteScad, "", 0, "L", false, 0, "")
-	if b.Scadenza != "" {
+	if scad != "" {
 		x := cardInner + 7 + pdf.GetStringWidth(enteScad)
 		pdf.SetXY(x, y)
 		setText(pdf, cTerra)
-		pdf.CellFormat(0, 4.5, transliterate(b.Scadenza), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 4.5, transliterate(scad), "", 0, "L", false, 0, "")
 	}
 	y += 7
 
@@ -1119,12 +1167,12 @@ func drawBonusCardActive(pdf *gofpdf.Fpdf, b models.Bonus, profile models.UserPr
 		pdf.WriteLinkString(4, transliterate(linkText), b.LinkUfficiale)
 	}
 
-	if b.Scadenza != "" {
+	if scad != "" {
 		pdf.SetFont("Helvetica", "", 7.5)
 		setText(pdf, cTerra)
-		scadW := pdf.GetStringWidth(transliterate(b.Scadenza)) + 2
+		scadW := pdf.GetStringWidth(transliterate(scad)) + 2
 		pdf.SetXY(cardInner+innerW-scadW, y)
-		pdf.CellFormat(scadW, 4, transliterate(b.Scadenza), "", 0, "R", false, 0, "")
+		pdf.CellFormat(scadW, 4, transliterate(scad), "", 0, "R", false, 0, "")
 	}
 	y += 6
 
@@ -1185,8 +1233,11 @@ func drawBonusCardExpired(pdf *gofpdf.Fpdf, b models.Bonus) {
 	pdf.SetFont("Helvetica", "I", 8)
 	setText(pdf, cInk50)
 	nota := "Questo bonus non e piu disponibile."
-	if b.Scadenza != "" {
-		nota += " Scaduto il " + b.Scadenza + "."
+	switch t := deadline.Of(b); {
+	case t.Tipo == deadline.TipoEsaurimento:
+		nota += " Fondi esauriti."
+	case !deadline.Closing(t, time.Now()).IsZero():
+		nota += " Scaduto il " + deadline.Closing(t, time.Now()).Format("02/01/2006") + "."
 	}
 	pdf.CellFormat(innerW-7, 4.5, transliterate(nota), "", 1, "L", false, 0, "")
 	y += 8
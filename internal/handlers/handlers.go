@@ -1,22 +1,18 @@
 package handlers
 
 import (
+	"bonusperme/internal/dsu"
 	"bonusperme/internal/linkcheck"
 	"bonusperme/internal/matcher"
 	"bonusperme/internal/models"
 	"bonusperme/internal/scraper"
 	sentryutil "bonusperme/internal/sentry"
 	"bonusperme/internal/validity"
-	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
-	"regexp"
-	"strconv"
-	"strings"
 	"time"
-
-	"github.com/ledongthuc/pdf"
 )
 
 func MatchHandler(w http.ResponseWriter, r *http.Request) {
@@ -50,6 +46,10 @@ func MatchHandler(w http.ResponseWriter, r *http.Request) {
 	linkcheck.ApplyStatus(result.Bonus)
 	validity.ApplyStatus(result.Bonus)
 	result.Avvisi = validity.GenerateAvvisi(result.Bonus)
+	// explain=true adds the bonuses missed by one or two requirements, with the reasons
+	if r.URL.Query().Get("explain") == "true" {
+		result.QuasiIdonei = matcher.AlmostEligible(profile, cachedBonus)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	// No caching - data is ephemeral
@@ -100,8 +100,10 @@ func HealthHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 type iseeResponse struct {
-	ISEE  float64 `json:"isee"`
-	Found bool    `json:"found"`
+	ISEE         float64           `json:"isee"`
+	Found        bool              `json:"found"`
+	Attestazione *dsu.Attestazione `json:"attestazione,omitempty"`
+	Scaduta      bool              `json:"scaduta,omitempty"`
 }
 
 func ParseISEEHandler(w http.ResponseWriter, r *http.Request) {
@@ -140,61 +142,24 @@ func ParseISEEHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	reader := bytes.NewReader(data)
-	pdfReader, err := pdf.NewReader(reader, int64(len(data)))
-	if err != nil {
-		sentryutil.CaptureError(err, map[string]string{"handler": "parse-isee", "phase": "pdf-parse"})
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
-		w.Header().Set("Pragma", "no-cache")
-		json.NewEncoder(w).Encode(iseeResponse{ISEE: 0, Found: false})
-		return
-	}
-
-	var textBuilder strings.Builder
-	for i := 1; i <= pdfReader.NumPage(); i++ {
-		p := pdfReader.Page(i)
-		if p.V.IsNull() {
-			continue
-		}
-		text, err := p.GetPlainText(nil)
-		if err != nil {
-			continue
-		}
-		textBuilder.WriteString(text)
-		textBuilder.WriteString(" ")
-	}
-
-	fullText := textBuilder.String()
-	iseeValue := extractISEE(fullText)
-
+	att, err := dsu.ParsePDF(data)
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
 	w.Header().Set("Pragma", "no-cache")
-
-	if iseeValue > 0 {
-		json.NewEncoder(w).Encode(iseeResponse{ISEE: iseeValue, Found: true})
-	} else {
+	if err != nil {
+		if !errors.Is(err, dsu.ErrISEENotFound) {
+			sentryutil.CaptureError(err, map[string]string{"handler": "parse-isee", "phase": "pdf-parse"})
+		}
 		json.NewEncoder(w).Encode(iseeResponse{ISEE: 0, Found: false})
+		return
 	}
-}
 
-func extractISEE(text string) float64 {
-	re := regexp.MustCompile(`(?i)(?:isee|indicatore)[:\s€]*([0-9][0-9.,]*)`)
-	matches := re.FindStringSubmatch(text)
-	if len(matches) < 2 {
-		return 0
-	}
-	numStr := matches[1]
-	// Handle Italian number format: 18.432,00 -> 18432.00
-	if strings.Contains(numStr, ",") {
-		// Remove thousand separators (dots), replace comma with dot
-		numStr = strings.ReplaceAll(numStr, ".", "")
-		numStr = strings.Replace(numStr, ",", ".", 1)
-	}
-	val, err := strconv.ParseFloat(numStr, 64)
-	if err != nil {
-		return 0
-	}
-	return val
+	var profile models.UserProfile
+	att.Fill(&profile)
+	json.NewEncoder(w).Encode(iseeResponse{
+		ISEE:         profile.ISEE,
+		Found:        true,
+		Attestazione: &att,
+		Scaduta:      att.Expired(time.Now()),
+	})
 }
@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"bonusperme/internal/history"
 	"bonusperme/internal/i18n"
+	"bonusperme/internal/models"
+	"bonusperme/internal/storage"
+	"encoding/base64"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 )
 
 func init() {
@@ -144,3 +150,355 @@ func TestBonusListHandler(t *testing.T) {
 		t.Errorf("Expected 40+ bonuses, got %d", len(bonuses))
 	}
 }
+
+func TestMatchHandler_Explain(t *testing.T) {
+	body := `{"eta":40,"residenza":"Lazio","occupazione":"disoccupato","numero_figli":1,"figli_minorenni":1,"isee":12000}`
+	req := httptest.NewRequest(http.MethodPost, "/api/match?explain=true", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	MatchHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result struct {
+		Bonus []struct {
+			Verifiche []map[string]interface{} `json:"verifiche"`
+		} `json:"bonus"`
+		QuasiIdonei []map[string]interface{} `json:"quasi_idonei"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(result.QuasiIdonei) == 0 {
+		t.Error("Con explain=true dovrebbero esserci bonus quasi idonei")
+	}
+}
+
+func TestEncodeDecodeProfile_Componenti(t *testing.T) {
+	body := `{"eta":35,"residenza":"Lazio","isee":18000,"genitori_occupati":true,"componenti":[` +
+		`{"relazione":"richiedente","eta":35},{"relazione":"coniuge","eta":33},` +
+		`{"relazione":"figlio","anno_nascita":2015,"studente":true},{"relazione":"figlio","eta":2,"disabilita":"grave"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/encode-profile", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	EncodeProfileHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Encode: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var encResult map[string]string
+	json.Unmarshal(w.Body.Bytes(), &encResult)
+
+	req2 := httptest.NewRequest(http.MethodGet, "/api/decode-profile?code="+encResult["code"], nil)
+	w2 := httptest.NewRecorder()
+	DecodeProfileHandler(w2, req2)
+	if w2.Code != http.StatusOK {
+		t.Fatalf("Decode: expected 200, got %d: %s", w2.Code, w2.Body.String())
+	}
+	var profile models.UserProfile
+	if err := json.Unmarshal(w2.Body.Bytes(), &profile); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(profile.Componenti) != 4 || !profile.GenitoriOccupati {
+		t.Fatalf("componenti non preservati: %+v", profile)
+	}
+	if c := profile.Componenti[2]; c.AnnoNascita != 2015 || !c.Studente || c.Relazione != models.RelazioneFiglio {
+		t.Errorf("figlio non preservato: %+v", c)
+	}
+	if c := profile.Componenti[3]; c.Eta == nil || *c.Eta != 2 || c.Disabilita != models.DisabilitaGrave {
+		t.Errorf("figlio non preservato: %+v", c)
+	}
+}
+
+func TestMatchHandler_ComponenteNonValido(t *testing.T) {
+	for _, body := range []string{
+		`{"eta":35,"componenti":[{"relazione":"figlio","eta":5,"disabilita":"lieve"}]}`,
+		// Neither age nor year of birth
+		`{"eta":35,"componenti":[{"relazione":"figlio"}]}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/match", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		MatchHandler(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, w.Code)
+		}
+	}
+
+	body := `{"eta":35,"componenti":[{"relazione":"figlio","eta":0}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/match", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	MatchHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("neonato con eta 0: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestMatchHandler_ComuneFuoriRegione(t *testing.T) {
+	body := `{"eta":35,"residenza":"Lazio","comune":"Milano"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/match", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	MatchHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", w.Code)
+	}
+}
+
+func TestMatchHandler_DataEventoNonValida(t *testing.T) {
+	body := `{"eta":35,"data_nascita_figlio":"10/01/2026"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/match", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	MatchHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", w.Code)
+	}
+}
+
+func TestSimulateHandler_VarianteISEE(t *testing.T) {
+	body := `{"eta":30,"nuovo_nato_2025":true,"numero_figli":1,"figli_minorenni":1,"figli_under3":1,` +
+		`"isee":52000,"isee_simulato":20000,"tipo_isee_simulato":"minorenni"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/simulate", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	SimulateHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result models.SimulateResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	trovato := func(r models.MatchResult) bool {
+		for _, b := range r.Bonus {
+			if b.ID == "bonus-nascita" {
+				return true
+			}
+		}
+		return false
+	}
+	if trovato(result.Reale) || !trovato(result.Simulato) {
+		t.Error("simulando l'ISEE minorenni il bonus nascita deve comparire solo nello scenario simulato")
+	}
+}
+
+func TestSimulateHandler_ISEECorrente(t *testing.T) {
+	body := `{"eta":30,"nuovo_nato_2025":true,"numero_figli":1,"figli_minorenni":1,"figli_under3":1,` +
+		`"isee":52000,"isee_corrente":50000,"isee_simulato":20000}`
+	req := httptest.NewRequest(http.MethodPost, "/api/simulate", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	SimulateHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result models.SimulateResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	trovato := func(r models.MatchResult) bool {
+		for _, b := range r.Bonus {
+			if b.ID == "bonus-nascita" {
+				return true
+			}
+		}
+		return false
+	}
+	if trovato(result.Reale) || !trovato(result.Simulato) {
+		t.Error("l'ISEE ordinario simulato deve prevalere sull'ISEE corrente del profilo")
+	}
+}
+
+func TestBonusHistory(t *testing.T) {
+	storage.Use(storage.NewMemory())
+	defer storage.Use(storage.NewMemory())
+	history.Record([]history.Revisione{{BonusID: "assegno-unico", Campo: "scadenza",
+		Prima: "31 dicembre", Dopo: "30 giugno", Fonte: "INPS", Ciclo: 1, Data: time.Now()}})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/bonus/assegno-unico/history", nil)
+	w := httptest.NewRecorder()
+	BonusDetailHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, atteso 200", w.Code)
+	}
+	var resp struct {
+		Revisioni []history.Revisione `json:"revisioni"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Revisioni) != 1 || resp.Revisioni[0].Prima != "31 dicembre" {
+		t.Errorf("revisioni = %+v", resp.Revisioni)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/bonus/inesistente/history", nil)
+	w = httptest.NewRecorder()
+	BonusDetailHandler(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("bonus inesistente: status = %d, atteso 404", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/history?from_cycle=0&to_cycle=1", nil)
+	w = httptest.NewRecorder()
+	AdminHistoryHandler(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"dopo":"30 giugno"`) {
+		t.Errorf("admin history: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestBonusReferences(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/bonus/bonus-nido/references", nil)
+	w := httptest.NewRecorder()
+	BonusDetailHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, atteso 200", w.Code)
+	}
+	var resp struct {
+		Riferimenti []struct {
+			Tipo     string `json:"tipo"`
+			Numero   int    `json:"numero"`
+			Articolo string `json:"articolo"`
+			Comma    string `json:"comma"`
+			URN      string `json:"urn"`
+		} `json:"riferimenti"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Riferimenti) != 2 {
+		t.Fatalf("riferimenti = %+v, attesi legge e circolare", resp.Riferimenti)
+	}
+	legge := resp.Riferimenti[0]
+	if legge.Tipo != "legge" || legge.Numero != 207 || legge.Comma != "177" ||
+		legge.URN != "urn:nir:stato:legge:2024-12-30;207~art1-com177" {
+		t.Errorf("legge di bilancio: %+v", legge)
+	}
+	if resp.Riferimenti[1].Tipo != "circolare" || resp.Riferimenti[1].URN != "" {
+		t.Errorf("circolare: %+v", resp.Riferimenti[1])
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/bonus/inesistente/references", nil)
+	w = httptest.NewRecorder()
+	BonusDetailHandler(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("bonus inesistente: status = %d, atteso 404", w.Code)
+	}
+}
+
+func TestAdminDatasources(t *testing.T) {
+	storage.Use(storage.NewMemory())
+	defer storage.Use(storage.NewMemory())
+
+	stato := func() map[string]bool {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/datasources", nil)
+		w := httptest.NewRecorder()
+		AdminDatasourcesHandler(w, req)
+		var fonti []struct {
+			ID      string `json:"id"`
+			Enabled bool   `json:"enabled"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &fonti)
+		attive := make(map[string]bool)
+		for _, f := range fonti {
+			attive[f.ID] = f.Enabled
+		}
+		return attive
+	}
+	if attive := stato(); len(attive) != 5 || !attive["inps"] || attive["gu"] {
+		t.Fatalf("fonti = %v", attive)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/datasources/GU/enable", nil)
+	w := httptest.NewRecorder()
+	AdminDatasourcesHandler(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"id":"gu","name":"GazzettaUfficiale","enabled":true`) {
+		t.Errorf("enable: %d %s", w.Code, w.Body.String())
+	}
+	if !stato()["gu"] {
+		t.Error("Gazzetta non attivata")
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/admin/datasources/gu/reset", nil)
+	w = httptest.NewRecorder()
+	AdminDatasourcesHandler(w, req)
+	if w.Code != http.StatusOK || stato()["gu"] {
+		t.Errorf("reset: %d, gu attiva = %v", w.Code, stato()["gu"])
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/admin/datasources/ministero/disable", nil)
+	w = httptest.NewRecorder()
+	AdminDatasourcesHandler(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("fonte sconosciuta: status = %d, atteso 404", w.Code)
+	}
+}
+
+func TestCalendarHandler_Scadenze(t *testing.T) {
+	calendario := func(items string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/calendar?bonuses="+url.QueryEscape(items), nil)
+		w := httptest.NewRecorder()
+		CalendarHandler(w, req)
+		return w
+	}
+
+	// 60 giorni dalla nascita del 10 gennaio: l'11 marzo, non il 31 dicembre
+	w := calendario(`[{"nome":"Bonus nuovi nati","scadenza":"Entro 60 giorni dalla nascita","data_evento":"2026-01-10"}]`)
+	if body := w.Body.String(); w.Code != http.StatusOK || !strings.Contains(body, "DTSTART;VALUE=DATE:20260311\r\n") {
+		t.Errorf("scadenza da evento: %d %s", w.Code, body)
+	}
+
+	// Senza la data dell'evento o di un termine ormai passato non c'è un giorno certo
+	w = calendario(`[{"nome":"Bonus nuovi nati","scadenza":"Entro 60 giorni dalla nascita"},{"nome":"Vecchio","scadenza":"31 dicembre 2020"}]`)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("nessuna data certa: status = %d, atteso 204", w.Code)
+	}
+
+	// Le scadenze annuali si ripetono
+	w = calendario(`[{"nome":"Arretrati","scadenza":"Domanda entro il 28 febbraio per arretrati"}]`)
+	if body := w.Body.String(); !strings.Contains(body, "0228\r\n") || !strings.Contains(body, "RRULE:FREQ=YEARLY\r\n") {
+		t.Errorf("scadenza annuale: %s", body)
+	}
+}
+
+func TestCalendarFeedHandler(t *testing.T) {
+	storage.Use(storage.NewMemory())
+	defer storage.Use(storage.NewMemory())
+
+	nascita := time.Now().AddDate(0, 0, -37)
+	body := `{"eta":32,"numero_figli":1,"figli_minorenni":1,"figli_under3":1,"nuovo_nato_2025":true,"isee":20000,` +
+		`"residenza":"Lazio","stato_civile":"coniugato/a","occupazione":"dipendente","data_nascita_figlio":"` + nascita.Format("2006-01-02") + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/encode-profile", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	EncodeProfileHandler(w, req)
+	var enc struct {
+		Code string `json:"code"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &enc)
+
+	feed := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		CalendarFeedHandler(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+	w = feed("/api/calendar/" + enc.Code + ".ics")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/calendar; charset=utf-8" {
+		t.Fatalf("feed: %d %s", w.Code, w.Body.String())
+	}
+	// La data di nascita non entra nel codice, che finisce negli URL dei
+	// calendari: il feed ha solo le scadenze non legate a eventi personali
+	data, _ := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(enc.Code, codePrefix))
+	if strings.Contains(string(data), nascita.Format("2006-01-02")) {
+		t.Errorf("data di nascita nel codice profilo: %s", data)
+	}
+	out := w.Body.String()
+	if strings.Contains(out, nascita.AddDate(0, 0, 60).Format("20060102")) {
+		t.Errorf("scadenza personale nel feed:\n%s", out)
+	}
+	if !strings.Contains(out, "UID:assegno-unico-chiusura@bonusperme.it\r\n") {
+		t.Errorf("evento Assegno Unico mancante:\n%s", out)
+	}
+	for _, l := range strings.Split(w.Body.String(), "\r\n") {
+		if len(l) > 75 {
+			t.Errorf("riga non piegata: %q", l)
+		}
+	}
+
+	if w := feed("/api/calendar/BPM-non-valido.ics"); w.Code != http.StatusBadRequest {
+		t.Errorf("codice non valido: status = %d, atteso 400", w.Code)
+	}
+	if w := feed("/api/calendar/" + enc.Code); w.Code != http.StatusNotFound {
+		t.Errorf("senza .ics: status = %d, atteso 404", w.Code)
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bonusperme/internal/history"
+	"bonusperme/internal/logger"
+	"bonusperme/internal/matcher"
+	"bonusperme/internal/validity"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// bonusHistory serves GET /api/bonus/{id}/history: every recorded change of
+// the bonus, oldest first.
+func bonusHistory(w http.ResponseWriter, id string) {
+	revs, err := history.Revisions(id)
+	if err != nil {
+		logger.Error("history: cannot read", map[string]interface{}{"bonus_id": id, "error": err.Error()})
+		http.Error(w, "Storico non disponibile", http.StatusInternalServerError)
+		return
+	}
+	if len(revs) == 0 && !bonusEsiste(id) {
+		http.Error(w, "Bonus non trovato", http.StatusNotFound)
+		return
+	}
+	if revs == nil {
+		revs = []history.Revisione{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=600")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"bonus_id":  id,
+		"revisioni": revs,
+	})
+}
+
+func bonusEsiste(id string) bool {
+	for _, b := range matcher.GetAllBonusWithRegional() {
+		if b.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminHistoryHandler serves GET /api/admin/history: the net catalogue changes
+// between two dates (from, to: YYYY-MM-DD or RFC 3339, to defaults to now) or
+// between two scrape cycles (from_cycle, to_cycle).
+func AdminHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !validity.CheckAdminKey(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	filtro, err := historyFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	revs, err := history.Search(filtro)
+	if err != nil {
+		logger.Error("history: cannot read", map[string]interface{}{"error": err.Error()})
+		http.Error(w, "Storico non disponibile", http.StatusInternalServerError)
+		return
+	}
+	modifiche := history.Diff(revs)
+	if modifiche == nil {
+		modifiche = []history.Modifica{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"revisioni": len(revs),
+		"modifiche": modifiche,
+	})
+}
+
+func historyFilter(r *http.Request) (history.Filter, error) {
+	q := r.URL.Query()
+	if q.Get("from_cycle") != "" || q.Get("to_cycle") != "" {
+		da, err1 := strconv.Atoi(q.Get("from_cycle"))
+		a, err2 := strconv.Atoi(q.Get("to_cycle"))
+		if err1 != nil || err2 != nil || da < 0 || a < da {
+			return nil, errors.New("from_cycle e to_cycle devono essere interi con from_cycle <= to_cycle")
+		}
+		return history.BetweenCycles(da, a), nil
+	}
+
+	da, ok := parseHistoryTime(q.Get("from"), false)
+	if !ok {
+		return nil, errors.New("from richiesto (YYYY-MM-DD o RFC 3339)")
+	}
+	a := time.Now()
+	if s := q.Get("to"); s != "" {
+		if a, ok = parseHistoryTime(s, true); !ok {
+			return nil, errors.New("to non valido (YYYY-MM-DD o RFC 3339)")
+		}
+	}
+	if a.Before(da) {
+		return nil, errors.New("to precede from")
+	}
+	return history.Between(da, a), nil
+}
+
+// parseHistoryTime reads a date or a timestamp. A date used as upper bound
+// includes the whole day.
+func parseHistoryTime(s string, fine bool) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if fine {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, true
+}
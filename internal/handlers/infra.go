@@ -3,6 +3,7 @@ package handlers
 import (
 	"bonusperme/internal/config"
 	"bonusperme/internal/matcher"
+	"bonusperme/internal/normattiva"
 	"bonusperme/internal/scraper"
 	"encoding/json"
 	"encoding/xml"
@@ -195,14 +196,14 @@ func serveBonusPage(w http.ResponseWriter, b interface{}) {
 		Importo             string
 		Scadenza            string
 		Requisiti           []string
-		ComeRichiederlo     []string
+		ComeRichiederlo     []string `json:"come_richiederlo"`
 		Documenti           []string
-		LinkUfficiale       string
+		LinkUfficiale       string `json:"link_ufficiale"`
 		Ente                string
-		FonteURL            string
-		FonteNome           string
-		RiferimentiNormativi []string
-		UltimoAggiornamento string
+		FonteURL            string `json:"fonte_url"`
+		FonteNome           string `json:"fonte_nome"`
+		RiferimentiNormativi []string `json:"riferimenti_normativi"`
+		UltimoAggiornamento string `json:"ultimo_aggiornamento"`
 		Stato               string
 	}
 
@@ -299,7 +300,24 @@ footer{margin-top:40px;padding-top:20px;border-top:1px solid #ddd;color:#999;fon
 			sb.WriteString(` — <a href="` + htmlEscape(bonus.FonteURL) + `" target="_blank" rel="noopener">Sito ufficiale</a>`)
 		}
 		if len(bonus.RiferimentiNormativi) > 0 {
-			sb.WriteString(`<br>Riferimenti: ` + htmlEscape(strings.Join(bonus.RiferimentiNormativi, "; ")))
+			sb.WriteString(`<br>Riferimenti: `)
+			for i, rif := range bonus.RiferimentiNormativi {
+				if i > 0 {
+					sb.WriteString("; ")
+				}
+				// Link to Normattiva when the act is published there
+				url := ""
+				for _, r := range normattiva.Parse(rif) {
+					if url = r.URL(); url != "" {
+						break
+					}
+				}
+				if url != "" {
+					sb.WriteString(`<a href="` + htmlEscape(url) + `" target="_blank" rel="noopener">` + htmlEscape(rif) + `</a>`)
+				} else {
+					sb.WriteString(htmlEscape(rif))
+				}
+			}
 		}
 		sb.WriteString(`</div>`)
 	}
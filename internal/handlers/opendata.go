@@ -30,14 +30,10 @@ func BonusListHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Try cached (enriched) bonuses first, fallback to hardcoded
+	// Cached (enriched) national and regional bonuses, fallback to hardcoded
 	allBonus := scraper.GetCachedBonus()
 	if len(allBonus) == 0 {
 		allBonus = matcher.GetAllBonusWithRegional()
-	} else {
-		// Append regional bonuses if not already present
-		regionals := matcher.GetRegionalBonus()
-		allBonus = append(allBonus, regionals...)
 	}
 
 	linkcheck.ApplyStatus(allBonus)
@@ -68,6 +64,16 @@ func BonusDetailHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Bonus ID richiesto", http.StatusBadRequest)
 		return
 	}
+	// GET /api/bonus/{id}/history
+	if id, ok := strings.CutSuffix(bonusID, "/history"); ok {
+		bonusHistory(w, id)
+		return
+	}
+	// GET /api/bonus/{id}/references
+	if id, ok := strings.CutSuffix(bonusID, "/references"); ok {
+		bonusReferences(w, id)
+		return
+	}
 
 	allBonus := matcher.GetAllBonusWithRegional()
 	linkcheck.ApplyStatus(allBonus)
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"bonusperme/internal/istat"
 	"bonusperme/internal/models"
 	"encoding/base64"
 	"encoding/json"
@@ -8,50 +9,97 @@ import (
 	"strings"
 )
 
-// compactProfile holds only non-identifying fields for the profile code.
+// compactProfile holds only non-identifying fields for the profile code. The
+// code ends up in subscribed calendar URLs, so the dates of personal events
+// (births, contracts, deeds) are never part of it.
 type compactProfile struct {
-	Eta              int     `json:"e,omitempty"`
-	NumeroFigli      int     `json:"f,omitempty"`
-	FigliMinorenni   int     `json:"fm,omitempty"`
-	FigliUnder3      int     `json:"f3,omitempty"`
-	Over65           int     `json:"o,omitempty"`
-	ISEE             float64 `json:"i,omitempty"`
-	RedditoAnnuo     float64 `json:"r,omitempty"`
-	Residenza        string  `json:"re,omitempty"`
-	StatoCivile      string  `json:"sc,omitempty"`
-	Occupazione      string  `json:"oc,omitempty"`
-	Disabilita       bool    `json:"d,omitempty"`
-	Affittuario      bool    `json:"af,omitempty"`
-	PrimaAbitazione  bool    `json:"pa,omitempty"`
-	RistrutturazCasa bool    `json:"rc,omitempty"`
-	Studente         bool    `json:"st,omitempty"`
-	NuovoNato2025    bool    `json:"nn,omitempty"`
+	Eta                int     `json:"e,omitempty"`
+	NumeroFigli        int     `json:"f,omitempty"`
+	FigliMinorenni     int     `json:"fm,omitempty"`
+	FigliUnder3        int     `json:"f3,omitempty"`
+	Over65             int     `json:"o,omitempty"`
+	ISEE               float64 `json:"i,omitempty"`
+	ISEEMinorenni      float64 `json:"im,omitempty"`
+	ISEEUniversitario  float64 `json:"iu,omitempty"`
+	ISEESociosanitario float64 `json:"is,omitempty"`
+	ISEECorrente       float64 `json:"ic,omitempty"`
+	RedditoAnnuo       float64 `json:"r,omitempty"`
+	Residenza          string  `json:"re,omitempty"`
+	Provincia          string  `json:"pr,omitempty"`
+	StatoCivile        string  `json:"sc,omitempty"`
+	Occupazione        string  `json:"oc,omitempty"`
+	Disabilita         bool    `json:"d,omitempty"`
+	Affittuario        bool    `json:"af,omitempty"`
+	PrimaAbitazione    bool    `json:"pa,omitempty"`
+	RistrutturazCasa   bool    `json:"rc,omitempty"`
+	Studente           bool    `json:"st,omitempty"`
+	NuovoNato2025      bool    `json:"nn,omitempty"`
+
+	Componenti       []compactComponente `json:"c,omitempty"`
+	GenitoriOccupati bool                `json:"go,omitempty"`
+	MadreUnder21     bool                `json:"m21,omitempty"`
+}
+
+// compactComponente is a household member in the profile code.
+type compactComponente struct {
+	Eta         *int   `json:"e,omitempty"`
+	AnnoNascita int    `json:"a,omitempty"`
+	Relazione   string `json:"r,omitempty"`
+	Disabilita  string `json:"d,omitempty"`
+	Studente    bool   `json:"s,omitempty"`
 }
 
 func toCompact(p models.UserProfile) compactProfile {
-	return compactProfile{
+	c := compactProfile{
 		Eta: p.Eta, NumeroFigli: p.NumeroFigli, FigliMinorenni: p.FigliMinorenni,
 		FigliUnder3: p.FigliUnder3, Over65: p.Over65, ISEE: p.ISEE,
-		RedditoAnnuo: p.RedditoAnnuo, Residenza: p.Residenza, StatoCivile: p.StatoCivile,
+		RedditoAnnuo: p.RedditoAnnuo, Residenza: p.Residenza, Provincia: p.Provincia, StatoCivile: p.StatoCivile,
 		Occupazione: p.Occupazione, Disabilita: p.Disabilita, Affittuario: p.Affittuario,
 		PrimaAbitazione: p.PrimaAbitazione, RistrutturazCasa: p.RistrutturazCasa,
 		Studente: p.Studente, NuovoNato2025: p.NuovoNato2025,
+		GenitoriOccupati: p.GenitoriOccupati, MadreUnder21: p.MadreUnder21,
+		ISEEMinorenni: p.ISEEMinorenni, ISEEUniversitario: p.ISEEUniversitario,
+		ISEESociosanitario: p.ISEESociosanitario, ISEECorrente: p.ISEECorrente,
+	}
+	// The comune is not encoded, only its province
+	if c.Provincia == "" {
+		if comune, ok := istat.FindComune(p.Comune); ok {
+			c.Provincia = comune.Provincia
+		}
+	}
+	for _, m := range p.Componenti {
+		c.Componenti = append(c.Componenti, compactComponente(m))
 	}
+	return c
 }
 
 func fromCompact(c compactProfile) models.UserProfile {
-	return models.UserProfile{
+	p := models.UserProfile{
 		Eta: c.Eta, NumeroFigli: c.NumeroFigli, FigliMinorenni: c.FigliMinorenni,
 		FigliUnder3: c.FigliUnder3, Over65: c.Over65, ISEE: c.ISEE,
-		RedditoAnnuo: c.RedditoAnnuo, Residenza: c.Residenza, StatoCivile: c.StatoCivile,
+		RedditoAnnuo: c.RedditoAnnuo, Residenza: c.Residenza, Provincia: c.Provincia, StatoCivile: c.StatoCivile,
 		Occupazione: c.Occupazione, Disabilita: c.Disabilita, Affittuario: c.Affittuario,
 		PrimaAbitazione: c.PrimaAbitazione, RistrutturazCasa: c.RistrutturazCasa,
 		Studente: c.Studente, NuovoNato2025: c.NuovoNato2025,
+		GenitoriOccupati: c.GenitoriOccupati, MadreUnder21: c.MadreUnder21,
+		ISEEMinorenni: c.ISEEMinorenni, ISEEUniversitario: c.ISEEUniversitario,
+		ISEESociosanitario: c.ISEESociosanitario, ISEECorrente: c.ISEECorrente,
 	}
+	for _, m := range c.Componenti {
+		// Older codes omitted an age of 0
+		if m.Eta == nil && m.AnnoNascita == 0 {
+			m.Eta = new(int)
+		}
+		p.Componenti = append(p.Componenti, models.Componente(m))
+	}
+	return p
 }
 
 const codePrefix = "BPM-"
 
+// maxCodeLen leaves room for a household of 20 members.
+const maxCodeLen = 1024
+
 // EncodeProfileHandler encodes a profile into a shareable code.
 func EncodeProfileHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -75,8 +123,9 @@ func EncodeProfileHandler(w http.ResponseWriter, r *http.Request) {
 
 	encoded := base64.RawURLEncoding.EncodeToString(data)
 	code := codePrefix + encoded
-	if len(code) > 64 {
-		code = code[:64]
+	if len(code) > maxCodeLen {
+		http.Error(w, "Profilo troppo grande per un codice", http.StatusBadRequest)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -91,40 +140,44 @@ func DecodeProfileHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	code := r.URL.Query().Get("code")
-	if code == "" || !strings.HasPrefix(code, codePrefix) {
-		http.Error(w, "Codice non valido", http.StatusBadRequest)
+	profile, msg, ok := decodeProfileCode(r.URL.Query().Get("code"))
+	if !ok {
+		http.Error(w, msg, http.StatusBadRequest)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(profile)
+}
+
+// decodeProfileCode decodes and validates a profile code; msg tells why an
+// invalid code is rejected.
+func decodeProfileCode(code string) (profile models.UserProfile, msg string, ok bool) {
+	if code == "" || !strings.HasPrefix(code, codePrefix) {
+		return profile, "Codice non valido", false
+	}
 	// Max length check to prevent abuse
-	if len(code) > 256 {
-		http.Error(w, "Codice troppo lungo", http.StatusBadRequest)
-		return
+	if len(code) > maxCodeLen {
+		return profile, "Codice troppo lungo", false
 	}
 
 	encoded := strings.TrimPrefix(code, codePrefix)
 	data, err := base64.RawURLEncoding.DecodeString(encoded)
 	if err != nil {
-		http.Error(w, "Codice malformato", http.StatusBadRequest)
-		return
+		return profile, "Codice malformato", false
 	}
 
 	var compact compactProfile
 	if err := json.Unmarshal(data, &compact); err != nil {
-		http.Error(w, "Codice non decodificabile", http.StatusBadRequest)
-		return
+		return profile, "Codice non decodificabile", false
 	}
 
-	profile := fromCompact(compact)
+	profile = fromCompact(compact)
 
 	// Validate decoded profile
 	if msg, ok := validateProfile(profile); !ok {
-		http.Error(w, msg, http.StatusBadRequest)
-		return
+		return profile, msg, false
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Cache-Control", "no-store")
-	json.NewEncoder(w).Encode(profile)
+	return profile, "", true
 }
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"bonusperme/internal/matcher"
+	"bonusperme/internal/normattiva"
+	"encoding/json"
+	"net/http"
+)
+
+// riferimento is a normative reference of a bonus with its Normattiva links.
+type riferimento struct {
+	normattiva.Riferimento
+	Citazione string `json:"citazione"`
+	URN       string `json:"urn,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// bonusReferences serves GET /api/bonus/{id}/references: the normative
+// references of the bonus as structured identifiers, linked to Normattiva
+// where the act is published there.
+func bonusReferences(w http.ResponseWriter, id string) {
+	for _, b := range matcher.GetAllBonusWithRegional() {
+		if b.ID != id {
+			continue
+		}
+		refs := []riferimento{}
+		for _, r := range normattiva.ParseAll(b.RiferimentiNormativi) {
+			refs = append(refs, riferimento{Riferimento: r, Citazione: r.String(), URN: r.URN(), URL: r.URL()})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"bonus_id":    id,
+			"testo":       b.RiferimentiNormativi,
+			"riferimenti": refs,
+		})
+		return
+	}
+	http.Error(w, "Bonus non trovato", http.StatusNotFound)
+}
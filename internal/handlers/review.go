@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"bonusperme/internal/logger"
+	"bonusperme/internal/models"
+	"bonusperme/internal/review"
+	"bonusperme/internal/validity"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// AdminReviewHandler serves the moderation queue of scraper proposals:
+//
+//	GET  /api/admin/review?stato=in_attesa   list proposals (all states when stato is empty)
+//	POST /api/admin/review/{id}/approve      {"nota": "..."}
+//	POST /api/admin/review/{id}/reject       {"nota": "..."}
+//	POST /api/admin/review/{id}/edit         {"valore": "..."} or {"bonus": {...}}
+//
+// For a match proposal (tipo "abbinamento") valore is the catalogue ID of the bonus.
+func AdminReviewHandler(w http.ResponseWriter, r *http.Request) {
+	if !validity.CheckAdminKey(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/review"), "/")
+	if path == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		proposte, err := review.List(r.URL.Query().Get("stato"))
+		if errors.Is(err, review.ErrUnknownState) {
+			http.Error(w, "stato deve essere in_attesa, approvata o rifiutata", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			logger.Error("review: cannot list proposals", map[string]interface{}{"error": err.Error()})
+			http.Error(w, "Coda non disponibile", http.StatusInternalServerError)
+			return
+		}
+		writeReviewJSON(w, proposte)
+		return
+	}
+
+	id, action, ok := strings.Cut(path, "/")
+	if !ok || id == "" {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Nota   string        `json:"nota"`
+		Valore string        `json:"valore"`
+		Bonus  *models.Bonus `json:"bonus"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "JSON non valido", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var (
+		p   review.Proposta
+		err error
+	)
+	switch action {
+	case "approve":
+		p, err = review.Approve(id, req.Nota)
+	case "reject":
+		p, err = review.Reject(id, req.Nota)
+	case "edit":
+		p, err = review.Edit(id, req.Valore, req.Bonus)
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	switch {
+	case errors.Is(err, review.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, review.ErrInvalidEdit), errors.Is(err, review.ErrAlreadyDecided):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		logger.Error("review: cannot update proposal", map[string]interface{}{"id": id, "error": err.Error()})
+		http.Error(w, "Coda non disponibile", http.StatusInternalServerError)
+	default:
+		logger.Info("review: proposal updated", map[string]interface{}{"id": id, "action": action, "stato": p.Stato})
+		writeReviewJSON(w, p)
+	}
+}
+
+func writeReviewJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,201 @@
+// Package history records every change of the served bonus catalogue as a
+// revision (field, old value, new value, source, scrape cycle, time), so that
+// what a bonus page said on a given day can be reconstructed.
+package history
+
+import (
+	"bonusperme/internal/models"
+	"bonusperme/internal/storage"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Revisione is one change of one field of a bonus.
+type Revisione struct {
+	BonusID string    `json:"bonus_id"`
+	Campo   string    `json:"campo"`
+	Prima   string    `json:"prima"`
+	Dopo    string    `json:"dopo"`
+	Fonte   string    `json:"fonte"`
+	Ciclo   int       `json:"ciclo"`
+	Data    time.Time `json:"data"`
+}
+
+// CampoBonus is the pseudo-field of revisions that add or remove a whole bonus;
+// Prima and Dopo hold the bonus name.
+const CampoBonus = "bonus"
+
+// FonteCatalogo is the source of changes coming from the catalogue files.
+const FonteCatalogo = "catalogo"
+
+// maxRevisioni bounds the history kept for a single bonus.
+const maxRevisioni = 500
+
+// campi lists the tracked fields with their JSON names.
+var campi = []struct {
+	nome   string
+	valore func(*models.Bonus) string
+}{
+	{"nome", func(b *models.Bonus) string { return b.Nome }},
+	{"importo", func(b *models.Bonus) string { return b.Importo }},
+	{"scadenza", func(b *models.Bonus) string { return b.Scadenza }},
+	{"stato", func(b *models.Bonus) string { return b.Stato }},
+	{"descrizione", func(b *models.Bonus) string { return b.Descrizione }},
+	{"link_ufficiale", func(b *models.Bonus) string { return b.LinkUfficiale }},
+	{"fonte_url", func(b *models.Bonus) string { return b.FonteURL }},
+}
+
+// Compare returns the revisions that turn prima into dopo. fonte tells which
+// source set a field of dopo; it may be nil, and an empty result means the
+// catalogue.
+func Compare(prima, dopo []models.Bonus, fonte func(bonusID, campo string) string, ciclo int, t time.Time) []Revisione {
+	origine := func(id, campo string) string {
+		if fonte != nil {
+			if f := fonte(id, campo); f != "" {
+				return f
+			}
+		}
+		return FonteCatalogo
+	}
+
+	vecchi := make(map[string]*models.Bonus, len(prima))
+	for i := range prima {
+		vecchi[prima[i].ID] = &prima[i]
+	}
+	var out []Revisione
+	visti := make(map[string]bool, len(dopo))
+	for i := range dopo {
+		b := &dopo[i]
+		visti[b.ID] = true
+		old, ok := vecchi[b.ID]
+		if !ok {
+			out = append(out, Revisione{BonusID: b.ID, Campo: CampoBonus, Dopo: b.Nome,
+				Fonte: origine(b.ID, CampoBonus), Ciclo: ciclo, Data: t})
+			continue
+		}
+		for _, c := range campi {
+			if v0, v1 := c.valore(old), c.valore(b); v0 != v1 {
+				out = append(out, Revisione{BonusID: b.ID, Campo: c.nome, Prima: v0, Dopo: v1,
+					Fonte: origine(b.ID, c.nome), Ciclo: ciclo, Data: t})
+			}
+		}
+	}
+	for i := range prima {
+		if b := &prima[i]; !visti[b.ID] {
+			out = append(out, Revisione{BonusID: b.ID, Campo: CampoBonus, Prima: b.Nome,
+				Fonte: FonteCatalogo, Ciclo: ciclo, Data: t})
+		}
+	}
+	return out
+}
+
+var mu sync.Mutex
+
+// Record appends revisions to the history of their bonus.
+func Record(revs []Revisione) error {
+	perBonus := make(map[string][]Revisione)
+	for _, r := range revs {
+		perBonus[r.BonusID] = append(perBonus[r.BonusID], r)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for id, nuove := range perBonus {
+		var storia []Revisione
+		if _, err := storage.Get(storage.BucketHistory, id, &storia); err != nil {
+			return err
+		}
+		storia = append(storia, nuove...)
+		if len(storia) > maxRevisioni {
+			storia = storia[len(storia)-maxRevisioni:]
+		}
+		if err := storage.Put(storage.BucketHistory, id, storia); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Revisions returns the revisions of a bonus, oldest first.
+func Revisions(bonusID string) ([]Revisione, error) {
+	var storia []Revisione
+	_, err := storage.Get(storage.BucketHistory, bonusID, &storia)
+	return storia, err
+}
+
+// Filter selects revisions by time or by scrape cycle.
+type Filter func(Revisione) bool
+
+// Between keeps revisions made in [da, a).
+func Between(da, a time.Time) Filter {
+	return func(r Revisione) bool { return !r.Data.Before(da) && r.Data.Before(a) }
+}
+
+// BetweenCycles keeps revisions made after cycle da, up to and including cycle a:
+// the changes between the catalogue served after da and the one served after a.
+func BetweenCycles(da, a int) Filter {
+	return func(r Revisione) bool { return r.Ciclo > da && r.Ciclo <= a }
+}
+
+// Search returns the revisions of all bonuses accepted by f, in time order.
+func Search(f Filter) ([]Revisione, error) {
+	var out []Revisione
+	err := storage.ForEach(storage.BucketHistory, func(_ string, data []byte) error {
+		var storia []Revisione
+		if err := json.Unmarshal(data, &storia); err != nil {
+			return err
+		}
+		for _, r := range storia {
+			if f(r) {
+				out = append(out, r)
+			}
+		}
+		return nil
+	})
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Data.Before(out[j].Data) })
+	return out, err
+}
+
+// Modifica is the net change of a field over a series of revisions.
+type Modifica struct {
+	BonusID   string    `json:"bonus_id"`
+	Campo     string    `json:"campo"`
+	Prima     string    `json:"prima"`
+	Dopo      string    `json:"dopo"`
+	Fonte     string    `json:"fonte"` // source of the last revision
+	Revisioni int       `json:"revisioni"`
+	Data      time.Time `json:"data"` // time of the last revision
+}
+
+// Diff collapses time-ordered revisions into the net change of every field.
+// Fields that went back to their original value are omitted.
+func Diff(revs []Revisione) []Modifica {
+	idx := make(map[[2]string]int)
+	var out []Modifica
+	for _, r := range revs {
+		k := [2]string{r.BonusID, r.Campo}
+		i, ok := idx[k]
+		if !ok {
+			i = len(out)
+			idx[k] = i
+			out = append(out, Modifica{BonusID: r.BonusID, Campo: r.Campo, Prima: r.Prima})
+		}
+		out[i].Dopo, out[i].Fonte, out[i].Data = r.Dopo, r.Fonte, r.Data
+		out[i].Revisioni++
+	}
+	net := out[:0]
+	for _, m := range out {
+		if m.Prima != m.Dopo {
+			net = append(net, m)
+		}
+	}
+	sort.SliceStable(net, func(i, j int) bool {
+		if net[i].BonusID != net[j].BonusID {
+			return net[i].BonusID < net[j].BonusID
+		}
+		return net[i].Campo < net[j].Campo
+	})
+	return net
+}
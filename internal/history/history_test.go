@@ -0,0 +1,77 @@
+package history
+
+import (
+	"bonusperme/internal/models"
+	"bonusperme/internal/storage"
+	"testing"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	prima := []models.Bonus{
+		{ID: "a", Nome: "Bonus A", Scadenza: "31 dicembre 2026", Importo: "500€"},
+		{ID: "b", Nome: "Bonus B"},
+	}
+	dopo := []models.Bonus{
+		{ID: "a", Nome: "Bonus A", Scadenza: "30 giugno 2026", Importo: "500€"},
+		{ID: "c", Nome: "Bonus C"},
+	}
+	fonte := func(id, campo string) string {
+		if id == "a" && campo == "scadenza" {
+			return "INPS"
+		}
+		return ""
+	}
+	revs := Compare(prima, dopo, fonte, 3, time.Now())
+	if len(revs) != 3 {
+		t.Fatalf("revisioni = %+v, attese 3", revs)
+	}
+	if r := revs[0]; r.BonusID != "a" || r.Campo != "scadenza" || r.Prima != "31 dicembre 2026" ||
+		r.Dopo != "30 giugno 2026" || r.Fonte != "INPS" || r.Ciclo != 3 {
+		t.Errorf("modifica scadenza = %+v", r)
+	}
+	if r := revs[1]; r.BonusID != "c" || r.Campo != CampoBonus || r.Dopo != "Bonus C" || r.Fonte != FonteCatalogo {
+		t.Errorf("bonus aggiunto = %+v", r)
+	}
+	if r := revs[2]; r.BonusID != "b" || r.Campo != CampoBonus || r.Prima != "Bonus B" || r.Dopo != "" {
+		t.Errorf("bonus rimosso = %+v", r)
+	}
+}
+
+func TestSearch_Diff(t *testing.T) {
+	storage.Use(storage.NewMemory())
+	defer storage.Use(storage.NewMemory())
+
+	giorno := func(d int) time.Time { return time.Date(2026, time.March, d, 12, 0, 0, 0, time.UTC) }
+	Record([]Revisione{
+		{BonusID: "a", Campo: "scadenza", Prima: "31/12", Dopo: "30/06", Fonte: "INPS", Ciclo: 1, Data: giorno(1)},
+		{BonusID: "b", Campo: "importo", Prima: "100€", Dopo: "200€", Ciclo: 1, Data: giorno(1)},
+	})
+	Record([]Revisione{
+		{BonusID: "a", Campo: "scadenza", Prima: "30/06", Dopo: "31/07", Fonte: "MIMIT", Ciclo: 2, Data: giorno(8)},
+		{BonusID: "b", Campo: "importo", Prima: "200€", Dopo: "100€", Ciclo: 2, Data: giorno(8)},
+	})
+
+	storia, err := Revisions("a")
+	if err != nil || len(storia) != 2 || storia[1].Dopo != "31/07" {
+		t.Fatalf("Revisions(a) = %+v, %v", storia, err)
+	}
+
+	revs, _ := Search(BetweenCycles(0, 2))
+	diff := Diff(revs)
+	// L'importo di b è tornato al valore iniziale: nessuna modifica netta
+	if len(diff) != 1 {
+		t.Fatalf("diff = %+v, attesa una sola modifica", diff)
+	}
+	if m := diff[0]; m.BonusID != "a" || m.Prima != "31/12" || m.Dopo != "31/07" || m.Fonte != "MIMIT" || m.Revisioni != 2 {
+		t.Errorf("modifica netta = %+v", m)
+	}
+
+	revs, _ = Search(Between(giorno(5), giorno(10)))
+	if diff := Diff(revs); len(diff) != 2 || diff[0].Prima != "30/06" {
+		t.Errorf("diff tra date = %+v", diff)
+	}
+	if revs, _ := Search(BetweenCycles(2, 2)); len(revs) != 0 {
+		t.Errorf("BetweenCycles(2, 2) = %+v, atteso vuoto", revs)
+	}
+}
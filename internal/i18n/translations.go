@@ -82,6 +82,7 @@ var T = map[string]map[string]string{
 		"results.link_ufficiale":   "Sito ufficiale",
 		"results.details":          "Vedi come richiederlo",
 		"results.calendar":         "Scarica scadenze",
+		"results.calendar_feed":    "Abbonati alle scadenze",
 		"results.pdf":              "Scarica PDF",
 		"results.print":            "Stampa per il CAF",
 		"results.expand":           "Espandi tutto",
@@ -235,6 +236,7 @@ var T = map[string]map[string]string{
 		"results.link_ufficiale":   "Official website",
 		"results.details":          "See how to claim it",
 		"results.calendar":         "Download deadlines",
+		"results.calendar_feed":    "Subscribe to deadlines",
 		"results.pdf":              "Download PDF",
 		"results.print":            "Print for CAF",
 		"results.expand":           "Expand all",
@@ -388,6 +390,7 @@ var T = map[string]map[string]string{
 		"results.link_ufficiale":   "Site officiel",
 		"results.details":          "Voir comment le demander",
 		"results.calendar":         "Télécharger les échéances",
+		"results.calendar_feed":    "S'abonner aux échéances",
 		"results.pdf":              "Télécharger PDF",
 		"results.print":            "Imprimer pour le CAF",
 		"results.expand":           "Tout développer",
@@ -541,6 +544,7 @@ var T = map[string]map[string]string{
 		"results.link_ufficiale":   "Sitio oficial",
 		"results.details":          "Ver cómo solicitarlo",
 		"results.calendar":         "Descargar plazos",
+		"results.calendar_feed":    "Suscribirse a los plazos",
 		"results.pdf":              "Descargar PDF",
 		"results.print":            "Imprimir para el CAF",
 		"results.expand":           "Expandir todo",
@@ -694,6 +698,7 @@ var T = map[string]map[string]string{
 		"results.link_ufficiale":   "Site oficial",
 		"results.details":          "Vezi cum să îl soliciți",
 		"results.calendar":         "Descarcă termenele limită",
+		"results.calendar_feed":    "Abonează-te la termene",
 		"results.pdf":              "Descarcă PDF",
 		"results.print":            "Tipărește pentru CAF",
 		"results.expand":           "Extinde tot",
@@ -847,6 +852,7 @@ var T = map[string]map[string]string{
 		"results.link_ufficiale":   "الموقع الرسمي",
 		"results.details":          "شاهد كيفية طلبه",
 		"results.calendar":         "حمّل المواعيد النهائية",
+		"results.calendar_feed":    "اشترك في المواعيد النهائية",
 		"results.pdf":              "حمّل PDF",
 		"results.print":            "اطبع لتقديمه في CAF",
 		"results.expand":           "وسّع الكل",
@@ -1000,6 +1006,7 @@ var T = map[string]map[string]string{
 		"results.link_ufficiale":   "Faqja zyrtare",
 		"results.details":          "Shiko si ta kërkosh",
 		"results.calendar":         "Shkarko afatet",
+		"results.calendar_feed":    "Abonohu te afatet",
 		"results.pdf":              "Shkarko PDF",
 		"results.print":            "Printo për CAF",
 		"results.expand":           "Zgjero të gjitha",
@@ -0,0 +1,148 @@
+// Package ical writes iCalendar (RFC 5545) calendars of all-day events, the
+// deadlines of the bonuses. Text is escaped and content lines are folded at
+// 75 octets, so every client reads the same events.
+package ical
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Calendar is a VCALENDAR. Name and Refresh are the hints subscribed
+// calendars use for their title and polling interval; both are optional.
+type Calendar struct {
+	Name    string
+	Refresh time.Duration
+	Events  []Event
+}
+
+// Event is an all-day VEVENT on Day. UID must stay the same across
+// regenerations of the calendar, and Sequence must grow whenever the event
+// changes, so clients update the event instead of adding a new one.
+type Event struct {
+	UID         string
+	Sequence    int
+	Day         time.Time
+	Summary     string
+	Description string
+	URL         string
+	// Yearly repeats the event every year.
+	Yearly bool
+	// Alarms are reminders, in days before the event.
+	Alarms []Alarm
+}
+
+// Alarm is a VALARM shown Days before the event.
+type Alarm struct {
+	Days        int
+	Description string
+}
+
+// WriteTo writes the calendar, stamped at now.
+func (c Calendar) WriteTo(w io.Writer, now time.Time) error {
+	lw := &lineWriter{w: w}
+	stamp := now.UTC().Format("20060102T150405Z")
+
+	lw.line("BEGIN:VCALENDAR")
+	lw.line("VERSION:2.0")
+	lw.line("PRODID:-//BonusPerMe//IT")
+	lw.line("CALSCALE:GREGORIAN")
+	lw.line("METHOD:PUBLISH")
+	if c.Name != "" {
+		lw.line("X-WR-CALNAME:" + Escape(c.Name))
+	}
+	if c.Refresh > 0 {
+		d := duration(c.Refresh)
+		lw.line("REFRESH-INTERVAL;VALUE=DURATION:" + d)
+		lw.line("X-PUBLISHED-TTL:" + d)
+	}
+	for _, e := range c.Events {
+		lw.line("BEGIN:VEVENT")
+		lw.line("UID:" + e.UID)
+		lw.line("DTSTAMP:" + stamp)
+		lw.line(fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+		lw.line("DTSTART;VALUE=DATE:" + e.Day.Format("20060102"))
+		lw.line("DTEND;VALUE=DATE:" + e.Day.AddDate(0, 0, 1).Format("20060102"))
+		if e.Yearly {
+			lw.line("RRULE:FREQ=YEARLY")
+		}
+		lw.line("SUMMARY:" + Escape(e.Summary))
+		if e.Description != "" {
+			lw.line("DESCRIPTION:" + Escape(e.Description))
+		}
+		if e.URL != "" {
+			lw.line("URL:" + e.URL)
+		}
+		lw.line("TRANSP:TRANSPARENT")
+		for _, a := range e.Alarms {
+			lw.line("BEGIN:VALARM")
+			lw.line(fmt.Sprintf("TRIGGER:-P%dD", a.Days))
+			lw.line("ACTION:DISPLAY")
+			lw.line("DESCRIPTION:" + Escape(a.Description))
+			lw.line("END:VALARM")
+		}
+		lw.line("END:VEVENT")
+	}
+	lw.line("END:VCALENDAR")
+	return lw.err
+}
+
+// Escape escapes a TEXT value (RFC 5545, 3.3.11).
+func Escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
+
+// Fold splits a content line into lines of at most 75 octets, the next ones
+// starting with a space (RFC 5545, 3.1). UTF-8 sequences are never split.
+func Fold(line string) string {
+	const max = 75
+	if len(line) <= max {
+		return line
+	}
+	var sb strings.Builder
+	limit := max
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		sb.WriteString(line[:cut])
+		sb.WriteString("\r\n ")
+		line = line[cut:]
+		limit = max - 1 // the leading space counts
+	}
+	sb.WriteString(line)
+	return sb.String()
+}
+
+// duration formats d as an RFC 5545 DURATION in whole hours or minutes.
+func duration(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("PT%dH", int(d/time.Hour))
+	}
+	return fmt.Sprintf("PT%dM", int(d/time.Minute))
+}
+
+// lineWriter writes folded content lines ending in CRLF, keeping the first
+// error.
+type lineWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (lw *lineWriter) line(s string) {
+	if lw.err != nil {
+		return
+	}
+	_, lw.err = io.WriteString(lw.w, Fold(s)+"\r\n")
+}
@@ -0,0 +1,66 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscape(t *testing.T) {
+	if got := Escape("Bonus casa; mobili, elettrodomestici\\TV\nseconda riga"); got != `Bonus casa\; mobili\, elettrodomestici\\TV\nseconda riga` {
+		t.Errorf("Escape = %q", got)
+	}
+}
+
+func TestFold(t *testing.T) {
+	line := "DESCRIPTION:" + strings.Repeat("Ricorda di presentare domanda per l'assegno unico è ", 4)
+	folded := Fold(line)
+	for i, l := range strings.Split(folded, "\r\n") {
+		if len(l) > 75 {
+			t.Errorf("riga %d di %d ottetti", i, len(l))
+		}
+		if i > 0 && !strings.HasPrefix(l, " ") {
+			t.Errorf("riga %d senza spazio iniziale", i)
+		}
+		if !utf8.ValidString(l) {
+			t.Errorf("riga %d spezza un carattere UTF-8", i)
+		}
+	}
+	if strings.ReplaceAll(folded, "\r\n ", "") != line {
+		t.Error("il testo ricomposto è diverso dall'originale")
+	}
+	if Fold("SUMMARY:corto") != "SUMMARY:corto" {
+		t.Error("una riga corta non va spezzata")
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	cal := Calendar{Name: "Scadenze", Refresh: 12 * time.Hour, Events: []Event{{
+		UID:      "assegno-unico-chiusura@bonusperme.it",
+		Sequence: 2,
+		Day:      time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC),
+		Summary:  "Scadenza: Assegno Unico, arretrati",
+		Yearly:   true,
+		Alarms:   []Alarm{{Days: 7, Description: "Tra 7 giorni"}},
+	}}}
+	var buf bytes.Buffer
+	if err := cal.WriteTo(&buf, time.Date(2026, time.January, 1, 9, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"REFRESH-INTERVAL;VALUE=DURATION:PT12H\r\n",
+		"UID:assegno-unico-chiusura@bonusperme.it\r\nDTSTAMP:20260101T090000Z\r\nSEQUENCE:2\r\n",
+		"DTSTART;VALUE=DATE:20260228\r\nDTEND;VALUE=DATE:20260301\r\nRRULE:FREQ=YEARLY\r\n",
+		"SUMMARY:Scadenza: Assegno Unico\\, arretrati\r\n",
+		"TRIGGER:-P7D\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("manca %q in:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package istat
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Column headers of the ISTAT "Elenco dei comuni italiani" export, matched by
+// prefix because ISTAT appends footnote markers to some of them.
+const (
+	colCodice    = "Codice Comune formato alfanumerico"
+	colNome      = "Denominazione (Italiana e straniera)"
+	colProvincia = "Codice Provincia (Storico)"
+	colRegione   = "Denominazione Regione"
+)
+
+// comuniTotali is the lower bound of the comuni in the ISTAT export; a
+// smaller table is the capoluoghi subset.
+const comuniTotali = 7800
+
+// ParseList reads the comuni from the ISTAT "Elenco dei comuni italiani"
+// CSV (Elenco-comuni-italiani.csv). The export is Latin-1 encoded; UTF-8
+// input is accepted too.
+func ParseList(r io.Reader) ([]Comune, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(raw) {
+		raw = latin1(raw)
+	}
+	cr := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(raw), "\ufeff")))
+	cr.Comma = ';'
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("istat: intestazione: %w", err)
+	}
+	idx := map[string]int{}
+	for _, col := range []string{colCodice, colNome, colProvincia, colRegione} {
+		idx[col] = -1
+		for i, h := range header {
+			if strings.HasPrefix(strings.TrimSpace(h), col) {
+				idx[col] = i
+				break
+			}
+		}
+		if idx[col] < 0 {
+			return nil, fmt.Errorf("istat: colonna %q mancante", col)
+		}
+	}
+
+	var out []Comune
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("istat: %w", err)
+		}
+		campo := func(col string) string {
+			if i := idx[col]; i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		c := Comune{Codice: campo(colCodice), Nome: campo(colNome), Provincia: campo(colProvincia), Regione: campo(colRegione)}
+		if c.Codice == "" {
+			continue
+		}
+		if len(c.Codice) != 6 || len(c.Provincia) != 3 || c.Nome == "" {
+			return nil, fmt.Errorf("istat: riga non valida %q", rec)
+		}
+		out = append(out, c)
+	}
+}
+
+// latin1 converts ISO 8859-1 text to UTF-8.
+func latin1(b []byte) []byte {
+	out := make([]byte, 0, len(b)+len(b)/8)
+	for _, c := range b {
+		out = utf8.AppendRune(out, rune(c))
+	}
+	return out
+}
+
+// Complete reports whether the bundled table lists every comune, not only
+// the capoluoghi. Unknown comuni can be rejected only with the full table.
+func Complete() bool {
+	loadOnce.Do(load)
+	return len(comuni) >= comuniTotali
+}
@@ -0,0 +1,94 @@
+//go:build ignore
+
+// genera rewrites data/comuni.csv from the ISTAT "Elenco dei comuni italiani"
+// export, keeping the extra names (aliases) of the current table:
+//
+//	curl -O https://www.istat.it/storage/codici-unita-amministrative/Elenco-comuni-italiani.csv
+//	go generate ./internal/istat
+package main
+
+import (
+	"bonusperme/internal/istat"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		log.Fatal("uso: go run genera.go Elenco-comuni-italiani.csv")
+	}
+	f, err := os.Open(os.Args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	elenco, err := istat.ParseList(f)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	province := codiciProvince()
+	alias := aliasAttuali()
+	rows := [][]string{{"codice", "denominazione", "provincia"}}
+	sort.Slice(elenco, func(i, j int) bool { return elenco[i].Codice < elenco[j].Codice })
+	for _, c := range elenco {
+		if !province[c.Provincia] {
+			log.Fatalf("comune %s %s: provincia %s assente da data/province.csv", c.Codice, c.Nome, c.Provincia)
+		}
+		nomi := append([]string{c.Nome}, alias[c.Codice]...)
+		rows = append(rows, []string{c.Codice, strings.Join(nomi, "|"), c.Provincia})
+	}
+
+	out, err := os.Create("data/comuni.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	w := csv.NewWriter(out)
+	w.Comma = ';'
+	if err := w.WriteAll(rows); err != nil {
+		log.Fatal(err)
+	}
+	if err := out.Close(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("data/comuni.csv: %d comuni\n", len(elenco))
+}
+
+func leggi(name string) [][]string {
+	f, err := os.Open(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.Comma = ';'
+	rows, err := r.ReadAll()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return rows[1:]
+}
+
+func codiciProvince() map[string]bool {
+	out := make(map[string]bool)
+	for _, r := range leggi("data/province.csv") {
+		out[r[0]] = true
+	}
+	return out
+}
+
+// aliasAttuali returns the names after the first of each comune in the
+// current table, e.g. "Reggio Emilia" for Reggio nell'Emilia.
+func aliasAttuali() map[string][]string {
+	out := make(map[string][]string)
+	for _, r := range leggi("data/comuni.csv") {
+		if nomi := strings.Split(r[1], "|"); len(nomi) > 1 {
+			out[r[0]] = nomi[1:]
+		}
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+// Package istat resolves Italian provinces and municipalities (comuni) to their
+// ISTAT codes using a table embedded in the binary.
+//
+// data/province.csv lists every province. data/comuni.csv is generated from
+// the ISTAT "Elenco dei comuni italiani" export by genera.go, which keeps the
+// extra names of the current rows; until it is regenerated the table may hold
+// only the chief towns (capoluoghi), see Complete.
+package istat
+
+import (
+	"embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//go:generate go run genera.go Elenco-comuni-italiani.csv
+
+//go:embed data/province.csv data/comuni.csv
+var data embed.FS
+
+// Provincia is a province (or equivalent territorial unit) with its ISTAT code.
+type Provincia struct {
+	Codice  string `json:"codice"` // 3 digits, e.g. "058"
+	Nome    string `json:"nome"`
+	Sigla   string `json:"sigla"`
+	Regione string `json:"regione"`
+}
+
+// Comune is a municipality with its ISTAT code.
+type Comune struct {
+	Codice    string `json:"codice"` // 6 digits, e.g. "058091"
+	Nome      string `json:"nome"`
+	Provincia string `json:"provincia"` // ISTAT code of the province
+	Regione   string `json:"regione"`
+}
+
+var (
+	loadOnce      sync.Once
+	province      map[string]Provincia
+	provinceIndex map[string]string // normalised name or sigla -> code
+	comuni        map[string]Comune
+	comuniIndex   map[string]string // normalised name -> code
+)
+
+func load() {
+	province = make(map[string]Provincia)
+	provinceIndex = make(map[string]string)
+	comuni = make(map[string]Comune)
+	comuniIndex = make(map[string]string)
+
+	// The embedded tables are part of the binary: a malformed row is a build error.
+	for _, r := range readCSV("data/province.csv", 4) {
+		nomi := strings.Split(r[1], "|")
+		p := Provincia{Codice: r[0], Nome: nomi[0], Sigla: r[2], Regione: r[3]}
+		province[p.Codice] = p
+		provinceIndex[Normalize(p.Sigla)] = p.Codice
+		for _, n := range nomi {
+			indexNames(provinceIndex, n, p.Codice)
+		}
+	}
+	for _, r := range readCSV("data/comuni.csv", 3) {
+		p, ok := province[r[2]]
+		if !ok {
+			panic(fmt.Sprintf("istat: comune %s con provincia sconosciuta %s", r[0], r[2]))
+		}
+		nomi := strings.Split(r[1], "|")
+		aggiungiComune(Comune{Codice: r[0], Nome: nomi[0], Provincia: p.Codice, Regione: p.Regione}, nomi)
+	}
+}
+
+// aggiungiComune adds c to the table, indexed under each of its names.
+func aggiungiComune(c Comune, nomi []string) {
+	comuni[c.Codice] = c
+	for _, n := range nomi {
+		indexNames(comuniIndex, n, c.Codice)
+	}
+}
+
+// indexNames indexes a name and, for bilingual names such as "Bolzano/Bozen",
+// each of its parts.
+func indexNames(index map[string]string, nome, codice string) {
+	index[Normalize(nome)] = codice
+	if strings.Contains(nome, "/") {
+		for _, part := range strings.Split(nome, "/") {
+			index[Normalize(part)] = codice
+		}
+	}
+}
+
+func readCSV(name string, fields int) [][]string {
+	f, err := data.Open(name)
+	if err != nil {
+		panic("istat: " + err.Error())
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.Comma = ';'
+	r.FieldsPerRecord = fields
+	var rows [][]string
+	for first := true; ; first = false {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return rows
+		}
+		if err != nil {
+			panic(fmt.Sprintf("istat: %s: %v", name, err))
+		}
+		if !first {
+			rows = append(rows, rec)
+		}
+	}
+}
+
+var accenti = strings.NewReplacer(
+	"à", "a", "á", "a", "è", "e", "é", "e", "ì", "i", "í", "i",
+	"ò", "o", "ó", "o", "ù", "u", "ú", "u", "â", "a", "ê", "e",
+)
+
+// Normalize reduces a place name to a comparison key: lower case, without
+// accents, spaces and punctuation ("L'Aquila" -> "laquila").
+func Normalize(s string) string {
+	s = accenti.Replace(strings.ToLower(strings.TrimSpace(s)))
+	var sb strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// FindComune resolves a municipality from its ISTAT code or its name.
+func FindComune(s string) (Comune, bool) {
+	loadOnce.Do(load)
+	if c, ok := comuni[strings.TrimSpace(s)]; ok {
+		return c, true
+	}
+	c, ok := comuni[comuniIndex[Normalize(s)]]
+	return c, ok
+}
+
+// FindProvincia resolves a province from its ISTAT code, its sigla or its name.
+func FindProvincia(s string) (Provincia, bool) {
+	loadOnce.Do(load)
+	if p, ok := province[strings.TrimSpace(s)]; ok {
+		return p, true
+	}
+	p, ok := province[provinceIndex[Normalize(s)]]
+	return p, ok
+}
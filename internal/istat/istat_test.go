@@ -0,0 +1,84 @@
+package istat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindComune(t *testing.T) {
+	cases := map[string]string{
+		"058091":        "058091",
+		"Roma":          "058091",
+		"  milano ":     "015146",
+		"L'Aquila":      "066049",
+		"laquila":       "066049",
+		"Bozen":         "021008",
+		"Reggio Emilia": "035033",
+		"Forli":         "040012",
+	}
+	for in, want := range cases {
+		c, ok := FindComune(in)
+		if !ok || c.Codice != want {
+			t.Errorf("FindComune(%q) = %+v, %v; atteso %s", in, c, ok, want)
+		}
+	}
+	if c, _ := FindComune("Trento"); c.Regione != "Trentino-Alto Adige" || c.Provincia != "022" {
+		t.Errorf("regione o provincia di Trento errate: %+v", c)
+	}
+	if _, ok := FindComune("Atlantide"); ok {
+		t.Error("un comune inesistente non deve essere trovato")
+	}
+	if _, ok := FindComune(""); ok {
+		t.Error("un nome vuoto non deve essere trovato")
+	}
+}
+
+func TestFindProvincia(t *testing.T) {
+	for _, in := range []string{"021", "BZ", "bz", "Bolzano", "Bozen"} {
+		if p, ok := FindProvincia(in); !ok || p.Codice != "021" {
+			t.Errorf("FindProvincia(%q) = %+v, %v", in, p, ok)
+		}
+	}
+}
+
+func TestTabelle_Coerenti(t *testing.T) {
+	loadOnce.Do(load)
+	if len(province) < 107 {
+		t.Errorf("attese almeno 107 province, trovate %d", len(province))
+	}
+	for code, c := range comuni {
+		if len(code) != 6 || code[:3] != c.Provincia {
+			t.Errorf("codice comune %s non coerente con la provincia %s", code, c.Provincia)
+		}
+	}
+}
+
+func TestParseList(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "elenco_comuni.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	elenco, err := ParseList(f)
+	if err != nil {
+		t.Fatalf("LeggiElenco: %v", err)
+	}
+	if len(elenco) != 4 {
+		t.Fatalf("attesi 4 comuni, letti %d", len(elenco))
+	}
+	if c := elenco[1]; c.Codice != "021051" || c.Nome != "Merano/Meran" || c.Provincia != "021" {
+		t.Errorf("riga Latin-1 letta male: %+v", c)
+	}
+
+	// Comuni that are not capoluoghi resolve once the table lists them
+	loadOnce.Do(load)
+	for _, c := range elenco {
+		aggiungiComune(c, []string{c.Nome})
+	}
+	for in, want := range map[string]string{"Agliè": "001001", "aglie": "001001", "Meran": "021051", "Fiumicino": "058120"} {
+		if c, ok := FindComune(in); !ok || c.Codice != want {
+			t.Errorf("FindComune(%q) = %+v, %v; atteso %s", in, c, ok, want)
+		}
+	}
+}
@@ -1,12 +1,12 @@
 package linkcheck
 
 import (
-	"bonusperme/internal/config"
+	"bonusperme/internal/crawler"
 	"bonusperme/internal/logger"
 	"bonusperme/internal/models"
 	sentryutil "bonusperme/internal/sentry"
+	"errors"
 	"fmt"
-	"net/http"
 	"sync"
 	"time"
 )
@@ -32,32 +32,14 @@ func ApplyStatus(bonuses []models.Bonus) {
 	}
 }
 
-var client = &http.Client{
-	Timeout: 10 * time.Second,
-	CheckRedirect: func(req *http.Request, via []*http.Request) error {
-		if len(via) >= 3 {
-			return http.ErrUseLastResponse
-		}
-		return nil
-	},
-}
-
 // CheckLink verifies if a URL responds with a 2xx/3xx status using HEAD.
-func CheckLink(url string) (ok bool, statusCode int) {
-	req, err := http.NewRequest("HEAD", url, nil)
-	if err != nil {
-		return false, 0
-	}
-	req.Header.Set("User-Agent", config.Cfg.UserAgent)
-	req.Header.Set("Accept-Language", "it-IT,it;q=0.9")
-
-	resp, err := client.Do(req)
+// err is crawler.ErrDisallowed when robots.txt excludes the URL.
+func CheckLink(url string) (ok bool, statusCode int, err error) {
+	statusCode, err = crawler.Head(url)
 	if err != nil {
-		return false, 0
+		return false, 0, err
 	}
-	defer resp.Body.Close()
-
-	return resp.StatusCode >= 200 && resp.StatusCode < 400, resp.StatusCode
+	return statusCode >= 200 && statusCode < 400, statusCode, nil
 }
 
 // CheckAllLinks checks all bonus links and updates verification fields.
@@ -80,7 +62,14 @@ func CheckAllLinks(bonusList []*models.Bonus) int {
 			sem <- struct{}{}
 			defer func() { <-sem }()
 
-			ok, status := CheckLink(bonus.LinkUfficiale)
+			ok, status, err := CheckLink(bonus.LinkUfficiale)
+			if errors.Is(err, crawler.ErrDisallowed) {
+				// Not ours to check: keep the previous verdict
+				logger.Info("linkcheck: skipped, disallowed by robots.txt", map[string]interface{}{
+					"bonus_id": bonus.ID, "url": bonus.LinkUfficiale,
+				})
+				return
+			}
 			bonus.LinkVerificatoAl = today
 
 			if ok {
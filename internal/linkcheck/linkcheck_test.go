@@ -0,0 +1,32 @@
+package linkcheck
+
+import (
+	"bonusperme/internal/crawler"
+	"bonusperme/internal/models"
+	"bonusperme/internal/replay"
+	"testing"
+)
+
+func TestCheckAllLinksReplay(t *testing.T) {
+	crawler.Use(replay.NewPlayer("../../testdata/replay"))
+	defer crawler.Use(nil)
+
+	bonuses := []*models.Bonus{
+		{ID: "bonus-nido", LinkUfficiale: "https://www.inps.it/it/it/dettaglio-scheda.bonus-asilo-nido.html"},
+		{ID: "bonus-rimosso", LinkUfficiale: "https://www.inps.it/it/it/pagina-rimossa.html"},
+		{ID: "senza-link"},
+	}
+	if broken := CheckAllLinks(bonuses); broken != 1 {
+		t.Errorf("link rotti = %d, atteso 1", broken)
+	}
+	if !bonuses[0].LinkVerificato || bonuses[1].LinkVerificato {
+		t.Errorf("verifica: nido = %v, rimosso = %v", bonuses[0].LinkVerificato, bonuses[1].LinkVerificato)
+	}
+
+	// Lo stato resta in cache per le risposte successive
+	list := []models.Bonus{{ID: "bonus-nido"}, {ID: "bonus-rimosso"}}
+	ApplyStatus(list)
+	if !list[0].LinkVerificato || list[1].LinkVerificato || list[0].LinkVerificatoAl == "" {
+		t.Errorf("ApplyStatus: %+v", list)
+	}
+}
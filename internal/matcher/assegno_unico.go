@@ -0,0 +1,155 @@
+package matcher
+
+import (
+	"bonusperme/internal/models"
+	"fmt"
+	"math"
+)
+
+// Parametri dell'Assegno Unico Universale (D.Lgs. 230/2021), importi mensili
+// rivalutati per il 2025 (Circolare INPS n. 33/2025). Tra le due soglie ISEE
+// gli importi decrescono linearmente, come nelle tabelle INPS.
+type parametriAU struct {
+	IseeMin, IseeMax float64
+
+	// Quota base per figlio minorenne e per figlio 18-20 anni
+	Minore, MinoreMin           float64
+	Maggiorenne, MaggiorenneMin float64
+
+	// Maggiorazione per ciascun figlio successivo al secondo
+	Terzo, TerzoMin float64
+
+	// Maggiorazioni per figlio minorenne con disabilità (non dipendono dall'ISEE)
+	DisNonAutosufficiente, DisGrave, DisMedia float64
+	// Maggiorazione per figlio con disabilità tra 18 e 20 anni
+	DisMaggiorenne float64
+
+	// Maggiorazione per madre con meno di 21 anni, per figlio
+	MadreUnder21 float64
+
+	// Maggiorazione per nucleo con entrambi i genitori lavoratori, per figlio minorenne
+	Lavoratori float64
+
+	// Forfait mensile per nuclei con almeno 4 figli
+	Numerosi float64
+
+	// Aumento della quota base per figli sotto 1 anno e, nei nuclei con
+	// almeno 3 figli e ISEE entro SogliaUnder3, per figli tra 1 e 3 anni
+	AumentoPiccoli float64
+	SogliaUnder3   float64
+}
+
+var parametriAU2025 = parametriAU{
+	IseeMin: 17227.33, IseeMax: 45939.56,
+
+	Minore: 201.00, MinoreMin: 57.50,
+	Maggiorenne: 97.70, MaggiorenneMin: 28.70,
+	Terzo: 97.70, TerzoMin: 17.20,
+
+	DisNonAutosufficiente: 120.60, DisGrave: 109.10, DisMedia: 97.70,
+	DisMaggiorenne: 91.90,
+
+	MadreUnder21: 23.00,
+	Lavoratori:   34.40,
+	Numerosi:     150.00,
+
+	AumentoPiccoli: 0.5,
+	SogliaUnder3:   45939.56,
+}
+
+// scalaISEE returns the amount between max and min for the given ISEE.
+// Without ISEE the minimum applies.
+func (pa parametriAU) scalaISEE(isee, max, min float64) float64 {
+	switch {
+	case isee <= 0 || isee >= pa.IseeMax:
+		return min
+	case isee <= pa.IseeMin:
+		return max
+	}
+	return max - (isee-pa.IseeMin)/(pa.IseeMax-pa.IseeMin)*(max-min)
+}
+
+// calcolaAssegnoUnico computes the monthly Assegno Unico line by line.
+func calcolaAssegnoUnico(p models.UserProfile) models.CalcoloImporto {
+	pa := parametriAU2025
+	isee := p.ISEE
+	var voci []models.VoceImporto
+	add := func(voce string, importo float64) {
+		if importo > 0 {
+			voci = append(voci, models.VoceImporto{Voce: voce, Importo: arrotonda2(importo)})
+		}
+	}
+
+	// Figli che danno diritto all'assegno: minorenni, 18-20 anni con requisiti,
+	// figli con disabilità senza limiti di età. Senza il dettaglio del nucleo si
+	// assume che i figli maggiorenni rispettino i requisiti (studio, lavoro, ricerca di lavoro).
+	membri, stimato := nucleo(p)
+	var aventi []membroNucleo
+	for _, f := range membri {
+		if f.Relazione != models.RelazioneFiglio {
+			continue
+		}
+		if f.eta < 18 || f.Disabilita != "" || (f.eta < 21 && (f.Studente || stimato)) {
+			aventi = append(aventi, f)
+		}
+	}
+	n := len(aventi)
+
+	quotaMinore := pa.scalaISEE(isee, pa.Minore, pa.MinoreMin)
+	quotaMaggiorenne := pa.scalaISEE(isee, pa.Maggiorenne, pa.MaggiorenneMin)
+	for i, f := range aventi {
+		nome := fmt.Sprintf("Figlio %d (%d anni)", i+1, f.eta)
+		switch {
+		case f.eta < 18:
+			add(nome+": quota base", quotaMinore)
+			if f.eta < 1 {
+				add(nome+": aumento 50% sotto 1 anno", quotaMinore*pa.AumentoPiccoli)
+			} else if f.eta < 3 && n >= 3 && isee > 0 && isee <= pa.SogliaUnder3 {
+				add(nome+": aumento 50% 1-3 anni (nucleo numeroso)", quotaMinore*pa.AumentoPiccoli)
+			}
+			switch f.Disabilita {
+			case models.DisabilitaNonAutosufficiente:
+				add(nome+": maggiorazione disabilità (non autosufficienza)", pa.DisNonAutosufficiente)
+			case models.DisabilitaGrave:
+				add(nome+": maggiorazione disabilità grave", pa.DisGrave)
+			case models.DisabilitaMedia:
+				add(nome+": maggiorazione disabilità media", pa.DisMedia)
+			}
+		case f.eta < 21:
+			add(nome+": quota base 18-20 anni", quotaMaggiorenne)
+			if f.Disabilita != "" {
+				add(nome+": maggiorazione disabilità", pa.DisMaggiorenne)
+			}
+		default:
+			// Figli con disabilità dai 21 anni: stessa quota dei figli 18-20 anni
+			add(nome+": quota figlio con disabilità", quotaMaggiorenne)
+		}
+		if i >= 2 {
+			add(nome+": maggiorazione dal terzo figlio", pa.scalaISEE(isee, pa.Terzo, pa.TerzoMin))
+		}
+		if p.MadreUnder21 {
+			add(nome+": maggiorazione madre under 21", pa.MadreUnder21)
+		}
+		if p.GenitoriOccupati && f.eta < 18 {
+			add(nome+": maggiorazione genitori entrambi lavoratori", pa.scalaISEE(isee, pa.Lavoratori, 0))
+		}
+	}
+	if n >= 4 {
+		add("Forfait nuclei con 4 o più figli", pa.Numerosi)
+	}
+
+	var mensile float64
+	for _, v := range voci {
+		mensile += v.Importo
+	}
+	mensile = arrotonda2(mensile)
+	return models.CalcoloImporto{
+		Mensile:   mensile,
+		Annuale:   arrotonda2(mensile * 12),
+		Dettaglio: voci,
+	}
+}
+
+func arrotonda2(x float64) float64 {
+	return math.Round(x*100) / 100
+}
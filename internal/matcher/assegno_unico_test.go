@@ -0,0 +1,50 @@
+package matcher
+
+import (
+	"bonusperme/internal/models"
+	"math"
+	"testing"
+)
+
+func TestCalcolaAssegnoUnico(t *testing.T) {
+	cases := []struct {
+		nome    string
+		profilo models.UserProfile
+		mensile float64
+	}{
+		{"un figlio, ISEE minimo", models.UserProfile{ISEE: 10000, Componenti: []models.Componente{{Relazione: models.RelazioneFiglio, Eta: anni(5)}}}, 201.00},
+		{"un figlio, senza ISEE", models.UserProfile{Componenti: []models.Componente{{Relazione: models.RelazioneFiglio, Eta: anni(5)}}}, 57.50},
+		{"un figlio, ISEE oltre soglia", models.UserProfile{ISEE: 60000, Componenti: []models.Componente{{Relazione: models.RelazioneFiglio, Eta: anni(5)}}}, 57.50},
+		// A metà tra le soglie: 201 - (201-57,5)/2
+		{"un figlio, ISEE intermedio", models.UserProfile{ISEE: (17227.33 + 45939.56) / 2, Componenti: []models.Componente{{Relazione: models.RelazioneFiglio, Eta: anni(5)}}}, 129.25},
+		{"neonato", models.UserProfile{ISEE: 10000, Componenti: []models.Componente{{Relazione: models.RelazioneFiglio, Eta: anni(0)}}}, 301.50},
+		{"figlio 19 anni studente", models.UserProfile{ISEE: 10000, Componenti: []models.Componente{{Relazione: models.RelazioneFiglio, Eta: anni(19), Studente: true}}}, 97.70},
+		{"figlio 19 anni senza requisiti", models.UserProfile{ISEE: 10000, Componenti: []models.Componente{{Relazione: models.RelazioneFiglio, Eta: anni(19)}}}, 0},
+		{"figlio disabile grave", models.UserProfile{Componenti: []models.Componente{{Relazione: models.RelazioneFiglio, Eta: anni(8), Disabilita: models.DisabilitaGrave}}}, 57.50 + 109.10},
+		{"figlio disabile 25 anni", models.UserProfile{ISEE: 10000, Componenti: []models.Componente{{Relazione: models.RelazioneFiglio, Eta: anni(25), Disabilita: models.DisabilitaMedia}}}, 97.70},
+		{"genitori lavoratori", models.UserProfile{ISEE: 10000, GenitoriOccupati: true, Componenti: []models.Componente{{Relazione: models.RelazioneFiglio, Eta: anni(5)}}}, 201.00 + 34.40},
+		{"madre under 21", models.UserProfile{ISEE: 10000, MadreUnder21: true, Componenti: []models.Componente{{Relazione: models.RelazioneFiglio, Eta: anni(1)}}}, 201.00 + 23.00},
+		// Tre figli: terzo con maggiorazione, il piccolo di 2 anni con aumento del 50%
+		{"tre figli", models.UserProfile{ISEE: 10000, Componenti: []models.Componente{{Relazione: models.RelazioneFiglio, Eta: anni(10)}, {Relazione: models.RelazioneFiglio, Eta: anni(6)}, {Relazione: models.RelazioneFiglio, Eta: anni(2)}}}, 201*3 + 100.50 + 97.70},
+		// Quattro figli: forfait nucleo numeroso
+		{"quattro figli", models.UserProfile{ISEE: 10000, Componenti: []models.Componente{{Relazione: models.RelazioneFiglio, Eta: anni(12)}, {Relazione: models.RelazioneFiglio, Eta: anni(10)}, {Relazione: models.RelazioneFiglio, Eta: anni(8)}, {Relazione: models.RelazioneFiglio, Eta: anni(6)}}}, 201*4 + 97.70*2 + 150},
+	}
+	for _, c := range cases {
+		got := calcolaAssegnoUnico(c.profilo)
+		if math.Abs(got.Mensile-c.mensile) > 0.005 {
+			t.Errorf("%s: mensile atteso %.2f, ottenuto %.2f (%+v)", c.nome, c.mensile, got.Mensile, got.Dettaglio)
+		}
+		if math.Abs(got.Annuale-arrotonda2(got.Mensile*12)) > 0.005 {
+			t.Errorf("%s: annuale %.2f non coerente con mensile %.2f", c.nome, got.Annuale, got.Mensile)
+		}
+	}
+}
+
+func TestCalcolaAssegnoUnico_DaContatori(t *testing.T) {
+	// Senza dettaglio dei figli si usano i contatori aggregati
+	p := models.UserProfile{ISEE: 10000, NumeroFigli: 2, FigliMinorenni: 2, FigliUnder3: 1, NuovoNato2025: true}
+	got := calcolaAssegnoUnico(p)
+	if want := 201*2 + 100.50; math.Abs(got.Mensile-want) > 0.005 {
+		t.Errorf("mensile atteso %.2f, ottenuto %.2f (%+v)", want, got.Mensile, got.Dettaglio)
+	}
+}
@@ -0,0 +1,219 @@
+package matcher
+
+import (
+	"bonusperme/internal/models"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxVincolati bounds the exhaustive search over the bonuses tied by a
+// relation: beyond it only those with the highest saving are searched, the
+// others join the result afterwards when they fit.
+const maxVincolati = 16
+
+// combinazione picks, among the active bonuses of matched, the set that can be
+// claimed together with the highest saving, given the relations declared in
+// the catalogue. Bonuses left out are marked FuoriCombinazione with a note,
+// reduced ones get a note too. savings[i] is the saving of matched[i], nomi
+// maps catalogue ids to names. It returns the saving of the chosen set.
+func combinazione(matched []models.Bonus, savings []float64, nomi map[string]string) float64 {
+	indice := make(map[string]int)
+	for i, b := range matched {
+		if !b.Scaduto {
+			indice[b.ID] = i
+		}
+	}
+	nome := func(id string) string {
+		if i, ok := indice[id]; ok {
+			return matched[i].Nome
+		}
+		if n := nomi[id]; n != "" {
+			return n
+		}
+		return id
+	}
+
+	// Esclusioni (valgono nei due sensi) e requisiti tra bonus attivi
+	esclusi := make(map[[2]int]bool)
+	richiesti := make(map[int][]int)
+	vincolato := make(map[int]bool)
+	mancante := make(map[int]string) // requisito non trovato o scaduto
+	for i, b := range matched {
+		if b.Scaduto || b.Relazioni == nil {
+			continue
+		}
+		for _, id := range b.Relazioni.Esclude {
+			if j, ok := indice[id]; ok {
+				esclusi[[2]int{i, j}], esclusi[[2]int{j, i}] = true, true
+				vincolato[i], vincolato[j] = true, true
+			}
+		}
+		for _, id := range b.Relazioni.Richiede {
+			j, ok := indice[id]
+			if !ok {
+				if _, gia := mancante[i]; !gia {
+					mancante[i] = id
+				}
+				continue
+			}
+			richiesti[i] = append(richiesti[i], j)
+			vincolato[i], vincolato[j] = true, true
+		}
+	}
+	for i := range mancante {
+		delete(vincolato, i)
+	}
+	for i := range matched {
+		for j := range matched {
+			if esclusi[[2]int{i, j}] {
+				matched[i].Conflitti = append(matched[i].Conflitti, matched[j].ID)
+			}
+		}
+	}
+
+	// Fuori dalla ricerca i bonus liberi entrano sempre, quelli senza
+	// requisito mai
+	scelti := make([]bool, len(matched))
+	var daScegliere []int
+	for i, b := range matched {
+		_, manca := mancante[i]
+		switch {
+		case b.Scaduto || manca:
+		case vincolato[i]:
+			daScegliere = append(daScegliere, i)
+		default:
+			scelti[i] = true
+		}
+	}
+	sort.SliceStable(daScegliere, func(a, b int) bool { return savings[daScegliere[a]] > savings[daScegliere[b]] })
+	var esclusiRicerca []int
+	if len(daScegliere) > maxVincolati {
+		daScegliere, esclusiRicerca = daScegliere[:maxVincolati], daScegliere[maxVincolati:]
+	}
+
+	valida := func(s []bool) bool {
+		for k := range esclusi {
+			if s[k[0]] && s[k[1]] {
+				return false
+			}
+		}
+		for i, req := range richiesti {
+			for _, j := range req {
+				if s[i] && !s[j] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	migliore, valoreMigliore, quantiMigliore := -1, 0.0, 0
+	prova := make([]bool, len(matched))
+	for mask := 0; mask < 1<<len(daScegliere); mask++ {
+		copy(prova, scelti)
+		quanti := 0
+		for k, i := range daScegliere {
+			if mask&(1<<k) != 0 {
+				prova[i] = true
+				quanti++
+			}
+		}
+		if !valida(prova) {
+			continue
+		}
+		v, _ := valore(matched, savings, prova)
+		if migliore < 0 || v > valoreMigliore || (v == valoreMigliore && quanti > quantiMigliore) {
+			migliore, valoreMigliore, quantiMigliore = mask, v, quanti
+		}
+	}
+	for k, i := range daScegliere {
+		scelti[i] = migliore&(1<<k) != 0
+	}
+	// The bonuses beyond the search are added, richest first, when they do not
+	// break a relation of the chosen set
+	for _, i := range esclusiRicerca {
+		scelti[i] = true
+		if !valida(scelti) {
+			scelti[i] = false
+		}
+	}
+
+	totale, riduzioni := valore(matched, savings, scelti)
+	for i := range matched {
+		b := &matched[i]
+		if b.Scaduto {
+			continue
+		}
+		if !scelti[i] {
+			b.FuoriCombinazione = true
+			b.NotaCombinazione = notaEsclusione(matched, i, scelti, esclusi, richiesti, mancante, nome)
+			continue
+		}
+		b.NotaCombinazione = strings.Join(riduzioni[i], "; ")
+	}
+	return totale
+}
+
+// valore returns the total saving of the bonuses in scelti once reductions
+// apply, and the notes explaining them per reduced bonus.
+func valore(matched []models.Bonus, savings []float64, scelti []bool) (float64, map[int][]string) {
+	netti := make([]float64, len(matched))
+	copy(netti, savings)
+	indice := make(map[string]int)
+	for i, b := range matched {
+		if scelti[i] {
+			indice[b.ID] = i
+		}
+	}
+	note := make(map[int][]string)
+	for i, b := range matched {
+		if !scelti[i] || b.Relazioni == nil {
+			continue
+		}
+		for _, r := range b.Relazioni.Riduce {
+			j, ok := indice[r.Bonus]
+			if !ok {
+				continue
+			}
+			taglio := min(savings[i], netti[j])
+			if r.ImportoMassimo > 0 {
+				taglio = min(taglio, r.ImportoMassimo)
+			}
+			if taglio <= 0 {
+				continue
+			}
+			netti[j] -= taglio
+			nota := fmt.Sprintf("Ridotto di circa €%.0f se richiesto insieme a %s", taglio, b.Nome)
+			if r.Nota != "" {
+				nota += ": " + r.Nota
+			}
+			note[j] = append(note[j], nota)
+		}
+	}
+	totale := 0.0
+	for i := range matched {
+		if scelti[i] {
+			totale += netti[i]
+		}
+	}
+	return totale, note
+}
+
+// notaEsclusione explains why matched[i] was left out of the combination.
+func notaEsclusione(matched []models.Bonus, i int, scelti []bool, esclusi map[[2]int]bool, richiesti map[int][]int, mancante map[int]string, nome func(string) string) string {
+	if id, ok := mancante[i]; ok {
+		return "Richiede anche " + nome(id) + ", che non risulta attivo per il tuo profilo"
+	}
+	for j := range matched {
+		if scelti[j] && esclusi[[2]int{i, j}] {
+			return "Non cumulabile con " + matched[j].Nome + ", più conveniente nel tuo caso"
+		}
+	}
+	for _, j := range richiesti[i] {
+		if !scelti[j] {
+			return "Richiede anche " + matched[j].Nome + ", non cumulabile con i bonus scelti"
+		}
+	}
+	return "Non cumulabile con i bonus scelti"
+}
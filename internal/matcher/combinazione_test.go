@@ -0,0 +1,142 @@
+package matcher
+
+import (
+	"bonusperme/internal/models"
+	"strings"
+	"testing"
+)
+
+func bonusRisparmio(id, risparmio, scadenza string, rel *models.Relazioni) models.Bonus {
+	return models.Bonus{
+		ID: id, Nome: "Bonus " + id, Categoria: "casa", Scadenza: scadenza, Relazioni: rel,
+		Regole: &models.RegoleBonus{
+			Punteggio: []models.Regola{{Valore: "80"}},
+			Risparmio: []models.Regola{{Valore: risparmio}},
+		},
+	}
+}
+
+func perID(result models.MatchResult) map[string]models.Bonus {
+	m := make(map[string]models.Bonus)
+	for _, b := range result.Bonus {
+		m[b.ID] = b
+	}
+	return m
+}
+
+func TestMatchBonus_Combinazione(t *testing.T) {
+	catalogo := []models.Bonus{
+		bonusRisparmio("ristrutturazione", "5000", "In vigore", nil),
+		bonusRisparmio("mobili", "2500", "In vigore", &models.Relazioni{Richiede: []string{"ristrutturazione"}}),
+		bonusRisparmio("ecobonus", "3000", "In vigore", &models.Relazioni{Esclude: []string{"ristrutturazione"}}),
+		bonusRisparmio("verde", "800", "In vigore", nil),
+	}
+	result := MatchBonus(models.UserProfile{Eta: 40}, catalogo)
+	if result.RisparmioStimato != "€8300" {
+		t.Errorf("risparmio = %s, atteso €8300 (ristrutturazione + mobili + verde)", result.RisparmioStimato)
+	}
+	b := perID(result)
+	if eco := b["ecobonus"]; !eco.FuoriCombinazione || !strings.Contains(eco.NotaCombinazione, "Bonus ristrutturazione") {
+		t.Errorf("ecobonus dovrebbe restare fuori per la ristrutturazione: %+v", eco)
+	}
+	if r := b["ristrutturazione"]; r.FuoriCombinazione || len(r.Conflitti) != 1 || r.Conflitti[0] != "ecobonus" {
+		t.Errorf("l'esclusione vale nei due sensi: %+v", r.Conflitti)
+	}
+	if result.BonusAttivi != 4 {
+		t.Errorf("i bonus fuori combinazione restano tra quelli trovati: %d attivi", result.BonusAttivi)
+	}
+
+	// Senza la ristrutturazione il bonus mobili non si può chiedere
+	catalogo[0].Scadenza = "31 dicembre 2020"
+	result = MatchBonus(models.UserProfile{Eta: 40}, catalogo)
+	b = perID(result)
+	if m := b["mobili"]; !m.FuoriCombinazione || !strings.HasPrefix(m.NotaCombinazione, "Richiede anche Bonus ristrutturazione") {
+		t.Errorf("mobili senza ristrutturazione attiva: %+v", m)
+	}
+	if b["ecobonus"].FuoriCombinazione || result.RisparmioStimato != "€3800" {
+		t.Errorf("senza ristrutturazione conviene l'ecobonus: %s", result.RisparmioStimato)
+	}
+}
+
+func TestMatchBonus_CombinazioneRiduzione(t *testing.T) {
+	catalogo := []models.Bonus{
+		bonusRisparmio("adi", "6000", "In vigore", nil),
+		bonusRisparmio("affitto", "2000", "Bando annuale", &models.Relazioni{
+			Riduce: []models.Riduzione{{Bonus: "adi", ImportoMassimo: 1500, Nota: "compensato con la quota affitto"}},
+		}),
+	}
+	result := MatchBonus(models.UserProfile{Eta: 40}, catalogo)
+	if result.RisparmioStimato != "€6500" {
+		t.Errorf("risparmio = %s, atteso €6500 (6000 - 1500 + 2000)", result.RisparmioStimato)
+	}
+	if adi := perID(result)["adi"]; adi.FuoriCombinazione || !strings.Contains(adi.NotaCombinazione, "Ridotto di circa €1500") {
+		t.Errorf("nota di riduzione mancante: %+v", adi)
+	}
+}
+
+// ADI, SFL e Carta Dedicata a te si escludono a vicenda: resta la più ricca
+func TestMatchBonus_CombinazioneCatalogo(t *testing.T) {
+	p := models.UserProfile{
+		Eta: 30, NumeroFigli: 2, FigliMinorenni: 2, ISEE: 5000, Occupazione: "disoccupato",
+	}
+	b := perID(MatchBonus(p))
+	for _, id := range []string{"adi", "sfl", "carta-dedicata"} {
+		if _, ok := b[id]; !ok {
+			t.Fatalf("%s mancante", id)
+		}
+	}
+	if b["adi"].FuoriCombinazione || !b["sfl"].FuoriCombinazione || !b["carta-dedicata"].FuoriCombinazione {
+		t.Errorf("attesa solo l'ADI: adi=%v sfl=%v carta=%v",
+			b["adi"].FuoriCombinazione, b["sfl"].FuoriCombinazione, b["carta-dedicata"].FuoriCombinazione)
+	}
+	if !strings.Contains(b["sfl"].NotaCombinazione, "Assegno di Inclusione") {
+		t.Errorf("nota SFL = %q", b["sfl"].NotaCombinazione)
+	}
+}
+
+// Ecobonus e bonus ristrutturazione non si cumulano solo sulle stesse spese:
+// restano entrambi, con il risparmio stimato sulle spese in comune
+func TestMatchBonus_CombinazioneEcobonus(t *testing.T) {
+	var catalogo []models.Bonus
+	for _, b := range GetAllBonus() {
+		if b.ID == "ecobonus" || b.ID == "bonus-ristrutturazione" {
+			// Le relazioni del catalogo, su bonus ancora aperti
+			b.Scadenza, b.Termine = "In vigore", nil
+			catalogo = append(catalogo, b)
+		}
+	}
+	b := perID(MatchBonus(models.UserProfile{Eta: 40, RistrutturazCasa: true}, catalogo))
+	eco, ristr := b["ecobonus"], b["bonus-ristrutturazione"]
+	if eco.ID == "" || ristr.ID == "" {
+		t.Fatal("ecobonus o bonus ristrutturazione mancanti")
+	}
+	if eco.FuoriCombinazione || ristr.FuoriCombinazione {
+		t.Errorf("entrambi devono restare nella combinazione: eco=%q ristr=%q", eco.NotaCombinazione, ristr.NotaCombinazione)
+	}
+	if !strings.Contains(ristr.NotaCombinazione, "stesse spese") {
+		t.Errorf("nota ristrutturazione = %q", ristr.NotaCombinazione)
+	}
+}
+
+// Oltre maxVincolati i bonus non esplorati entrano se non violano vincoli
+func TestMatchBonus_CombinazioneOltreRicerca(t *testing.T) {
+	var catalogo []models.Bonus
+	for i := 0; i < maxVincolati+2; i++ {
+		id := string(rune('a' + i))
+		catalogo = append(catalogo, bonusRisparmio(id, "1000", "In vigore", &models.Relazioni{Esclude: []string{"x" + id}}))
+		catalogo = append(catalogo, bonusRisparmio("x"+id, "100", "In vigore", nil))
+	}
+	result := MatchBonus(models.UserProfile{Eta: 40}, catalogo)
+	for id, b := range perID(result) {
+		escluso := strings.HasPrefix(id, "x")
+		if b.FuoriCombinazione != escluso {
+			t.Errorf("%s: fuori combinazione = %v, atteso %v (%q)", id, b.FuoriCombinazione, escluso, b.NotaCombinazione)
+		}
+		if escluso && !strings.HasPrefix(b.NotaCombinazione, "Non cumulabile con Bonus "+id[1:]) {
+			t.Errorf("%s: nota = %q", id, b.NotaCombinazione)
+		}
+	}
+	if want := "€18000"; result.RisparmioStimato != want {
+		t.Errorf("risparmio = %s, atteso %s", result.RisparmioStimato, want)
+	}
+}
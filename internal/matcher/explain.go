@@ -0,0 +1,329 @@
+package matcher
+
+import (
+	"bonusperme/internal/deadline"
+	"bonusperme/internal/models"
+	"bonusperme/internal/regions"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxMancanti is the number of failed requirements up to which an excluded
+// bonus is still reported as a near miss.
+const maxMancanti = 2
+
+// etichetteCampi are the human-readable names of profile fields used in explanations.
+var etichetteCampi = map[string]string{
+	"eta":               "età",
+	"residenza":         "regione di residenza",
+	"comune":            "comune",
+	"stato_civile":      "stato civile",
+	"occupazione":       "occupazione",
+	"numero_figli":      "numero di figli",
+	"figli_minorenni":   "figli minorenni",
+	"figli_under3":      "figli sotto i 3 anni",
+	"disabilita":        "disabilità in famiglia",
+	"over65":            "componenti over 65",
+	"isee":              "ISEE",
+	"reddito_annuo":     "reddito annuo",
+	"affittuario":       "casa in affitto",
+	"prima_abitazione":  "acquisto prima casa",
+	"ristrutturaz_casa": "lavori di ristrutturazione",
+	"studente":          "studente",
+	"nuovo_nato_2025":   "figlio nato o adottato quest'anno",
+	// Set by the rule's calculator from the household
+	"importo_mensile": "importo mensile spettante",
+	"importo_annuo":   "importo annuo spettante",
+}
+
+// envTipoISEE holds the ISEE variant of the bonus in the rule environment.
+const envTipoISEE = "#tipo_isee"
+
+// etichetta returns the label of a profile field, naming the ISEE variant
+// when the bonus does not use the ordinary ISEE.
+func etichetta(campo string, env exprEnv) string {
+	if tipo, _ := env[envTipoISEE].(string); campo == "isee" && tipo != "" && tipo != models.ISEEOrdinario {
+		return "ISEE " + tipo
+	}
+	return etichetteCampi[campo]
+}
+
+// campiInEuro are formatted as amounts in explanations.
+var campiInEuro = map[string]bool{"isee": true, "reddito_annuo": true, "soglia_isee": true,
+	"importo_mensile": true, "importo_annuo": true}
+
+var simboliOperatori = map[string]string{
+	"<": "<", "<=": "≤", ">": ">", ">=": "≥", "==": "=", "!=": "≠",
+}
+
+// spiegaCondizione evaluates src and describes it for the user.
+// ok is false when the condition does not concern the profile (e.g. a bonus
+// without ISEE threshold) and should not be shown.
+func spiegaCondizione(tipo, src string, env exprEnv) (c models.Condizione, ok bool, err error) {
+	n, err := compileExpr(src)
+	if err != nil {
+		return c, false, err
+	}
+	// Disjuncts that only depend on bonus attributes decide the condition on their own:
+	// if one is true the requirement does not apply, otherwise it is left out of the text.
+	var parti []exprNode
+	for _, d := range disgiunti(n) {
+		if haCampiProfilo(d) {
+			parti = append(parti, d)
+			continue
+		}
+		v, err := d.eval(env)
+		if err != nil {
+			return c, false, err
+		}
+		if truthy(v) {
+			return c, false, nil
+		}
+	}
+	if len(parti) == 0 {
+		return c, false, nil
+	}
+
+	v, err := n.eval(env)
+	if err != nil {
+		return c, false, err
+	}
+	c = models.Condizione{Tipo: tipo, Superata: truthy(v)}
+
+	var campi []string
+	seen := make(map[string]bool)
+	for _, p := range parti {
+		if usaNucleo(p) && !seen["componenti"] {
+			seen["componenti"] = true
+			campi = append(campi, "componenti")
+		}
+		for _, id := range exprIdents(p) {
+			if _, isField := etichetteCampi[id]; isField && !seen[id] {
+				seen[id] = true
+				campi = append(campi, id)
+			}
+		}
+	}
+	c.Campo = strings.Join(campi, ", ")
+	if len(campi) == 1 && campi[0] != "componenti" {
+		c.Valore = env[campi[0]]
+	}
+	if len(parti) == 1 {
+		if b, ok := parti[0].(binaryOp); ok {
+			if campo, soglia, op, ok := confronto(b, env); ok && campo == c.Campo {
+				c.Operatore = op
+				c.Soglia = soglia
+			}
+		}
+	}
+
+	testi := make([]string, len(parti))
+	for i, p := range parti {
+		testi[i] = descrivi(p, env)
+	}
+	testo := strings.Join(testi, " oppure ")
+	if c.Superata {
+		c.Motivo = "Soddisfatto: " + testo
+	} else {
+		c.Motivo = "Non soddisfatto: " + testo
+	}
+	if c.Valore != nil {
+		c.Motivo += fmt.Sprintf(" (tuo valore: %s)", formattaValore(campi[0], c.Valore))
+	}
+	return c, true, nil
+}
+
+// disgiunti splits a || chain into its operands.
+func disgiunti(n exprNode) []exprNode {
+	if b, ok := n.(binaryOp); ok && b.op == "||" {
+		return append(disgiunti(b.l), disgiunti(b.r)...)
+	}
+	return []exprNode{n}
+}
+
+func haCampiProfilo(n exprNode) bool {
+	if usaNucleo(n) {
+		return true
+	}
+	for _, id := range exprIdents(n) {
+		if _, ok := etichetteCampi[id]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// confronto recognises "field op value" (or "value op field") comparisons.
+func confronto(b binaryOp, env exprEnv) (campo string, soglia interface{}, op string, ok bool) {
+	if _, isCmp := simboliOperatori[b.op]; !isCmp {
+		return "", nil, "", false
+	}
+	if id, isID := b.l.(identRef); isID {
+		if _, isField := etichetteCampi[id.name]; isField && !haCampiProfilo(b.r) {
+			if v, err := b.r.eval(env); err == nil {
+				return id.name, v, b.op, true
+			}
+		}
+	}
+	if id, isID := b.r.(identRef); isID {
+		if _, isField := etichetteCampi[id.name]; isField && !haCampiProfilo(b.l) {
+			if v, err := b.l.eval(env); err == nil {
+				return id.name, v, invertiOperatore(b.op), true
+			}
+		}
+	}
+	return "", nil, "", false
+}
+
+func invertiOperatore(op string) string {
+	switch op {
+	case "<":
+		return ">"
+	case "<=":
+		return ">="
+	case ">":
+		return "<"
+	case ">=":
+		return "<="
+	}
+	return op
+}
+
+// descrivi renders an expression in plain Italian, e.g. "ISEE ≤ €9.360".
+func descrivi(n exprNode, env exprEnv) string {
+	switch x := n.(type) {
+	case identRef:
+		if _, ok := etichetteCampi[x.name]; ok {
+			return etichetta(x.name, env)
+		}
+		return formattaValore(x.name, env[x.name])
+	case unaryOp:
+		if x.op == "!" {
+			return "non " + descrivi(x.x, env)
+		}
+	case binaryOp:
+		switch x.op {
+		case "&&":
+			return descrivi(x.l, env) + " e " + descrivi(x.r, env)
+		case "||":
+			return descrivi(x.l, env) + " oppure " + descrivi(x.r, env)
+		}
+		if campo, soglia, op, ok := confronto(x, env); ok {
+			return etichetta(campo, env) + " " + simboliOperatori[op] + " " + formattaValore(campo, soglia)
+		}
+		if sym, ok := simboliOperatori[x.op]; ok {
+			return descrivi(x.l, env) + " " + sym + " " + descrivi(x.r, env)
+		}
+	case callExpr:
+		// conta_figli(6, 14) -> "figli tra 6 e 14 anni"
+		if f, ok := funzioniNucleo[x.name]; ok && len(x.args) == 2 {
+			return fmt.Sprintf("%s tra %s e %s anni", f.etichetta, descrivi(x.args[0], env), descrivi(x.args[1], env))
+		}
+	case numLit:
+		return formattaNumero(x.v)
+	case strLit:
+		return x.v
+	}
+	// Arithmetic and function calls: show the computed value
+	if v, err := n.eval(env); err == nil {
+		return formattaValore("", v)
+	}
+	return ""
+}
+
+func formattaValore(campo string, v interface{}) string {
+	switch x := v.(type) {
+	case float64:
+		if campiInEuro[campo] {
+			return "€" + formattaNumero(x)
+		}
+		return formattaNumero(x)
+	case bool:
+		if x {
+			return "sì"
+		}
+		return "no"
+	case string:
+		if x == "" {
+			return "non indicato"
+		}
+		return x
+	}
+	return fmt.Sprint(v)
+}
+
+// formattaNumero formats n in Italian style: 15748.78 -> "15.748,78".
+func formattaNumero(n float64) string {
+	neg := n < 0
+	n = math.Abs(n)
+	intPart := int64(n)
+	dec := math.Round((n - float64(intPart)) * 100)
+	if dec == 100 {
+		intPart++
+		dec = 0
+	}
+	s := strconv.FormatInt(intPart, 10)
+	var sb strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteRune(r)
+	}
+	out := sb.String()
+	if dec > 0 {
+		out += fmt.Sprintf(",%02d", int(dec))
+	}
+	if neg {
+		out = "-" + out
+	}
+	return out
+}
+
+// AlmostEligible returns the bonuses excluded for the profile because of at most
+// maxMancanti failed requirements, each with its list of Verifiche.
+// Bonuses outside the user's region are not considered.
+func AlmostEligible(profile models.UserProfile, bonusList ...[]models.Bonus) []models.Bonus {
+	var allBonus []models.Bonus
+	if len(bonusList) > 0 && len(bonusList[0]) > 0 {
+		allBonus = bonusList[0]
+	} else {
+		allBonus = GetAllBonusWithRegional()
+	}
+	profile.NormalizeHousehold()
+	userRegion := regions.Code(profile.Residenza)
+	comune, provincia := localitaUtente(profile)
+
+	type quasi struct {
+		b        models.Bonus
+		mancanti int
+	}
+	var out []quasi
+	for _, b := range allBonus {
+		if !applicabileInRegione(b, userRegion) || !applicabileInComune(b, comune, provincia) {
+			continue
+		}
+		if b.Regole == nil && len(b.RegioniApplicabili) > 0 {
+			b.Regole = regoleRegionali(strings.ToLower(b.Categoria))
+		}
+		res := valutaRegole(b, profile)
+		if res.Punteggio > 0 || res.Mancanti == 0 || res.Mancanti > maxMancanti {
+			continue
+		}
+		b.Verifiche = res.Verifiche
+		b.Scaduto = deadline.Expired(deadline.Of(b), time.Now())
+		out = append(out, quasi{b, res.Mancanti})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].mancanti < out[j].mancanti
+	})
+	result := make([]models.Bonus, len(out))
+	for i, q := range out {
+		result[i] = q.b
+	}
+	return result
+}
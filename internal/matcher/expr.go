@@ -0,0 +1,661 @@
+package matcher
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Small expression language used by bonus rules (see models.RegoleBonus).
+//
+// Grammar, lowest to highest precedence:
+//
+//	cond ? a : b
+//	a || b
+//	a && b
+//	a == b, a != b
+//	a < b, a <= b, a > b, a >= b
+//	a + b, a - b
+//	a * b, a / b
+//	!a, -a
+//	number, "string", true, false, identifier, func(args...), (expr)
+//
+// Identifiers are the JSON names of models.UserProfile fields plus the
+// variables declared by the rule itself.
+
+type exprValue = interface{}
+
+type exprEnv map[string]exprValue
+
+type exprNode interface {
+	eval(env exprEnv) (exprValue, error)
+}
+
+type (
+	numLit   struct{ v float64 }
+	strLit   struct{ v string }
+	boolLit  struct{ v bool }
+	identRef struct{ name string }
+	unaryOp  struct {
+		op string
+		x  exprNode
+	}
+	binaryOp struct {
+		op   string
+		l, r exprNode
+	}
+	ternaryOp struct{ cond, a, b exprNode }
+	callExpr  struct {
+		name string
+		args []exprNode
+	}
+)
+
+// exprFunc is a built-in function; env gives access to the evaluation context.
+type exprFunc func(env exprEnv, args []exprValue) (exprValue, error)
+
+// exprFuncs are the built-in functions available to rules.
+var exprFuncs = map[string]exprFunc{
+	"min": func(_ exprEnv, args []exprValue) (exprValue, error) {
+		return foldNums("min", args, math.Min)
+	},
+	"max": func(_ exprEnv, args []exprValue) (exprValue, error) {
+		return foldNums("max", args, math.Max)
+	},
+	"arrotonda": func(_ exprEnv, args []exprValue) (exprValue, error) {
+		if len(args) < 1 || len(args) > 2 {
+			return nil, fmt.Errorf("arrotonda: attesi 1 o 2 argomenti")
+		}
+		x, err := toNum(args[0])
+		if err != nil {
+			return nil, err
+		}
+		dec := 0.0
+		if len(args) == 2 {
+			if dec, err = toNum(args[1]); err != nil {
+				return nil, err
+			}
+		}
+		p := math.Pow(10, dec)
+		return math.Round(x*p) / p, nil
+	},
+}
+
+func foldNums(name string, args []exprValue, f func(a, b float64) float64) (exprValue, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%s: almeno un argomento richiesto", name)
+	}
+	acc, err := toNum(args[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range args[1:] {
+		n, err := toNum(a)
+		if err != nil {
+			return nil, err
+		}
+		acc = f(acc, n)
+	}
+	return acc, nil
+}
+
+func (n numLit) eval(exprEnv) (exprValue, error)  { return n.v, nil }
+func (n strLit) eval(exprEnv) (exprValue, error)  { return n.v, nil }
+func (n boolLit) eval(exprEnv) (exprValue, error) { return n.v, nil }
+
+func (n identRef) eval(env exprEnv) (exprValue, error) {
+	v, ok := env[n.name]
+	if !ok {
+		return nil, fmt.Errorf("campo sconosciuto %q", n.name)
+	}
+	return v, nil
+}
+
+func (n unaryOp) eval(env exprEnv) (exprValue, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "!":
+		return !truthy(x), nil
+	case "-":
+		f, err := toNum(x)
+		if err != nil {
+			return nil, err
+		}
+		return -f, nil
+	}
+	return nil, fmt.Errorf("operatore unario %q non supportato", n.op)
+}
+
+func (n binaryOp) eval(env exprEnv) (exprValue, error) {
+	l, err := n.l.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	// Short-circuit logical operators
+	switch n.op {
+	case "&&":
+		if !truthy(l) {
+			return false, nil
+		}
+		r, err := n.r.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(r), nil
+	case "||":
+		if truthy(l) {
+			return true, nil
+		}
+		r, err := n.r.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		return truthy(r), nil
+	}
+
+	r, err := n.r.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==", "!=":
+		eq, err := valuesEqual(l, r)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!=" {
+			return !eq, nil
+		}
+		return eq, nil
+	}
+
+	a, err := toNum(l)
+	if err != nil {
+		return nil, err
+	}
+	b, err := toNum(r)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	case ">":
+		return a > b, nil
+	case ">=":
+		return a >= b, nil
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, fmt.Errorf("divisione per zero")
+		}
+		return a / b, nil
+	}
+	return nil, fmt.Errorf("operatore %q non supportato", n.op)
+}
+
+func (n ternaryOp) eval(env exprEnv) (exprValue, error) {
+	c, err := n.cond.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if truthy(c) {
+		return n.a.eval(env)
+	}
+	return n.b.eval(env)
+}
+
+func (n callExpr) eval(env exprEnv) (exprValue, error) {
+	f, ok := exprFuncs[n.name]
+	if !ok {
+		return nil, fmt.Errorf("funzione sconosciuta %q", n.name)
+	}
+	args := make([]exprValue, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return f(env, args)
+}
+
+func truthy(v exprValue) bool {
+	switch x := v.(type) {
+	case bool:
+		return x
+	case float64:
+		return x != 0
+	case string:
+		return x != ""
+	}
+	return false
+}
+
+func toNum(v exprValue) (float64, error) {
+	switch x := v.(type) {
+	case float64:
+		return x, nil
+	case bool:
+		if x {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("valore non numerico: %v", v)
+}
+
+func valuesEqual(l, r exprValue) (bool, error) {
+	switch a := l.(type) {
+	case string:
+		b, ok := r.(string)
+		if !ok {
+			return false, fmt.Errorf("confronto tra testo e %T", r)
+		}
+		return strings.EqualFold(a, b), nil
+	case bool:
+		if b, ok := r.(bool); ok {
+			return a == b, nil
+		}
+	}
+	a, err := toNum(l)
+	if err != nil {
+		return false, err
+	}
+	b, err := toNum(r)
+	if err != nil {
+		return false, err
+	}
+	return a == b, nil
+}
+
+// ---------- lexer ----------
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokNum
+	tokStr
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+func tokenize(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		c, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(c):
+			i += size
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			toks = append(toks, token{tokNum, src[start:i], start})
+		case c == '"':
+			start := i
+			i++
+			for i < len(src) && src[i] != '"' {
+				i++
+			}
+			if i >= len(src) {
+				return nil, fmt.Errorf("stringa non terminata alla posizione %d", start)
+			}
+			toks = append(toks, token{tokStr, src[start+1 : i], start})
+			i++
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(src) {
+				r, n := utf8.DecodeRuneInString(src[i:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += n
+			}
+			toks = append(toks, token{tokIdent, src[start:i], start})
+		default:
+			if i+1 < len(src) {
+				two := src[i : i+2]
+				switch two {
+				case "&&", "||", "==", "!=", "<=", ">=":
+					toks = append(toks, token{tokOp, two, i})
+					i += 2
+					continue
+				}
+			}
+			if strings.ContainsRune("+-*/<>!()?:,", c) {
+				toks = append(toks, token{tokOp, string(c), i})
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("carattere non valido %q alla posizione %d", c, i)
+		}
+	}
+	toks = append(toks, token{tokEOF, "", len(src)})
+	return toks, nil
+}
+
+// ---------- parser ----------
+
+type exprParser struct {
+	toks []token
+	pos  int
+}
+
+func (p *exprParser) peek() token { return p.toks[p.pos] }
+
+func (p *exprParser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokOp && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) expect(op string) error {
+	if !p.accept(op) {
+		t := p.peek()
+		return fmt.Errorf("atteso %q alla posizione %d", op, t.pos)
+	}
+	return nil
+}
+
+func (p *exprParser) parseTernary() (exprNode, error) {
+	cond, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept("?") {
+		return cond, nil
+	}
+	a, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	b, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	return ternaryOp{cond, a, b}, nil
+}
+
+// binaryLevels lists binary operators from lowest to highest precedence.
+var binaryLevels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/"},
+}
+
+func (p *exprParser) parseBinary(level int) (exprNode, error) {
+	if level == len(binaryLevels) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		matched := false
+		if t.kind == tokOp {
+			for _, op := range binaryLevels[level] {
+				if t.text == op {
+					matched = true
+					break
+				}
+			}
+		}
+		if !matched {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryOp{t.text, left, right}
+	}
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.accept("!") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryOp{"!", x}, nil
+	}
+	if p.accept("-") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryOp{"-", x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNum:
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("numero non valido %q", t.text)
+		}
+		return numLit{v}, nil
+	case tokStr:
+		return strLit{t.text}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return boolLit{true}, nil
+		case "false":
+			return boolLit{false}, nil
+		}
+		if p.accept("(") {
+			var args []exprNode
+			if !p.accept(")") {
+				for {
+					a, err := p.parseTernary()
+					if err != nil {
+						return nil, err
+					}
+					args = append(args, a)
+					if p.accept(")") {
+						break
+					}
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if _, ok := exprFuncs[t.text]; !ok {
+				return nil, fmt.Errorf("funzione sconosciuta %q", t.text)
+			}
+			return callExpr{t.text, args}, nil
+		}
+		return identRef{t.text}, nil
+	case tokOp:
+		if t.text == "(" {
+			x, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+	case tokEOF:
+		return nil, fmt.Errorf("espressione incompleta")
+	}
+	return nil, fmt.Errorf("token inatteso %q alla posizione %d", t.text, t.pos)
+}
+
+// compiledExprs caches parsed expressions by source text.
+var compiledExprs sync.Map // map[string]exprNode
+
+// compileExpr parses src into an evaluable node, using the cache when possible.
+func compileExpr(src string) (exprNode, error) {
+	if n, ok := compiledExprs.Load(src); ok {
+		return n.(exprNode), nil
+	}
+	toks, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks}
+	n, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("token inatteso %q alla posizione %d", t.text, t.pos)
+	}
+	compiledExprs.Store(src, n)
+	return n, nil
+}
+
+// exprIdents returns the identifiers referenced by n (functions excluded).
+func exprIdents(n exprNode) []string {
+	var out []string
+	var walk func(exprNode)
+	walk = func(n exprNode) {
+		switch x := n.(type) {
+		case identRef:
+			out = append(out, x.name)
+		case unaryOp:
+			walk(x.x)
+		case binaryOp:
+			walk(x.l)
+			walk(x.r)
+		case ternaryOp:
+			walk(x.cond)
+			walk(x.a)
+			walk(x.b)
+		case callExpr:
+			for _, a := range x.args {
+				walk(a)
+			}
+		}
+	}
+	walk(n)
+	return out
+}
+
+// ---------- templates ----------
+
+// textTemplate is a string with {expr} placeholders, e.g. "€{mensile}/mese".
+type textTemplate struct {
+	parts []templatePart
+}
+
+type templatePart struct {
+	text string
+	expr exprNode
+}
+
+var compiledTemplates sync.Map // map[string]*textTemplate
+
+func compileTemplate(src string) (*textTemplate, error) {
+	if t, ok := compiledTemplates.Load(src); ok {
+		return t.(*textTemplate), nil
+	}
+	tpl := &textTemplate{}
+	rest := src
+	for {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			if rest != "" {
+				tpl.parts = append(tpl.parts, templatePart{text: rest})
+			}
+			break
+		}
+		close := strings.IndexByte(rest[open:], '}')
+		if close < 0 {
+			return nil, fmt.Errorf("segnaposto non chiuso in %q", src)
+		}
+		if open > 0 {
+			tpl.parts = append(tpl.parts, templatePart{text: rest[:open]})
+		}
+		n, err := compileExpr(rest[open+1 : open+close])
+		if err != nil {
+			return nil, err
+		}
+		tpl.parts = append(tpl.parts, templatePart{expr: n})
+		rest = rest[open+close+1:]
+	}
+	compiledTemplates.Store(src, tpl)
+	return tpl, nil
+}
+
+func (t *textTemplate) render(env exprEnv) (string, error) {
+	var sb strings.Builder
+	for _, p := range t.parts {
+		if p.expr == nil {
+			sb.WriteString(p.text)
+			continue
+		}
+		v, err := p.expr.eval(env)
+		if err != nil {
+			return "", err
+		}
+		switch x := v.(type) {
+		case float64:
+			sb.WriteString(fmt.Sprintf("%.2f", x))
+		default:
+			sb.WriteString(fmt.Sprint(x))
+		}
+	}
+	return sb.String(), nil
+}
+
+func (t *textTemplate) idents() []string {
+	var out []string
+	for _, p := range t.parts {
+		if p.expr != nil {
+			out = append(out, exprIdents(p.expr)...)
+		}
+	}
+	return out
+}
@@ -3,6 +3,7 @@ package matcher
 import (
 	"bonusperme/internal/models"
 	"testing"
+	"time"
 )
 
 func TestMatchBonus_FamigliaConFigli(t *testing.T) {
@@ -107,3 +108,31 @@ func TestMatchBonus_SenzaRegione(t *testing.T) {
 		}
 	}
 }
+
+func TestMatchBonus_ScadenzaPersonale(t *testing.T) {
+	profile := models.UserProfile{
+		Eta: 32, NumeroFigli: 1, FigliMinorenni: 1, FigliUnder3: 1, NuovoNato2025: true,
+		ISEE: 20000, Residenza: "Lazio", StatoCivile: "sposato", Occupazione: "dipendente",
+		DataNascitaFiglio: time.Now().AddDate(0, 0, -37).Format("2006-01-02"),
+	}
+	cerca := func(result models.MatchResult) *models.Bonus {
+		for i, b := range result.Bonus {
+			if b.ID == "bonus-nascita" {
+				return &result.Bonus[i]
+			}
+		}
+		t.Fatal("Carta per i Nuovi Nati mancante")
+		return nil
+	}
+
+	sp := cerca(MatchBonus(profile)).ScadenzaPersonale
+	if sp == nil || sp.GiorniMancanti != 23 || sp.Evento != "nascita" {
+		t.Errorf("60 giorni da una nascita di 37 giorni fa: %+v, attesi 23 giorni", sp)
+	}
+
+	// Senza la data della nascita non c'è una scadenza personale
+	profile.DataNascitaFiglio = ""
+	if sp := cerca(MatchBonus(profile)).ScadenzaPersonale; sp != nil {
+		t.Errorf("scadenza personale senza data evento: %+v", sp)
+	}
+}
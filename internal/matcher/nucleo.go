@@ -0,0 +1,119 @@
+package matcher
+
+import (
+	"bonusperme/internal/models"
+	"fmt"
+	"time"
+)
+
+// envProfilo is the environment key holding the whole profile, used by the
+// household functions. It is not a valid identifier, so rules cannot read it.
+const envProfilo = "#profilo"
+
+// membroNucleo is a household member with its age resolved.
+type membroNucleo struct {
+	models.Componente
+	eta int
+}
+
+// nucleo returns the household members of p. Without per-member detail the
+// members are approximated from the aggregate counters and stimato is true.
+// Members of unknown age are left out, as every computation here is by age.
+func nucleo(p models.UserProfile) (membri []membroNucleo, stimato bool) {
+	if len(p.Componenti) > 0 {
+		anno := time.Now().Year()
+		for _, c := range p.Componenti {
+			if eta, ok := c.AgeIn(anno); ok {
+				membri = append(membri, membroNucleo{c, eta})
+			}
+		}
+		return membri, false
+	}
+
+	membri = append(membri, membroNucleo{models.Componente{Relazione: models.RelazioneRichiedente, Studente: p.Studente}, p.Eta})
+	for i := 0; i < p.NumeroFigli; i++ {
+		var eta int
+		switch {
+		case i == 0 && p.FigliUnder3 > 0 && p.NuovoNato2025:
+			eta = 0
+		case i < p.FigliUnder3:
+			eta = 1
+		case i < p.FigliMinorenni:
+			eta = 10
+		default:
+			eta = 19
+		}
+		membri = append(membri, membroNucleo{models.Componente{Relazione: models.RelazioneFiglio}, eta})
+	}
+	for i := 0; i < p.Over65; i++ {
+		membri = append(membri, membroNucleo{models.Componente{Relazione: models.RelazioneAltro}, 70})
+	}
+	return membri, true
+}
+
+// funzioniNucleo count household members whose age is within [min, max].
+var funzioniNucleo = map[string]struct {
+	etichetta string
+	filtro    func(m membroNucleo) bool
+}{
+	"conta_componenti": {"componenti", func(m membroNucleo) bool { return true }},
+	"conta_figli": {"figli", func(m membroNucleo) bool {
+		return m.Relazione == models.RelazioneFiglio
+	}},
+	"conta_figli_studenti": {"figli studenti", func(m membroNucleo) bool {
+		return m.Relazione == models.RelazioneFiglio && m.Studente
+	}},
+	"conta_figli_disabili": {"figli con disabilità", func(m membroNucleo) bool {
+		return m.Relazione == models.RelazioneFiglio && m.Disabilita != ""
+	}},
+}
+
+func init() {
+	for name, fn := range funzioniNucleo {
+		name, filtro := name, fn.filtro
+		exprFuncs[name] = func(env exprEnv, args []exprValue) (exprValue, error) {
+			if len(args) != 2 {
+				return nil, fmt.Errorf("%s: attesi 2 argomenti (età minima, età massima)", name)
+			}
+			min, err := toNum(args[0])
+			if err != nil {
+				return nil, err
+			}
+			max, err := toNum(args[1])
+			if err != nil {
+				return nil, err
+			}
+			p, _ := env[envProfilo].(models.UserProfile)
+			membri, _ := nucleo(p)
+			n := 0
+			for _, m := range membri {
+				if float64(m.eta) >= min && float64(m.eta) <= max && filtro(m) {
+					n++
+				}
+			}
+			return float64(n), nil
+		}
+	}
+}
+
+// usaNucleo reports whether n calls one of the household functions.
+func usaNucleo(n exprNode) bool {
+	switch x := n.(type) {
+	case callExpr:
+		if _, ok := funzioniNucleo[x.name]; ok {
+			return true
+		}
+		for _, a := range x.args {
+			if usaNucleo(a) {
+				return true
+			}
+		}
+	case unaryOp:
+		return usaNucleo(x.x)
+	case binaryOp:
+		return usaNucleo(x.l) || usaNucleo(x.r)
+	case ternaryOp:
+		return usaNucleo(x.cond) || usaNucleo(x.a) || usaNucleo(x.b)
+	}
+	return false
+}
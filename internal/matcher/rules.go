@@ -0,0 +1,370 @@
+package matcher
+
+import (
+	"bonusperme/internal/logger"
+	"bonusperme/internal/models"
+	"fmt"
+)
+
+// profileEnv exposes the profile fields to rule expressions, using their JSON names.
+func profileEnv(p models.UserProfile, b models.Bonus) exprEnv {
+	return exprEnv{
+		"eta":               float64(p.Eta),
+		"residenza":         p.Residenza,
+		"comune":            p.Comune,
+		"stato_civile":      p.StatoCivile,
+		"occupazione":       p.Occupazione,
+		"numero_figli":      float64(p.NumeroFigli),
+		"figli_minorenni":   float64(p.FigliMinorenni),
+		"figli_under3":      float64(p.FigliUnder3),
+		"disabilita":        p.Disabilita,
+		"over65":            float64(p.Over65),
+		"isee":              p.ISEE,
+		"reddito_annuo":     p.RedditoAnnuo,
+		"affittuario":       p.Affittuario,
+		"prima_abitazione":  p.PrimaAbitazione,
+		"ristrutturaz_casa": p.RistrutturazCasa,
+		"studente":          p.Studente,
+		"nuovo_nato_2025":   p.NuovoNato2025,
+		// Bonus attributes usable by generic rules
+		"soglia_isee": b.SogliaISEE,
+		// Whole profile, for the household functions (conta_figli, ...)
+		envProfilo:  p,
+		envTipoISEE: b.TipoISEE,
+	}
+}
+
+// ruleResult is the outcome of evaluating a bonus rule set against a profile.
+type ruleResult struct {
+	Punteggio    int
+	Risparmio    float64
+	ImportoReale string
+	// Verifiche explains the evaluated conditions; Mancanti counts failed requirements.
+	Verifiche []models.Condizione
+	Mancanti  int
+	// Dettaglio is the breakdown produced by the rule's calculator, if any.
+	Dettaglio []models.VoceImporto
+}
+
+// calcolatori are the built-in amount calculators that rules can reference
+// through RegoleBonus.Calcolatore, for amounts too complex for expressions.
+var calcolatori = map[string]func(models.UserProfile) models.CalcoloImporto{
+	"assegno_unico": calcolaAssegnoUnico,
+}
+
+// identCalcolatore are the identifiers set by a calculator.
+var identCalcolatore = []string{"importo_mensile", "importo_annuo"}
+
+// valutaRegole evaluates the rules of b for profile p.
+// A bonus without rules, or with a failing eligibility condition, scores 0.
+// All requirements are evaluated, so that exclusions can be explained; one
+// that cannot be evaluated after a failed requirement counts as failed.
+func valutaRegole(b models.Bonus, p models.UserProfile) ruleResult {
+	var res ruleResult
+	r := b.Regole
+	if r == nil {
+		return res
+	}
+	// Rules and calculators see the ISEE variant required by the bonus
+	p.ISEE = p.ISEEFor(b.TipoISEE)
+	env, calc, err := buildEnv(r, p, b)
+	if err != nil {
+		logRuleError(b, err)
+		return res
+	}
+
+	for _, cond := range r.Idoneita {
+		ok, err := evalBool(cond, env)
+		if err != nil && res.Mancanti > 0 {
+			// An earlier failed requirement may guard this one
+			// ("numero_figli > 0" before a division by numero_figli)
+			res.Mancanti++
+			continue
+		}
+		if err != nil {
+			logRuleError(b, err)
+			return ruleResult{}
+		}
+		if !ok {
+			res.Mancanti++
+		}
+		if c, show, err := spiegaCondizione("requisito", cond, env); err == nil && show {
+			res.Verifiche = append(res.Verifiche, c)
+		}
+	}
+	if res.Mancanti > 0 {
+		return res
+	}
+
+	score, idx, err := firstNumber(r.Punteggio, env)
+	if err != nil {
+		logRuleError(b, err)
+		return ruleResult{}
+	}
+	res.Verifiche = append(res.Verifiche, spiegaPunteggio(r.Punteggio, idx, env)...)
+	res.Punteggio = int(score)
+	if res.Punteggio <= 0 {
+		res.Punteggio = 0
+		return res
+	}
+
+	if res.Risparmio, _, err = firstNumber(r.Risparmio, env); err != nil {
+		logRuleError(b, err)
+	}
+	if res.ImportoReale, err = firstText(r.ImportoReale, env); err != nil {
+		logRuleError(b, err)
+	}
+	if calc != nil {
+		res.Dettaglio = calc.Dettaglio
+	}
+	return res
+}
+
+// spiegaPunteggio explains the conditional score cases up to the matched one:
+// the ones skipped show what would raise the score.
+func spiegaPunteggio(cases []models.Regola, matched int, env exprEnv) []models.Condizione {
+	var out []models.Condizione
+	for i, c := range cases {
+		if matched >= 0 && i > matched {
+			break
+		}
+		if c.Se == "" {
+			continue
+		}
+		cond, show, err := spiegaCondizione("punteggio", c.Se, env)
+		if err != nil || !show {
+			continue
+		}
+		if v, _, err := firstNumber([]models.Regola{{Valore: c.Valore}}, env); err == nil {
+			if cond.Superata {
+				cond.Motivo += fmt.Sprintf(" — compatibilità %d%%", int(v))
+			} else {
+				cond.Motivo += fmt.Sprintf(" — con questo requisito la compatibilità sarebbe %d%%", int(v))
+			}
+		}
+		out = append(out, cond)
+	}
+	return out
+}
+
+// buildEnv creates the evaluation environment: it runs the rule's calculator,
+// if any, then computes the rule variables in order.
+func buildEnv(r *models.RegoleBonus, p models.UserProfile, b models.Bonus) (exprEnv, *models.CalcoloImporto, error) {
+	env := profileEnv(p, b)
+	var calc *models.CalcoloImporto
+	if r.Calcolatore != "" {
+		f, ok := calcolatori[r.Calcolatore]
+		if !ok {
+			return nil, nil, fmt.Errorf("calcolatore sconosciuto %q", r.Calcolatore)
+		}
+		c := f(p)
+		calc = &c
+		env["importo_mensile"] = c.Mensile
+		env["importo_annuo"] = c.Annuale
+	}
+	for _, v := range r.Variabili {
+		n, err := compileExpr(v.Espr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("variabile %s: %w", v.Nome, err)
+		}
+		val, err := n.eval(env)
+		if err != nil {
+			return nil, nil, fmt.Errorf("variabile %s: %w", v.Nome, err)
+		}
+		env[v.Nome] = val
+	}
+	return env, calc, nil
+}
+
+func evalBool(src string, env exprEnv) (bool, error) {
+	n, err := compileExpr(src)
+	if err != nil {
+		return false, err
+	}
+	v, err := n.eval(env)
+	if err != nil {
+		return false, err
+	}
+	return truthy(v), nil
+}
+
+// firstNumber returns the value of the first case whose condition holds.
+func firstNumber(cases []models.Regola, env exprEnv) (float64, int, error) {
+	for i, c := range cases {
+		if c.Se != "" {
+			ok, err := evalBool(c.Se, env)
+			if err != nil {
+				return 0, -1, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		n, err := compileExpr(c.Valore)
+		if err != nil {
+			return 0, -1, err
+		}
+		v, err := n.eval(env)
+		if err != nil {
+			return 0, -1, err
+		}
+		f, err := toNum(v)
+		if err != nil {
+			return 0, -1, err
+		}
+		return f, i, nil
+	}
+	return 0, -1, nil
+}
+
+// firstText renders the template of the first case whose condition holds.
+func firstText(cases []models.Regola, env exprEnv) (string, error) {
+	for _, c := range cases {
+		if c.Se != "" {
+			ok, err := evalBool(c.Se, env)
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				continue
+			}
+		}
+		tpl, err := compileTemplate(c.Valore)
+		if err != nil {
+			return "", err
+		}
+		return tpl.render(env)
+	}
+	return "", nil
+}
+
+func logRuleError(b models.Bonus, err error) {
+	logger.Warn("matcher: rule evaluation failed", map[string]interface{}{
+		"bonus_id": b.ID, "error": err.Error(),
+	})
+}
+
+// ValidateRules checks that every expression of r parses and only references
+// known profile fields or previously declared variables.
+func ValidateRules(r *models.RegoleBonus) error {
+	if r == nil {
+		return nil
+	}
+	known := make(map[string]bool)
+	for k := range profileEnv(models.UserProfile{}, models.Bonus{}) {
+		known[k] = true
+	}
+	if r.Calcolatore != "" {
+		if _, ok := calcolatori[r.Calcolatore]; !ok {
+			return fmt.Errorf("calcolatore: %q sconosciuto", r.Calcolatore)
+		}
+		for _, id := range identCalcolatore {
+			known[id] = true
+		}
+	}
+
+	checkExpr := func(where, src string) error {
+		n, err := compileExpr(src)
+		if err != nil {
+			return fmt.Errorf("%s: %w", where, err)
+		}
+		for _, id := range exprIdents(n) {
+			if !known[id] {
+				return fmt.Errorf("%s: campo sconosciuto %q", where, id)
+			}
+		}
+		return nil
+	}
+
+	for i, v := range r.Variabili {
+		if v.Nome == "" {
+			return fmt.Errorf("variabili[%d]: nome mancante", i)
+		}
+		if err := checkExpr(fmt.Sprintf("variabili[%d]", i), v.Espr); err != nil {
+			return err
+		}
+		known[v.Nome] = true
+	}
+	for i, c := range r.Idoneita {
+		if err := checkExpr(fmt.Sprintf("idoneita[%d]", i), c); err != nil {
+			return err
+		}
+	}
+	if len(r.Punteggio) == 0 {
+		return fmt.Errorf("punteggio: almeno una regola richiesta")
+	}
+	numeric := []struct {
+		name  string
+		cases []models.Regola
+	}{{"punteggio", r.Punteggio}, {"risparmio", r.Risparmio}}
+	for _, group := range numeric {
+		for i, c := range group.cases {
+			where := fmt.Sprintf("%s[%d]", group.name, i)
+			if c.Se != "" {
+				if err := checkExpr(where+".se", c.Se); err != nil {
+					return err
+				}
+			}
+			if err := checkExpr(where+".valore", c.Valore); err != nil {
+				return err
+			}
+		}
+	}
+	for i, c := range r.ImportoReale {
+		where := fmt.Sprintf("importo_reale[%d]", i)
+		if c.Se != "" {
+			if err := checkExpr(where+".se", c.Se); err != nil {
+				return err
+			}
+		}
+		tpl, err := compileTemplate(c.Valore)
+		if err != nil {
+			return fmt.Errorf("%s.valore: %w", where, err)
+		}
+		for _, id := range tpl.idents() {
+			if !known[id] {
+				return fmt.Errorf("%s.valore: campo sconosciuto %q", where, id)
+			}
+		}
+	}
+	return nil
+}
+
+// regoleRegionali returns the default rules for a regional bonus of the given category.
+// I bonus regionali sono esclusi se l'ISEE supera la soglia del bando.
+func regoleRegionali(categoria string) *models.RegoleBonus {
+	idoneita := []string{"soglia_isee == 0 || isee == 0 || isee <= soglia_isee"}
+	switch categoria {
+	case "famiglia":
+		return &models.RegoleBonus{
+			Idoneita:  append(idoneita, "numero_figli > 0 || figli_minorenni > 0 || figli_under3 > 0"),
+			Punteggio: []models.Regola{{Valore: "75"}},
+			Risparmio: []models.Regola{{Valore: "800"}},
+		}
+	case "istruzione":
+		return &models.RegoleBonus{
+			// Studenti o figli in età scolare
+			Idoneita:  append(idoneita, "studente || conta_figli(6, 19) > 0"),
+			Punteggio: []models.Regola{{Valore: "70"}},
+			Risparmio: []models.Regola{{Valore: "200"}},
+		}
+	case "casa":
+		return &models.RegoleBonus{
+			Idoneita:  append(idoneita, "affittuario || prima_abitazione"),
+			Punteggio: []models.Regola{{Valore: "70"}},
+			Risparmio: []models.Regola{{Valore: "2000"}},
+		}
+	case "trasporti":
+		// Studenti, giovani, over 65 o ISEE medio-basso
+		return &models.RegoleBonus{
+			Idoneita:  append(idoneita, "studente || eta < 26 || over65 > 0 || (isee > 0 && isee <= 30000)"),
+			Punteggio: []models.Regola{{Valore: "65"}},
+			Risparmio: []models.Regola{{Valore: "400"}},
+		}
+	}
+	return &models.RegoleBonus{
+		Idoneita:  idoneita,
+		Punteggio: []models.Regola{{Valore: "50"}},
+		Risparmio: []models.Regola{{Valore: "300"}},
+	}
+}
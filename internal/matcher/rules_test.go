@@ -0,0 +1,328 @@
+package matcher
+
+import (
+	"bonusperme/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompileExpr_Valutazione(t *testing.T) {
+	env := exprEnv{"isee": 12000.0, "numero_figli": 2.0, "occupazione": "Dipendente", "affittuario": true}
+	cases := []struct {
+		src  string
+		want exprValue
+	}{
+		{"isee > 0 && isee <= 17000", true},
+		{"2400 * numero_figli", 4800.0},
+		{`occupazione == "dipendente"`, true},
+		{"!affittuario || numero_figli >= 3", false},
+		{"numero_figli >= 3 ? 1 : 2", 2.0},
+		{"arrotonda(10 / 3, 2)", 3.33},
+		{"max(1, min(5, 3))", 3.0},
+		{"-numero_figli + 1", -1.0},
+	}
+	for _, c := range cases {
+		n, err := compileExpr(c.src)
+		if err != nil {
+			t.Fatalf("%s: errore di compilazione: %v", c.src, err)
+		}
+		got, err := n.eval(env)
+		if err != nil {
+			t.Fatalf("%s: errore di valutazione: %v", c.src, err)
+		}
+		if got != c.want {
+			t.Errorf("%s: atteso %v, ottenuto %v", c.src, c.want, got)
+		}
+	}
+}
+
+func TestTokenize_NonASCII(t *testing.T) {
+	toks, err := tokenize("età > 18")
+	if err != nil {
+		t.Fatalf("errore inatteso: %v", err)
+	}
+	if len(toks) != 4 || toks[0].text != "età" || toks[1].text != ">" {
+		t.Errorf("token inattesi: %+v", toks)
+	}
+	if _, err := tokenize("isee § 1"); err == nil {
+		t.Error("atteso errore per carattere non valido")
+	}
+	r := &models.RegoleBonus{
+		Idoneita:  []string{"età > 18"},
+		Punteggio: []models.Regola{{Valore: "50"}},
+	}
+	if err := ValidateRules(r); err == nil || !strings.Contains(err.Error(), "età") {
+		t.Errorf("atteso errore di campo sconosciuto, ottenuto %v", err)
+	}
+}
+
+func TestValidateRules_Catalogo(t *testing.T) {
+	for _, b := range GetAllBonusWithRegional() {
+		if b.Regole == nil {
+			t.Errorf("%s: regole mancanti", b.ID)
+			continue
+		}
+		if err := ValidateRules(b.Regole); err != nil {
+			t.Errorf("%s: %v", b.ID, err)
+		}
+	}
+}
+
+func TestValidateRules_CampoSconosciuto(t *testing.T) {
+	r := &models.RegoleBonus{
+		Idoneita:  []string{"figli > 0"},
+		Punteggio: []models.Regola{{Valore: "50"}},
+	}
+	err := ValidateRules(r)
+	if err == nil || !strings.Contains(err.Error(), "idoneita[0]") {
+		t.Errorf("atteso errore su idoneita[0], ottenuto %v", err)
+	}
+}
+
+func TestValutaRegole_AssegnoUnico(t *testing.T) {
+	var au models.Bonus
+	for _, b := range GetAllBonus() {
+		if b.ID == "assegno-unico" {
+			au = b
+		}
+	}
+	p := models.UserProfile{NumeroFigli: 2, FigliMinorenni: 2, ISEE: 15000,
+		Componenti: []models.Componente{{Relazione: models.RelazioneFiglio, Eta: anni(0)}, {Relazione: models.RelazioneFiglio, Eta: anni(6)}}}
+	res := valutaRegole(au, p)
+	if res.Punteggio != 98 {
+		t.Errorf("punteggio atteso 98, ottenuto %d", res.Punteggio)
+	}
+	// 201 + 100,50 (sotto 1 anno) + 201
+	if res.Risparmio != 6030 {
+		t.Errorf("risparmio atteso 6030, ottenuto %.2f", res.Risparmio)
+	}
+	if want := "€502.50/mese (€6030.00/anno)"; res.ImportoReale != want {
+		t.Errorf("importo atteso %q, ottenuto %q", want, res.ImportoReale)
+	}
+	if len(res.Dettaglio) != 3 {
+		t.Errorf("attese 3 voci di dettaglio, ottenute %+v", res.Dettaglio)
+	}
+	if res := valutaRegole(au, models.UserProfile{ISEE: 15000}); res.Punteggio != 0 {
+		t.Errorf("senza figli il bonus non deve risultare idoneo (punteggio %d)", res.Punteggio)
+	}
+	// A 30-year-old child without disability is not entitled to the allowance
+	adulto := models.UserProfile{NumeroFigli: 1, ISEE: 15000,
+		Componenti: []models.Componente{{Relazione: models.RelazioneFiglio, Eta: anni(30)}}}
+	if res := valutaRegole(au, adulto); res.Punteggio != 0 || res.Mancanti != 1 {
+		t.Errorf("figlio di 30 anni: atteso escluso, ottenuto punteggio %d (%q)", res.Punteggio, res.ImportoReale)
+	}
+}
+
+func TestValutaRegole_ErroreEspressione(t *testing.T) {
+	b := models.Bonus{ID: "test", Regole: &models.RegoleBonus{
+		Idoneita:  []string{"isee >"},
+		Punteggio: []models.Regola{{Valore: "50"}},
+	}}
+	if res := valutaRegole(b, models.UserProfile{ISEE: 1000}); res.Punteggio != 0 {
+		t.Errorf("una regola non valida non deve produrre un match (punteggio %d)", res.Punteggio)
+	}
+}
+
+func TestValutaRegole_CondizioneProtetta(t *testing.T) {
+	b := models.Bonus{ID: "test", Regole: &models.RegoleBonus{
+		Idoneita:  []string{"numero_figli > 0", "isee / numero_figli <= 10000"},
+		Punteggio: []models.Regola{{Valore: "50"}},
+	}}
+	res := valutaRegole(b, models.UserProfile{ISEE: 15000})
+	if res.Punteggio != 0 || res.Mancanti != 2 {
+		t.Errorf("senza figli attesi 2 requisiti mancanti, ottenuto %+v", res)
+	}
+	if len(res.Verifiche) == 0 || res.Verifiche[0].Superata {
+		t.Errorf("il requisito sui figli deve essere spiegato: %+v", res.Verifiche)
+	}
+	if res := valutaRegole(b, models.UserProfile{ISEE: 15000, NumeroFigli: 2}); res.Punteggio != 50 {
+		t.Errorf("con due figli il bonus deve risultare idoneo (punteggio %d)", res.Punteggio)
+	}
+}
+
+func TestMatchBonus_Verifiche(t *testing.T) {
+	p := models.UserProfile{Eta: 35, NumeroFigli: 2, FigliMinorenni: 2, ISEE: 20000}
+	for _, b := range MatchBonus(p).Bonus {
+		if b.ID != "assegno-unico" {
+			continue
+		}
+		var punteggio *models.Condizione
+		for i, c := range b.Verifiche {
+			if c.Tipo == "punteggio" {
+				punteggio = &b.Verifiche[i]
+			}
+		}
+		if punteggio == nil || punteggio.Superata || punteggio.Campo != "isee" {
+			t.Fatalf("attesa verifica di punteggio non superata su isee, ottenuto %+v", b.Verifiche)
+		}
+		if !strings.Contains(punteggio.Motivo, "98%") {
+			t.Errorf("il motivo dovrebbe indicare il punteggio raggiungibile: %q", punteggio.Motivo)
+		}
+		return
+	}
+	t.Fatal("Assegno Unico mancante")
+}
+
+func TestAlmostEligible_ADI(t *testing.T) {
+	p := models.UserProfile{Eta: 40, FigliMinorenni: 1, NumeroFigli: 1, ISEE: 12000}
+	for _, b := range AlmostEligible(p) {
+		if b.ID != "adi" {
+			continue
+		}
+		for _, c := range b.Verifiche {
+			if !c.Superata {
+				if c.Campo != "isee" || c.Operatore != "<=" || c.Soglia != 9360.0 {
+					t.Errorf("requisito mancante inatteso: %+v", c)
+				}
+				if c.Motivo != "Non soddisfatto: ISEE ≤ €9.360 (tuo valore: €12.000)" {
+					t.Errorf("motivo inatteso: %q", c.Motivo)
+				}
+				return
+			}
+		}
+		t.Fatalf("nessun requisito mancante per ADI: %+v", b.Verifiche)
+	}
+	t.Fatal("ADI dovrebbe comparire tra i quasi idonei con ISEE 12.000")
+}
+
+func TestFormattaNumero(t *testing.T) {
+	cases := map[float64]string{0: "0", 999: "999", 9360: "9.360", 15748.78: "15.748,78", 1234567.5: "1.234.567,50"}
+	for n, want := range cases {
+		if got := formattaNumero(n); got != want {
+			t.Errorf("formattaNumero(%v) = %q, atteso %q", n, got, want)
+		}
+	}
+}
+
+// anni returns a pointer to an age, for Componente literals.
+func anni(n int) *int { return &n }
+
+func TestNucleo_Componenti(t *testing.T) {
+	anno := time.Now().Year()
+	p := models.UserProfile{Eta: 40, Componenti: []models.Componente{
+		{Relazione: models.RelazioneRichiedente, Eta: anni(40)},
+		{Relazione: models.RelazioneFiglio, Eta: anni(1)},
+		{Relazione: models.RelazioneFiglio, AnnoNascita: anno - 8, Studente: true},
+		{Relazione: models.RelazioneFiglio, Eta: anni(19), Disabilita: models.DisabilitaMedia},
+		{Relazione: models.RelazioneGenitore, Eta: anni(72)},
+	}}
+	p.NormalizeHousehold()
+	if p.NumeroFigli != 3 || p.FigliMinorenni != 2 || p.FigliUnder3 != 1 || p.Over65 != 1 || !p.Disabilita {
+		t.Errorf("contatori derivati errati: %+v", p)
+	}
+	if p.NuovoNato2025 {
+		t.Error("nessun figlio nato quest'anno")
+	}
+	for _, c := range []models.Componente{{Eta: anni(0)}, {AnnoNascita: anno}} {
+		c.Relazione = models.RelazioneFiglio
+		n := models.UserProfile{Componenti: []models.Componente{c}}
+		n.NormalizeHousehold()
+		if !n.NuovoNato2025 || n.FigliUnder3 != 1 {
+			t.Errorf("%+v: atteso nuovo nato, ottenuto %+v", c, n)
+		}
+	}
+	// Without age or year of birth a child is not counted as newborn or under 3
+	ignota := models.UserProfile{Componenti: []models.Componente{{Relazione: models.RelazioneFiglio}}}
+	ignota.NormalizeHousehold()
+	if ignota.NumeroFigli != 1 || ignota.FigliUnder3 != 0 || ignota.FigliMinorenni != 0 || ignota.NuovoNato2025 {
+		t.Errorf("figlio di eta ignota: %+v", ignota)
+	}
+	if m, _ := nucleo(ignota); len(m) != 0 {
+		t.Errorf("figlio di eta ignota nel nucleo: %+v", m)
+	}
+	env := profileEnv(p, models.Bonus{})
+	cases := map[string]float64{
+		"conta_figli(6, 14)":           1,
+		"conta_figli(0, 99)":           3,
+		"conta_figli_studenti(6, 14)":  1,
+		"conta_figli_disabili(18, 20)": 1,
+		"conta_componenti(65, 120)":    1,
+	}
+	for src, want := range cases {
+		n, err := compileExpr(src)
+		if err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		if got, err := n.eval(env); err != nil || got != want {
+			t.Errorf("%s: atteso %v, ottenuto %v (%v)", src, want, got, err)
+		}
+	}
+}
+
+func TestMatchBonus_Provincia(t *testing.T) {
+	base := models.UserProfile{Eta: 35, Residenza: "Trentino-Alto Adige", NumeroFigli: 1, FigliMinorenni: 1, ISEE: 20000}
+	trovato := func(p models.UserProfile, id string) bool {
+		for _, b := range MatchBonus(p).Bonus {
+			if b.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	bz := base
+	bz.Comune = "Bolzano"
+	if !trovato(bz, "familiengeld-bolzano") || trovato(bz, "assegno-unico-trento") {
+		t.Error("residente a Bolzano: atteso solo il bonus della provincia di Bolzano")
+	}
+	tn := base
+	tn.Provincia = "TN"
+	if trovato(tn, "familiengeld-bolzano") || !trovato(tn, "assegno-unico-trento") {
+		t.Error("residente in provincia di Trento: atteso solo il bonus della provincia di Trento")
+	}
+	if trovato(base, "familiengeld-bolzano") {
+		t.Error("senza comune né provincia i bonus provinciali non sono verificabili")
+	}
+}
+
+func TestMatchBonus_RegioneAlias(t *testing.T) {
+	for _, residenza := range []string{"Friuli Venezia Giulia", "Friuli-Venezia Giulia", "IT-36"} {
+		p := models.UserProfile{Eta: 35, Residenza: residenza, NumeroFigli: 1, FigliMinorenni: 1, ISEE: 20000}
+		found := false
+		for _, b := range MatchBonus(p).Bonus {
+			if b.ID == "carta-famiglia-fvg" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("residenza %q: Carta Famiglia FVG non trovata", residenza)
+		}
+	}
+}
+
+func TestValutaRegole_VarianteISEE(t *testing.T) {
+	var nascita models.Bonus
+	for _, b := range GetAllBonus() {
+		if b.ID == "bonus-nascita" {
+			nascita = b
+		}
+	}
+	if nascita.TipoISEE != models.ISEEMinorenni {
+		t.Fatalf("il bonus nascita deve usare l'ISEE minorenni, trovato %q", nascita.TipoISEE)
+	}
+	p := models.UserProfile{NuovoNato2025: true, ISEE: 52000}
+	if res := valutaRegole(nascita, p); res.Punteggio != 0 {
+		t.Error("con ISEE ordinario oltre soglia e senza ISEE minorenni il bonus non spetta")
+	}
+	p.ISEEMinorenni = 31000
+	if res := valutaRegole(nascita, p); res.Punteggio == 0 {
+		t.Error("con ISEE minorenni sotto soglia il bonus deve spettare")
+	}
+	p.ISEEMinorenni = 0
+	p.ISEECorrente = 30000
+	if res := valutaRegole(nascita, p); res.Punteggio == 0 {
+		t.Error("senza ISEE minorenni deve valere l'ISEE corrente")
+	}
+
+	p = models.UserProfile{NuovoNato2025: true, ISEE: 30000, ISEEMinorenni: 45000}
+	res := valutaRegole(nascita, p)
+	if res.Mancanti != 1 {
+		t.Fatalf("atteso un requisito mancante, ottenuti %d", res.Mancanti)
+	}
+	for _, c := range res.Verifiche {
+		if !c.Superata && !strings.Contains(c.Motivo, "ISEE minorenni ≤ €40.000") {
+			t.Errorf("il motivo deve indicare la variante di ISEE: %q", c.Motivo)
+		}
+	}
+}
@@ -6,6 +6,7 @@ type UserProfile struct {
 	Eta              int     `json:"eta"`
 	Residenza        string  `json:"residenza"`
 	Comune           string  `json:"comune"`
+	Provincia        string  `json:"provincia,omitempty"` // sigla, name or ISTAT code; derived from Comune when known
 	StatoCivile      string  `json:"stato_civile"`
 	Occupazione      string  `json:"occupazione"`
 	NumeroFigli      int     `json:"numero_figli"`
@@ -19,8 +20,160 @@ type UserProfile struct {
 	PrimaAbitazione  bool    `json:"prima_abitazione"`
 	RistrutturazCasa bool    `json:"ristrutturaz_casa"`
 	Studente         bool    `json:"studente"`
-	NuovoNato2025    bool    `json:"nuovo_nato_2025"`
-	ISEESimulato     float64 `json:"isee_simulato,omitempty"`
+	// NuovoNato2025 is a child born or adopted in the current year; the name
+	// is kept for API compatibility.
+	NuovoNato2025 bool    `json:"nuovo_nato_2025"`
+	ISEESimulato  float64 `json:"isee_simulato,omitempty"`
+	// TipoISEESimulato is the ISEE variant replaced by ISEESimulato (default ordinario).
+	TipoISEESimulato string `json:"tipo_isee_simulato,omitempty"`
+
+	// Varianti dell'ISEE (facoltative); ISEE è l'ordinario
+	ISEEMinorenni      float64 `json:"isee_minorenni,omitempty"`
+	ISEEUniversitario  float64 `json:"isee_universitario,omitempty"`
+	ISEESociosanitario float64 `json:"isee_sociosanitario,omitempty"`
+	ISEECorrente       float64 `json:"isee_corrente,omitempty"`
+
+	// Componenti is the optional per-member detail of the household. When set,
+	// NumeroFigli, FigliMinorenni, FigliUnder3, Over65 and Disabilita are
+	// derived from it (see NormalizeHousehold).
+	Componenti       []Componente `json:"componenti,omitempty"`
+	GenitoriOccupati bool         `json:"genitori_occupati,omitempty"`
+	MadreUnder21     bool         `json:"madre_under21,omitempty"`
+
+	// Date degli eventi personali (AAAA-MM-GG, facoltative) da cui decorrono
+	// termini come "entro 60 giorni dalla nascita"
+	DataNascitaFiglio    string `json:"data_nascita_figlio,omitempty"` // nascita o adozione
+	DataContrattoAffitto string `json:"data_contratto_affitto,omitempty"`
+	DataRogito           string `json:"data_rogito,omitempty"`
+	DataInizioLavori     string `json:"data_inizio_lavori,omitempty"`
+}
+
+// Varianti dell'ISEE (DPCM 159/2013).
+const (
+	ISEEOrdinario      = "ordinario"
+	ISEEMinorenni      = "minorenni"
+	ISEEUniversitario  = "universitario"
+	ISEESociosanitario = "sociosanitario"
+	ISEECorrente       = "corrente"
+)
+
+// TipiISEE lists the accepted ISEE variants.
+var TipiISEE = []string{ISEEOrdinario, ISEEMinorenni, ISEEUniversitario, ISEESociosanitario, ISEECorrente}
+
+// ISEEFor returns the ISEE value to use for a bonus requiring the given
+// variant. A missing variant falls back to the ISEE corrente, which updates
+// the ordinary one after an income drop, and then to the ordinary ISEE.
+func (p UserProfile) ISEEFor(tipo string) float64 {
+	var v float64
+	switch tipo {
+	case ISEEMinorenni:
+		v = p.ISEEMinorenni
+	case ISEEUniversitario:
+		v = p.ISEEUniversitario
+	case ISEESociosanitario:
+		v = p.ISEESociosanitario
+	}
+	if v > 0 {
+		return v
+	}
+	if p.ISEECorrente > 0 {
+		return p.ISEECorrente
+	}
+	return p.ISEE
+}
+
+// SetISEE sets the value of the given ISEE variant ("" is the ordinary ISEE).
+func (p *UserProfile) SetISEE(tipo string, v float64) {
+	switch tipo {
+	case ISEEMinorenni:
+		p.ISEEMinorenni = v
+	case ISEEUniversitario:
+		p.ISEEUniversitario = v
+	case ISEESociosanitario:
+		p.ISEESociosanitario = v
+	case ISEECorrente:
+		p.ISEECorrente = v
+	default:
+		p.ISEE = v
+	}
+}
+
+// Relazioni ammesse per i componenti del nucleo.
+const (
+	RelazioneRichiedente = "richiedente"
+	RelazioneConiuge     = "coniuge"
+	RelazioneFiglio      = "figlio"
+	RelazioneGenitore    = "genitore"
+	RelazioneAltro       = "altro"
+)
+
+// Gradi di disabilità ammessi per i componenti del nucleo.
+const (
+	DisabilitaMedia              = "media"
+	DisabilitaGrave              = "grave"
+	DisabilitaNonAutosufficiente = "non_autosufficiente"
+)
+
+// Componente is a member of the household.
+type Componente struct {
+	// Eta is the age in years, nil when not given; AnnoNascita takes
+	// precedence when set.
+	Eta         *int   `json:"eta,omitempty"`
+	AnnoNascita int    `json:"anno_nascita,omitempty"`
+	Relazione   string `json:"relazione"`
+	// Disabilita is the disability degree: "", "media", "grave", "non_autosufficiente".
+	Disabilita string `json:"disabilita,omitempty"`
+	// Studente is true for members in school, university or training.
+	Studente bool `json:"studente,omitempty"`
+}
+
+// AgeIn returns the age of the member in the given year; ok is false when
+// neither Eta nor AnnoNascita is set.
+func (c Componente) AgeIn(anno int) (eta int, ok bool) {
+	if c.AnnoNascita > 0 {
+		return anno - c.AnnoNascita, true
+	}
+	if c.Eta != nil {
+		return *c.Eta, true
+	}
+	return 0, false
+}
+
+// NormalizeHousehold derives the aggregate household counters from Componenti,
+// so that rules written against the counters keep working. Profiles without
+// Componenti are left unchanged.
+func (p *UserProfile) NormalizeHousehold() {
+	if len(p.Componenti) == 0 {
+		return
+	}
+	anno := time.Now().Year()
+	p.NumeroFigli, p.FigliMinorenni, p.FigliUnder3, p.Over65 = 0, 0, 0, 0
+	for _, c := range p.Componenti {
+		eta, ok := c.AgeIn(anno)
+		if c.Disabilita != "" {
+			p.Disabilita = true
+		}
+		if c.Relazione == RelazioneFiglio {
+			p.NumeroFigli++
+			// A member of unknown age is left out of the age-based counters
+			if !ok {
+				continue
+			}
+			if eta < 18 {
+				p.FigliMinorenni++
+			}
+			if eta < 3 {
+				p.FigliUnder3++
+			}
+			// Born this year, by year of birth or entered with age 0
+			if eta == 0 {
+				p.NuovoNato2025 = true
+			}
+		}
+		if c.Relazione != RelazioneRichiedente && ok && eta >= 65 {
+			p.Over65++
+		}
+	}
 }
 
 type FAQ struct {
@@ -43,6 +196,18 @@ type Bonus struct {
 	Importo              string               `json:"importo"`
 	ImportoReale         string               `json:"importo_reale,omitempty"`
 	Scadenza             string               `json:"scadenza"`
+	// Termine is the structured form of Scadenza, evaluated by package
+	// deadline. When the catalogue does not set it, it is derived from Scadenza.
+	Termine              *Termine             `json:"termine,omitempty"`
+	// ScadenzaPersonale is set by the matcher: the deadline for the profile.
+	ScadenzaPersonale    *ScadenzaPersonale   `json:"scadenza_personale,omitempty"`
+	Fondi                *Fondi               `json:"fondi,omitempty"`
+	Relazioni            *Relazioni           `json:"relazioni,omitempty"`
+	// Set by the matcher when the bonus cannot be claimed together with the
+	// others: Conflitti lists the matched bonuses it excludes.
+	Conflitti            []string             `json:"conflitti,omitempty"`
+	FuoriCombinazione    bool                 `json:"fuori_combinazione,omitempty"`
+	NotaCombinazione     string               `json:"nota_combinazione,omitempty"`
 	Scaduto              bool                 `json:"scaduto"`
 	Requisiti            []string             `json:"requisiti"`
 	ComeRichiederlo      []string             `json:"come_richiederlo"`
@@ -58,7 +223,12 @@ type Bonus struct {
 	FonteNome            string               `json:"fonte_nome,omitempty"`
 	RiferimentiNormativi []string             `json:"riferimenti_normativi,omitempty"`
 	RegioniApplicabili        []string             `json:"regioni,omitempty"`
+	// ISTAT codes of the municipalities (6 digits) and provinces (3 digits) a
+	// local bonus applies to; empty means the whole region.
+	ComuniApplicabili         []string             `json:"comuni,omitempty"`
+	ProvinceApplicabili       []string             `json:"province,omitempty"`
 	SogliaISEE                float64              `json:"soglia_isee,omitempty"`
+	TipoISEE                  string               `json:"tipo_isee,omitempty"` // ISEE variant used by the bonus, default ordinario
 	LinkRicerca               string               `json:"link_ricerca,omitempty"`
 	LinkVerificato            bool                  `json:"link_verificato"`
 	LinkVerificatoAl          string               `json:"link_verificato_al,omitempty"`
@@ -72,6 +242,129 @@ type Bonus struct {
 	StatoValidita             string               `json:"stato_validita,omitempty"`
 	MotivoStato               string               `json:"motivo_stato,omitempty"`
 	Traduzioni                map[string]BonusTrad `json:"traduzioni,omitempty"`
+	Regole                    *RegoleBonus         `json:"regole,omitempty"`
+	Verifiche                 []Condizione         `json:"verifiche,omitempty"`
+	DettaglioImporto          []VoceImporto        `json:"dettaglio_importo,omitempty"`
+}
+
+// Termine is a deadline to apply for a bonus. Tipo selects the fields used:
+//
+//	data_fissa         Chiusura
+//	finestra           Apertura and/or Chiusura
+//	da_evento          Giorni after Evento ("60 giorni dalla nascita"), or
+//	                   ChiusuraAnnua of Anni years after the year of Evento
+//	annuale            ChiusuraAnnua every year, optionally from AperturaAnnua
+//	bando_annuale      a yearly call, with AperturaAnnua/ChiusuraAnnua when known
+//	esaurimento_fondi  open until the funds run out; Esaurito once they have
+//	permanente         no deadline
+//
+// Dates are YYYY-MM-DD, yearly dates MM-DD.
+type Termine struct {
+	Tipo          string `json:"tipo"`
+	Apertura      string `json:"apertura,omitempty"`
+	Chiusura      string `json:"chiusura,omitempty"`
+	AperturaAnnua string `json:"apertura_annua,omitempty"`
+	ChiusuraAnnua string `json:"chiusura_annua,omitempty"`
+	Giorni        int    `json:"giorni,omitempty"`
+	Anni          int    `json:"anni,omitempty"`
+	Evento        string `json:"evento,omitempty"`
+	Esaurito      bool   `json:"esaurito,omitempty"`
+}
+
+// RegoleBonus describes eligibility, score and amounts of a bonus as data.
+// Every string is an expression over UserProfile fields (see matcher/expr.go),
+// so rules can be changed without touching Go code.
+type RegoleBonus struct {
+	// Variabili are computed in order and can be referenced by later expressions.
+	Variabili []Variabile `json:"variabili,omitempty"`
+	// Idoneita lists conditions that must all be true for the bonus to match.
+	Idoneita []string `json:"idoneita,omitempty"`
+	// Punteggio, Risparmio and ImportoReale are evaluated first-match-wins.
+	Punteggio    []Regola `json:"punteggio"`
+	Risparmio    []Regola `json:"risparmio,omitempty"`
+	ImportoReale []Regola `json:"importo_reale,omitempty"`
+	// Calcolatore names a built-in amount calculator (e.g. "assegno_unico"); its
+	// results are available to expressions as importo_mensile and importo_annuo.
+	Calcolatore string `json:"calcolatore,omitempty"`
+}
+
+// Variabile is a named intermediate value of a rule.
+type Variabile struct {
+	Nome string `json:"nome"`
+	Espr string `json:"espr"`
+}
+
+// Regola is a conditional case: when Se is empty or true, the result is Valore.
+// For ImportoReale, Valore is a text template with {expr} placeholders.
+type Regola struct {
+	Se     string `json:"se,omitempty"`
+	Valore string `json:"valore"`
+}
+
+// VoceImporto is a line of an amount breakdown.
+type VoceImporto struct {
+	Voce    string  `json:"voce"`
+	Importo float64 `json:"importo"`
+}
+
+// CalcoloImporto is the result of a built-in amount calculator.
+type CalcoloImporto struct {
+	Mensile   float64       `json:"mensile"`
+	Annuale   float64       `json:"annuale"`
+	Dettaglio []VoceImporto `json:"dettaglio"`
+}
+
+// Condizione is a rule condition evaluated against a profile, used to explain
+// why a bonus matched with a given score or was excluded.
+type Condizione struct {
+	// Tipo is "requisito" for eligibility conditions, "punteggio" for score cases.
+	Tipo      string      `json:"tipo"`
+	Campo     string      `json:"campo"`
+	Operatore string      `json:"operatore,omitempty"`
+	Soglia    interface{} `json:"soglia,omitempty"`
+	Valore    interface{} `json:"valore,omitempty"`
+	Superata  bool        `json:"superata"`
+	Motivo    string      `json:"motivo"`
+}
+
+// Fondi is the funding status of a bonus paid until its budget runs out,
+// often on a click day. It is fed by the data sources or set by an admin.
+type Fondi struct {
+	Stanziati  float64   `json:"stanziati,omitempty"`  // euro; zero when not published
+	Utilizzati float64   `json:"utilizzati,omitempty"` // euro already booked, when published
+	Apertura   time.Time `json:"apertura,omitzero"`
+	Chiusura   time.Time `json:"chiusura,omitzero"`
+	ClickDay   bool      `json:"click_day,omitempty"`
+	// Esauriti is set when the managing body declares the funds exhausted.
+	Esauriti   bool      `json:"esauriti,omitempty"`
+	Fonte      string    `json:"fonte,omitempty"` // datasource id, or "admin"
+	Aggiornato time.Time `json:"aggiornato,omitzero"`
+}
+
+// Relazioni declares, by catalogue id, how a bonus combines with the others.
+// An exclusion holds both ways: declaring it on one of the two is enough.
+type Relazioni struct {
+	Richiede []string    `json:"richiede,omitempty"` // claimable only together with these
+	Esclude  []string    `json:"esclude,omitempty"`  // not cumulable with these
+	Riduce   []Riduzione `json:"riduce,omitempty"`
+}
+
+// Riduzione lowers the amount of another bonus when both are claimed: the
+// other bonus loses up to ImportoMassimo euro a year, and never more than
+// this bonus is worth. Zero ImportoMassimo means no cap.
+type Riduzione struct {
+	Bonus          string  `json:"bonus"`
+	ImportoMassimo float64 `json:"importo_massimo,omitempty"`
+	Nota           string  `json:"nota,omitempty"`
+}
+
+// ScadenzaPersonale is the concrete deadline of a matched bonus for the
+// user: the closing day, counted from the user's event when the deadline
+// depends on one.
+type ScadenzaPersonale struct {
+	Data           string `json:"data"`             // AAAA-MM-GG
+	GiorniMancanti int    `json:"giorni_mancanti"`  // negative once passed
+	Evento         string `json:"evento,omitempty"` // nascita, contratto, rogito, lavori
 }
 
 type MatchResult struct {
@@ -82,6 +375,7 @@ type MatchResult struct {
 	PersoFinora      string  `json:"perso_finora,omitempty"`
 	Bonus            []Bonus   `json:"bonus"`
 	Avvisi           []Avviso  `json:"avvisi,omitempty"`
+	QuasiIdonei      []Bonus   `json:"quasi_idonei,omitempty"`
 }
 
 type Avviso struct {
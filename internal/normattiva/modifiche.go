@@ -0,0 +1,166 @@
+package normattiva
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Modifica is an amendment or repeal made by a newly published act.
+type Modifica struct {
+	Riferimento        // the amended act, article and comma
+	Abrogazione bool   `json:"abrogazione,omitempty"`
+	Frase       string `json:"frase"`
+}
+
+var (
+	reModifica = regexp.MustCompile(`(?i)(?:\bsono|(?:^|\s)è)\s+(?:sostituit[oaie]|modificat[oaie]|inserit[oaie]|aggiunt[oaie]|soppress[oaie]|abrogat[oaie]|apportate)\b` +
+		`|\bcessa(?:no)?\s+di\s+avere\s+efficacia`)
+	reAbrogazione = regexp.MustCompile(`(?i)(?:\bsono|(?:^|\s)è)\s+(?:abrogat[oaie]|soppress[oaie])\b|\bcessa(?:no)?\s+di\s+avere\s+efficacia`)
+	// "sono apportate le seguenti modificazioni:" introduces a list of changes
+	reElenco = regexp.MustCompile(`(?i)\bseguenti\s+modificazioni\s*:$`)
+	// Article or comma named by an item of such a list: "a) al comma 177, ..."
+	reArtVoce   = regexp.MustCompile(`(?i)\bart(?:icolo|\.)?\s*(` + numero + `)(?:\s*,?\s*(?:comma|commi)\s*(` + commi + `))?`)
+	reCommaVoce = regexp.MustCompile(`(?i)\b(?:comma|commi)\s*(` + commi + `)`)
+	// The new wording of an amended provision, which may cite other acts
+	reCitazione = regexp.MustCompile(`«[^»]*»|“[^”]*”`)
+)
+
+// Amendments returns the amendments and repeals made by the text of an act.
+// Only provisions that change another act are considered: a reference in a
+// sentence without an amending verb is a mere citation.
+func Amendments(text string) []Modifica {
+	text = reCitazione.ReplaceAllString(text, "«»")
+
+	var out []Modifica
+	var elenco *Riferimento // act introducing a list of changes
+	voci := 0
+	chiudi := func() {
+		// A list whose items name no comma changes the article as a whole
+		if elenco != nil && voci == 0 {
+			out = append(out, Modifica{Riferimento: *elenco, Frase: elenco.Testo})
+		}
+		elenco, voci = nil, 0
+	}
+
+	for _, p := range periodi(text) {
+		modifica := reModifica.MatchString(p.testo)
+		var refs []Riferimento
+		for _, r := range Parse(p.testo) {
+			if !r.Conversione && r.Tipo != Circolare && r.Tipo != Messaggio {
+				refs = append(refs, r)
+			}
+		}
+
+		switch {
+		case len(refs) > 0 && reElenco.MatchString(p.testo):
+			chiudi()
+			r := refs[0]
+			r.Testo = p.testo
+			elenco = &r
+		case len(refs) > 0 && modifica:
+			chiudi()
+			for _, r := range refs {
+				out = append(out, Modifica{Riferimento: r, Abrogazione: reAbrogazione.MatchString(p.testo), Frase: p.testo})
+			}
+		case elenco != nil && modifica:
+			r := *elenco
+			if m := reArtVoce.FindStringSubmatch(p.testo); m != nil && r.Articolo == "" {
+				r.Articolo, r.Comma = norma(m[1]), norma(m[2])
+			} else if m := reCommaVoce.FindStringSubmatch(p.testo); m != nil && r.Articolo != "" && r.Comma == "" {
+				r.Comma = norma(m[1])
+			}
+			out = append(out, Modifica{Riferimento: r, Abrogazione: reAbrogazione.MatchString(p.testo), Frase: p.testo})
+			voci++
+		}
+		if p.fine == '.' {
+			chiudi()
+		}
+	}
+	chiudi()
+	return out
+}
+
+type periodo struct {
+	testo string
+	fine  byte // '.', ';' or ':'
+}
+
+// abbreviazioni are the words whose trailing dot does not end a sentence.
+var abbreviazioni = map[string]bool{
+	"n": true, "art": true, "artt": true, "lett": true, "c": true, "d": true, "l": true,
+	"lgs": true, "p": true, "r": true, "m": true, "g": true, "u": true, "s": true, "ss": true,
+}
+
+// periodi splits text into sentences and into the items of lists. Line
+// breaks are ignored: act texts are often wrapped at a fixed width.
+func periodi(text string) []periodo {
+	var out []periodo
+	start := 0
+	emit := func(end int, fine byte) {
+		if t := strings.Join(strings.Fields(text[start:end]), " "); t != "" {
+			if fine == ':' {
+				t += ":"
+			}
+			out = append(out, periodo{t, fine})
+		}
+		start = end + 1
+	}
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; c {
+		case ';', ':':
+			emit(i, c)
+		case '.':
+			if i+2 < len(text) && isSpace(text[i+1]) && !abbreviazione(text[start:i]) && inizioFrase(text[i+2]) {
+				emit(i, c)
+			}
+		}
+	}
+	emit(len(text), '.')
+	return out
+}
+
+func abbreviazione(prima string) bool {
+	i := strings.LastIndexFunc(prima, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	return abbreviazioni[strings.ToLower(prima[i+1:])]
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\t' || c == '\r'
+}
+
+func inizioFrase(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0xC0 // accented capitals, «
+}
+
+// HTMLText returns the text of an act page, one line per block.
+func HTMLText(body []byte) string {
+	doc, err := html.Parse(strings.NewReader(string(body)))
+	if err != nil {
+		return ""
+	}
+	var sb strings.Builder
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+		case n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style" || n.Data == "nav" || n.Data == "header" || n.Data == "footer"):
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "p", "div", "li", "br", "h1", "h2", "h3", "h4", "tr", "pre":
+				sb.WriteString("\n")
+			}
+		}
+	}
+	visit(doc)
+	return sb.String()
+}
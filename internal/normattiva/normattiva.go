@@ -0,0 +1,411 @@
+// Package normattiva parses the normative references of a bonus ("Legge di
+// Bilancio 2025, art. 1 comma 177", "D.Lgs. 29 dicembre 2021, n. 230") into
+// structured identifiers and links them to Normattiva through URN-NIR, the
+// stable identifiers of Italian legislation. It also reads the text of newly
+// published acts to find the articles they amend or repeal.
+package normattiva
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Act types, as named in URN-NIR where one exists.
+const (
+	Legge                    = "legge"
+	DecretoLegge             = "decreto.legge"
+	DecretoLegislativo       = "decreto.legislativo"
+	DPR                      = "decreto.del.presidente.della.repubblica"
+	DPCM                     = "decreto.del.presidente.del.consiglio.dei.ministri"
+	DecretoMinisteriale      = "decreto.ministeriale"
+	DecretoInterministeriale = "decreto.interministeriale"
+	Circolare                = "circolare"
+	Messaggio                = "messaggio"
+)
+
+// Atto identifies an act. Data is "2006-01-02", or empty when only the year
+// is known ("DL 63/2013"); Numero is 0 for acts cited by date only.
+type Atto struct {
+	Tipo   string `json:"tipo"`
+	Data   string `json:"data,omitempty"`
+	Anno   int    `json:"anno"`
+	Numero int    `json:"numero,omitempty"`
+}
+
+// Key identifies the act independently of how it is cited.
+func (a Atto) Key() string {
+	if a.Numero == 0 {
+		return a.Tipo + ":" + a.Data
+	}
+	return a.Tipo + ":" + strconv.Itoa(a.Anno) + ";" + strconv.Itoa(a.Numero)
+}
+
+// URN returns the URN-NIR of the act, or "" for acts Normattiva does not
+// identify by type, date and number (ministerial decrees, circulars).
+func (a Atto) URN() string {
+	if a.Numero == 0 {
+		return ""
+	}
+	var autorita string
+	switch a.Tipo {
+	case Legge, DecretoLegge, DecretoLegislativo:
+		autorita = "stato:" + a.Tipo
+	case DPR:
+		autorita = "presidente.repubblica:decreto"
+	default:
+		return ""
+	}
+	data := a.Data
+	if data == "" {
+		data = strconv.Itoa(a.Anno)
+	}
+	return "urn:nir:" + autorita + ":" + data + ";" + strconv.Itoa(a.Numero)
+}
+
+// Riferimento is a reference to an act, optionally to one of its articles
+// and commas.
+type Riferimento struct {
+	Atto
+	Articolo string `json:"articolo,omitempty"` // "1", "16-bis"
+	Comma    string `json:"comma,omitempty"`    // "177", "1-ter", "206-208"
+	// The act is cited as the conversion law of another act
+	Conversione bool   `json:"conversione,omitempty"`
+	Testo       string `json:"testo"` // the citation as written
+}
+
+// URN returns the URN-NIR of the reference, down to the article and, when a
+// single comma is cited, the comma.
+func (r Riferimento) URN() string {
+	urn := r.Atto.URN()
+	if urn == "" || r.Articolo == "" {
+		return urn
+	}
+	urn += "~art" + partizione(r.Articolo)
+	if r.Comma != "" && (!strings.Contains(r.Comma, "-") || suffisso(r.Comma)) {
+		urn += "-com" + partizione(r.Comma)
+	}
+	return urn
+}
+
+// URL returns the Normattiva page of the reference, or "".
+func (r Riferimento) URL() string {
+	if urn := r.URN(); urn != "" {
+		return "https://www.normattiva.it/uri-res/N2Ls?" + urn
+	}
+	return ""
+}
+
+// String is the canonical citation: "Legge 30 dicembre 2024, n. 207, art. 1, comma 177".
+func (r Riferimento) String() string {
+	var sb strings.Builder
+	sb.WriteString(nomi[r.Tipo])
+	if r.Data != "" {
+		t, _ := time.Parse("2006-01-02", r.Data)
+		sb.WriteString(fmt.Sprintf(" %d %s %d", t.Day(), mesi[t.Month()-1], t.Year()))
+		if r.Numero > 0 {
+			sb.WriteString(", n. " + strconv.Itoa(r.Numero))
+		}
+	} else if r.Tipo == Circolare || r.Tipo == Messaggio {
+		sb.WriteString(fmt.Sprintf(" n. %d/%d", r.Numero, r.Anno))
+	} else {
+		sb.WriteString(fmt.Sprintf(" %d/%d", r.Numero, r.Anno))
+	}
+	if r.Articolo != "" {
+		sb.WriteString(", art. " + r.Articolo)
+	}
+	if r.Comma != "" {
+		if strings.Contains(r.Comma, "-") && !suffisso(r.Comma) {
+			sb.WriteString(", commi " + r.Comma)
+		} else {
+			sb.WriteString(", comma " + r.Comma)
+		}
+	}
+	return sb.String()
+}
+
+// Affects reports whether a change to m touches what r cites: the same act
+// and, when both name them, the same article and overlapping commas.
+func (r Riferimento) Affects(m Riferimento) bool {
+	if r.Key() != m.Key() {
+		return false
+	}
+	if r.Articolo == "" || m.Articolo == "" {
+		return true
+	}
+	if r.Articolo != m.Articolo {
+		return false
+	}
+	if r.Comma == "" || m.Comma == "" {
+		return true
+	}
+	a0, a1 := intervallo(r.Comma)
+	b0, b1 := intervallo(m.Comma)
+	if a0 == 0 || b0 == 0 {
+		return r.Comma == m.Comma
+	}
+	return a0 <= b1 && b0 <= a1
+}
+
+var nomi = map[string]string{
+	Legge:                    "Legge",
+	DecretoLegge:             "Decreto-legge",
+	DecretoLegislativo:       "Decreto legislativo",
+	DPR:                      "D.P.R.",
+	DPCM:                     "D.P.C.M.",
+	DecretoMinisteriale:      "D.M.",
+	DecretoInterministeriale: "Decreto interministeriale",
+	Circolare:                "Circolare",
+	Messaggio:                "Messaggio",
+}
+
+var mesi = []string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno",
+	"luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"}
+
+// leggiBilancio are the budget laws, cited by year of application.
+var leggiBilancio = map[int]Atto{
+	2018: {Tipo: Legge, Data: "2017-12-27", Anno: 2017, Numero: 205},
+	2019: {Tipo: Legge, Data: "2018-12-30", Anno: 2018, Numero: 145},
+	2020: {Tipo: Legge, Data: "2019-12-27", Anno: 2019, Numero: 160},
+	2021: {Tipo: Legge, Data: "2020-12-30", Anno: 2020, Numero: 178},
+	2022: {Tipo: Legge, Data: "2021-12-30", Anno: 2021, Numero: 234},
+	2023: {Tipo: Legge, Data: "2022-12-29", Anno: 2022, Numero: 197},
+	2024: {Tipo: Legge, Data: "2023-12-30", Anno: 2023, Numero: 213},
+	2025: {Tipo: Legge, Data: "2024-12-30", Anno: 2024, Numero: 207},
+	2026: {Tipo: Legge, Data: "2025-12-30", Anno: 2025, Numero: 199},
+}
+
+// attiNoti are acts usually cited by name.
+var attiNoti = []struct {
+	re   *regexp.Regexp
+	atto Atto
+}{
+	{regexp.MustCompile(`(?i)\bTUIR\b|testo unico delle imposte sui redditi`), Atto{Tipo: DPR, Data: "1986-12-22", Anno: 1986, Numero: 917}},
+	{regexp.MustCompile(`(?i)\bdecreto\s+sostegni[\s-]+bis\b`), Atto{Tipo: DecretoLegge, Data: "2021-05-25", Anno: 2021, Numero: 73}},
+	{regexp.MustCompile(`(?i)\bdecreto\s+sostegni\b`), Atto{Tipo: DecretoLegge, Data: "2021-03-22", Anno: 2021, Numero: 41}},
+	{regexp.MustCompile(`(?i)\bdecreto\s+rilancio\b`), Atto{Tipo: DecretoLegge, Data: "2020-05-19", Anno: 2020, Numero: 34}},
+	{regexp.MustCompile(`(?i)\bdecreto\s+lavoro\b`), Atto{Tipo: DecretoLegge, Data: "2023-05-04", Anno: 2023, Numero: 48}},
+}
+
+const (
+	mese = `(gennaio|febbraio|marzo|aprile|maggio|giugno|luglio|agosto|settembre|ottobre|novembre|dicembre)`
+	// Latin suffixes of inserted articles and commas: 16-bis, 1-ter
+	latino  = `(?:bis|ter|quater|quinquies|sexies|septies|octies|novies|nonies|decies)`
+	numero  = `[0-9]+(?:[-\s]?` + latino + `)?`
+	commi   = numero + `(?:\s*[-–]\s*[0-9]+|\s+e\s+[0-9]+)?`
+	tipoRaw = `(legge|decreto[\s-]+legge|decreto\s+legislativo|decreto\s+ministeriale|decreto\s+interministeriale|` +
+		`decreto\s+del\s+presidente\s+del\s+consiglio\s+dei\s+ministri|decreto\s+del\s+presidente\s+della\s+repubblica|` +
+		`d\.\s?l\.|dl|d\.\s?lgs\.?|dlgs|d\.\s?m\.|dm|dpcm|d\.p\.c\.m\.|dpr|d\.p\.r\.|l\.)`
+)
+
+var (
+	reAttoData  = regexp.MustCompile(`(?i)\b` + tipoRaw + `\s+(?:del\s+)?([0-9]{1,2})°?\s+` + mese + `\s+([0-9]{4})(?:,?\s+(?:n\.|n°|numero)\s*([0-9]+))?`)
+	reAttoNum   = regexp.MustCompile(`(?i)\b` + tipoRaw + `\s+(?:n\.\s*)?([0-9]+)/([0-9]{4})`)
+	reBilancio  = regexp.MustCompile(`(?i)\blegge\s+di\s+bilancio\s+(?:per\s+il\s+)?([0-9]{4})`)
+	reCircolare = regexp.MustCompile(`(?i)\b(circolare|messaggio)\s+(?:inps\s+)?(?:n\.|n°|numero)\s*([0-9]+)` +
+		`(?:/([0-9]{4})|\s+del\s+(?:[0-9]{1,2}(?:/[0-9]{1,2}/|\s+` + mese + `\s+))?([0-9]{4}))?`)
+
+	// Article and comma cited before the act ("art. 16, comma 2, DL 63/2013",
+	// "comma 177 dell'articolo 1 della legge") or after it ("DL 48/2023, art. 12")
+	reArtPrima = regexp.MustCompile(`(?i)\bart(?:icolo|\.)?\s*(` + numero + `)(?:\s*,?\s*(?:comma|commi|c\.)\s*(` + commi + `))?` +
+		`(?:\s*,?\s*lett(?:era|\.)?\s*[a-z](?:-?` + latino + `)?\)?)?\s*,?\s*(?:del(?:la|lo|l['’])?\s*)?(?:citat[oa]\s+|predett[oa]\s+)?$`)
+	reCommaPrima  = regexp.MustCompile(`(?i)\b(?:comma|commi)\s*(` + commi + `)\s*,?\s*dell['’]\s*art(?:icolo|\.)?\s*(` + numero + `)\s*,?\s*(?:del(?:la|lo|l['’])?\s*)?(?:citat[oa]\s+)?$`)
+	reArtDopo     = regexp.MustCompile(`(?i)^\s*(?:\([^)]*\))?\s*,?\s*art(?:icolo|\.)?\s*(` + numero + `)(?:\s*,?\s*(?:comma|commi|c\.)\s*(` + commi + `))?`)
+	reConversione = regexp.MustCompile(`(?i)convertit[oa](?:\s*,\s*con\s+modificazioni\s*,)?\s+(?:in|dalla|con)\s*$`)
+)
+
+type trovato struct {
+	inizio, fine int
+	rif          Riferimento
+}
+
+// Parse returns the references cited in text, in order of appearance.
+// The same act cited twice ("Art. 16-bis DPR 917/1986 (TUIR)") is returned
+// once, with the most precise citation.
+func Parse(text string) []Riferimento {
+	var atti []trovato
+	occupato := func(s, e int) bool {
+		for _, a := range atti {
+			if s < a.fine && a.inizio < e {
+				return true
+			}
+		}
+		return false
+	}
+	aggiungi := func(s, e int, a Atto) {
+		if !occupato(s, e) {
+			atti = append(atti, trovato{s, e, Riferimento{Atto: a, Testo: strings.Join(strings.Fields(text[s:e]), " ")}})
+		}
+	}
+
+	for _, m := range reAttoData.FindAllStringSubmatchIndex(text, -1) {
+		g := gruppi(text, m)
+		giorno, _ := strconv.Atoi(g[2])
+		anno, _ := strconv.Atoi(g[4])
+		data := time.Date(anno, meseNum(g[3]), giorno, 0, 0, 0, 0, time.UTC)
+		if data.Day() != giorno {
+			continue // 31 febbraio
+		}
+		n, _ := strconv.Atoi(g[5])
+		aggiungi(m[0], m[1], Atto{Tipo: tipo(g[1]), Data: data.Format("2006-01-02"), Anno: anno, Numero: n})
+	}
+	for _, m := range reAttoNum.FindAllStringSubmatchIndex(text, -1) {
+		g := gruppi(text, m)
+		n, _ := strconv.Atoi(g[2])
+		anno, _ := strconv.Atoi(g[3])
+		aggiungi(m[0], m[1], Atto{Tipo: tipo(g[1]), Anno: anno, Numero: n})
+	}
+	for _, m := range reBilancio.FindAllStringSubmatchIndex(text, -1) {
+		anno, _ := strconv.Atoi(text[m[2]:m[3]])
+		if a, ok := leggiBilancio[anno]; ok {
+			aggiungi(m[0], m[1], a)
+		}
+	}
+	for _, n := range attiNoti {
+		for _, m := range n.re.FindAllStringIndex(text, -1) {
+			aggiungi(m[0], m[1], n.atto)
+		}
+	}
+	for _, m := range reCircolare.FindAllStringSubmatchIndex(text, -1) {
+		g := gruppi(text, m)
+		n, _ := strconv.Atoi(g[2])
+		anno, _ := strconv.Atoi(g[3] + g[5])
+		if anno == 0 {
+			continue
+		}
+		aggiungi(m[0], m[1], Atto{Tipo: strings.ToLower(g[1]), Anno: anno, Numero: n})
+	}
+	sort.Slice(atti, func(i, j int) bool { return atti[i].inizio < atti[j].inizio })
+
+	// Articles and commas around each act
+	for i := range atti {
+		a := &atti[i]
+		prima := 0
+		if i > 0 {
+			prima = atti[i-1].fine
+		}
+		dopo := len(text)
+		if i+1 < len(atti) {
+			dopo = atti[i+1].inizio
+		}
+		before := text[prima:a.inizio]
+		if m := reCommaPrima.FindStringSubmatch(before); m != nil {
+			a.rif.Articolo, a.rif.Comma = norma(m[2]), norma(m[1])
+		} else if m := reArtPrima.FindStringSubmatch(before); m != nil {
+			a.rif.Articolo, a.rif.Comma = norma(m[1]), norma(m[2])
+		} else if m := reArtDopo.FindStringSubmatch(text[a.fine:dopo]); m != nil {
+			a.rif.Articolo, a.rif.Comma = norma(m[1]), norma(m[2])
+		}
+		a.rif.Conversione = reConversione.MatchString(before)
+	}
+
+	var out []Riferimento
+	idx := make(map[string]int)
+	for _, a := range atti {
+		if j, ok := idx[a.rif.Key()]; ok {
+			if out[j].Articolo == "" && a.rif.Articolo != "" {
+				out[j].Articolo, out[j].Comma = a.rif.Articolo, a.rif.Comma
+			}
+			continue
+		}
+		idx[a.rif.Key()] = len(out)
+		out = append(out, a.rif)
+	}
+	return out
+}
+
+// ParseAll parses a list of citations, such as Bonus.RiferimentiNormativi.
+func ParseAll(citazioni []string) []Riferimento {
+	var out []Riferimento
+	for _, c := range citazioni {
+		out = append(out, Parse(c)...)
+	}
+	return out
+}
+
+func gruppi(text string, m []int) []string {
+	g := make([]string, len(m)/2)
+	for i := range g {
+		if m[2*i] >= 0 {
+			g[i] = text[m[2*i]:m[2*i+1]]
+		}
+	}
+	return g
+}
+
+func meseNum(s string) time.Month {
+	s = strings.ToLower(s)
+	for i, m := range mesi {
+		if m == s {
+			return time.Month(i + 1)
+		}
+	}
+	return 0
+}
+
+// tipo maps the way an act type is written to its constant.
+func tipo(s string) string {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	s = strings.ReplaceAll(strings.ReplaceAll(s, ".", ""), " ", "")
+	switch {
+	case s == "legge" || s == "l":
+		return Legge
+	case s == "decreto-legge" || s == "decretolegge" || s == "dl":
+		return DecretoLegge
+	case s == "decretolegislativo" || s == "dlgs":
+		return DecretoLegislativo
+	case s == "decretoministeriale" || s == "dm":
+		return DecretoMinisteriale
+	case s == "decretointerministeriale":
+		return DecretoInterministeriale
+	case s == "dpcm" || strings.Contains(s, "consiglio"):
+		return DPCM
+	default:
+		return DPR
+	}
+}
+
+// norma writes an article or comma as "16-bis" or "206-208".
+func norma(s string) string {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	s = strings.NewReplacer("–", "-", " e ", "-", " - ", "-", " -", "-", "- ", "-").Replace(s)
+	if i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' }); i > 0 && s[i] != '-' {
+		s = s[:i] + "-" + strings.TrimLeft(s[i:], " ")
+	}
+	return s
+}
+
+// suffisso reports whether a comma like "1-ter" is a single inserted comma
+// rather than a range.
+func suffisso(s string) bool {
+	i := strings.IndexAny(s, "-–")
+	return i >= 0 && i+1 < len(s) && (s[i+1] < '0' || s[i+1] > '9')
+}
+
+// partizione writes an article or comma as URN-NIR does: "16bis".
+func partizione(s string) string {
+	return strings.ReplaceAll(s, "-", "")
+}
+
+// intervallo returns the first and last comma of a numeric comma range;
+// 0, 0 for commas with a Latin suffix.
+func intervallo(s string) (int, int) {
+	if suffisso(s) {
+		return 0, 0
+	}
+	parti := strings.SplitN(s, "-", 2)
+	a, err := strconv.Atoi(parti[0])
+	if err != nil {
+		return 0, 0
+	}
+	b := a
+	if len(parti) == 2 {
+		if v, err := strconv.Atoi(parti[1]); err == nil && v >= a {
+			b = v
+		}
+	}
+	return a, b
+}
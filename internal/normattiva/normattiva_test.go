@@ -0,0 +1,96 @@
+package normattiva
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []string // canonical citation | URN
+	}{
+		{"Legge di Bilancio 2025, art. 1 comma 177", []string{
+			"Legge 30 dicembre 2024, n. 207, art. 1, comma 177 | urn:nir:stato:legge:2024-12-30;207~art1-com177"}},
+		{"Legge di Bilancio 2025, art. 1 commi 206-208", []string{
+			"Legge 30 dicembre 2024, n. 207, art. 1, commi 206-208 | urn:nir:stato:legge:2024-12-30;207~art1"}},
+		{"Art. 16, comma 2, DL 63/2013", []string{
+			"Decreto-legge 63/2013, art. 16, comma 2 | urn:nir:stato:decreto.legge:2013;63~art16-com2"}},
+		{"D.Lgs. 29 dicembre 2021, n. 230", []string{
+			"Decreto legislativo 29 dicembre 2021, n. 230 | urn:nir:stato:decreto.legislativo:2021-12-29;230"}},
+		{"DL 48/2023, convertito in L. 85/2023", []string{
+			"Decreto-legge 48/2023 | urn:nir:stato:decreto.legge:2023;48",
+			"Legge 85/2023 | urn:nir:stato:legge:2023;85"}},
+		{"Art. 16-bis DPR 917/1986 (TUIR)", []string{
+			"D.P.R. 917/1986, art. 16-bis | urn:nir:presidente.repubblica:decreto:1986;917~art16bis"}},
+		{"Art. 16, comma 1-ter, TUIR", []string{
+			"D.P.R. 22 dicembre 1986, n. 917, art. 16, comma 1-ter | urn:nir:presidente.repubblica:decreto:1986-12-22;917~art16-com1ter"}},
+		{"Decreto Sostegni-bis, art. 31", []string{
+			"Decreto-legge 25 maggio 2021, n. 73, art. 31 | urn:nir:stato:decreto.legge:2021-05-25;73~art31"}},
+		{"DL 228/2021, art. 1-quater", []string{
+			"Decreto-legge 228/2021, art. 1-quater | urn:nir:stato:decreto.legge:2021;228~art1quater"}},
+		{"Circolare INPS n. 33 del 4 febbraio 2025 — Aggiornamento importi", []string{"Circolare n. 33/2025 | "}},
+		{"DM 24 novembre 2023", []string{"D.M. 24 novembre 2023 | "}},
+		{"DGR Lombardia annuale", nil},
+		{"DPCM annuale soglie ISEE", nil},
+	}
+	for _, c := range cases {
+		var got []string
+		for _, r := range Parse(c.in) {
+			got = append(got, r.String()+" | "+r.URN())
+		}
+		if strings.Join(got, "\n") != strings.Join(c.want, "\n") {
+			t.Errorf("Parse(%q):\n%s\natteso:\n%s", c.in, strings.Join(got, "\n"), strings.Join(c.want, "\n"))
+		}
+	}
+
+	r := Parse("Legge di Bilancio 2025, art. 1 comma 177")[0]
+	if r.URL() != "https://www.normattiva.it/uri-res/N2Ls?urn:nir:stato:legge:2024-12-30;207~art1-com177" {
+		t.Errorf("URL = %q", r.URL())
+	}
+	if conv := Parse("DL 48/2023, convertito in L. 85/2023"); !conv[1].Conversione || conv[0].Conversione {
+		t.Errorf("legge di conversione non riconosciuta: %+v", conv)
+	}
+}
+
+func TestAffects(t *testing.T) {
+	bonus := Parse("Legge di Bilancio 2025, art. 1 commi 206-208")[0]
+	cases := map[string]bool{
+		"All'articolo 1, comma 207, della legge 30 dicembre 2024, n. 207": true,
+		"All'articolo 1, comma 177, della legge 30 dicembre 2024, n. 207": false,
+		"All'articolo 2 della legge 30 dicembre 2024, n. 207":             false,
+		"La legge 30 dicembre 2024, n. 207":                               true,
+		"All'articolo 1, comma 207, della legge 30 dicembre 2023, n. 213": false,
+	}
+	for in, want := range cases {
+		if got := bonus.Affects(Parse(in)[0]); got != want {
+			t.Errorf("%q: Riguarda = %v, atteso %v", in, got, want)
+		}
+	}
+}
+
+func TestAmendments(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "gu_decreto.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range Amendments(HTMLText(body)) {
+		s := m.Riferimento.String()
+		if m.Abrogazione {
+			s += " (abrogazione)"
+		}
+		got = append(got, s)
+	}
+	want := []string{
+		"Legge 30 dicembre 2024, n. 207, art. 1, comma 177",
+		"Legge 30 dicembre 2024, n. 207, art. 1, commi 206-207 (abrogazione)",
+		"Decreto-legge 4 maggio 2023, n. 48, art. 12, comma 3",
+		"Decreto-legge 4 giugno 2013, n. 63, art. 16, comma 2 (abrogazione)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("modifiche:\n%s\nattese:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
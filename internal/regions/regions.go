@@ -0,0 +1,167 @@
+// Package regions is the single table of Italian regions. Every region has a
+// canonical ISO 3166-2:IT code, its official Italian name, display names in the
+// supported languages and the aliases found in user input and scraped pages.
+// Region names must be compared through this package, never as raw strings.
+package regions
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Regione is an Italian region.
+type Regione struct {
+	Codice string            // ISO 3166-2:IT, e.g. "IT-36"
+	Nome   string            // official Italian name, used as canonical value
+	Nomi   map[string]string // display name by language code
+	Alias  []string
+}
+
+// All lists the 20 regions in alphabetical order.
+var All = []Regione{
+	{Codice: "IT-65", Nome: "Abruzzo", Nomi: map[string]string{
+		"en": "Abruzzo", "fr": "Abruzzes", "es": "Abruzos", "ro": "Abruzzo", "ar": "أبروتسو", "sq": "Abruco"}},
+	{Codice: "IT-77", Nome: "Basilicata", Nomi: map[string]string{
+		"en": "Basilicata", "fr": "Basilicate", "es": "Basilicata", "ro": "Basilicata", "ar": "بازيليكاتا", "sq": "Bazilikata"},
+		Alias: []string{"Lucania"}},
+	{Codice: "IT-78", Nome: "Calabria", Nomi: map[string]string{
+		"en": "Calabria", "fr": "Calabre", "es": "Calabria", "ro": "Calabria", "ar": "كالابريا", "sq": "Kalabria"}},
+	{Codice: "IT-72", Nome: "Campania", Nomi: map[string]string{
+		"en": "Campania", "fr": "Campanie", "es": "Campania", "ro": "Campania", "ar": "كامبانيا", "sq": "Kampania"}},
+	{Codice: "IT-45", Nome: "Emilia-Romagna", Nomi: map[string]string{
+		"en": "Emilia-Romagna", "fr": "Émilie-Romagne", "es": "Emilia-Romaña", "ro": "Emilia-Romagna", "ar": "إميليا رومانيا", "sq": "Emilia-Romanja"}},
+	{Codice: "IT-36", Nome: "Friuli-Venezia Giulia", Nomi: map[string]string{
+		"en": "Friuli-Venezia Giulia", "fr": "Frioul-Vénétie Julienne", "es": "Friuli-Venecia Julia", "ro": "Friuli-Veneția Giulia", "ar": "فريولي فينيتسيا جوليا", "sq": "Friuli-Venecia Xhulia"},
+		Alias: []string{"FVG", "Friuli"}},
+	{Codice: "IT-62", Nome: "Lazio", Nomi: map[string]string{
+		"en": "Lazio", "fr": "Latium", "es": "Lacio", "ro": "Lazio", "ar": "لاتسيو", "sq": "Lacio"}},
+	{Codice: "IT-42", Nome: "Liguria", Nomi: map[string]string{
+		"en": "Liguria", "fr": "Ligurie", "es": "Liguria", "ro": "Liguria", "ar": "ليغوريا", "sq": "Liguria"}},
+	{Codice: "IT-25", Nome: "Lombardia", Nomi: map[string]string{
+		"en": "Lombardy", "fr": "Lombardie", "es": "Lombardía", "ro": "Lombardia", "ar": "لومبارديا", "sq": "Lombardia"}},
+	{Codice: "IT-57", Nome: "Marche", Nomi: map[string]string{
+		"en": "Marche", "fr": "Marches", "es": "Marcas", "ro": "Marche", "ar": "ماركي", "sq": "Marke"}},
+	{Codice: "IT-67", Nome: "Molise", Nomi: map[string]string{
+		"en": "Molise", "fr": "Molise", "es": "Molise", "ro": "Molise", "ar": "موليزي", "sq": "Molise"}},
+	{Codice: "IT-21", Nome: "Piemonte", Nomi: map[string]string{
+		"en": "Piedmont", "fr": "Piémont", "es": "Piamonte", "ro": "Piemont", "ar": "بيمونتي", "sq": "Piemonte"}},
+	{Codice: "IT-75", Nome: "Puglia", Nomi: map[string]string{
+		"en": "Apulia", "fr": "Pouilles", "es": "Apulia", "ro": "Apulia", "ar": "بوليا", "sq": "Pulja"}},
+	{Codice: "IT-88", Nome: "Sardegna", Nomi: map[string]string{
+		"en": "Sardinia", "fr": "Sardaigne", "es": "Cerdeña", "ro": "Sardinia", "ar": "سردينيا", "sq": "Sardenja"}},
+	{Codice: "IT-82", Nome: "Sicilia", Nomi: map[string]string{
+		"en": "Sicily", "fr": "Sicile", "es": "Sicilia", "ro": "Sicilia", "ar": "صقلية", "sq": "Sicilia"}},
+	{Codice: "IT-52", Nome: "Toscana", Nomi: map[string]string{
+		"en": "Tuscany", "fr": "Toscane", "es": "Toscana", "ro": "Toscana", "ar": "توسكانا", "sq": "Toskana"}},
+	{Codice: "IT-32", Nome: "Trentino-Alto Adige", Nomi: map[string]string{
+		"en": "Trentino-South Tyrol", "fr": "Trentin-Haut-Adige", "es": "Trentino-Alto Adigio", "ro": "Trentino-Tirolul de Sud", "ar": "ترينتينو ألتو أديجي", "sq": "Trentino-Alto Adixhe"},
+		Alias: []string{"Trentino-Alto Adige/Südtirol", "Trentino-Südtirol", "Trentino"}},
+	{Codice: "IT-55", Nome: "Umbria", Nomi: map[string]string{
+		"en": "Umbria", "fr": "Ombrie", "es": "Umbría", "ro": "Umbria", "ar": "أومبريا", "sq": "Umbria"}},
+	{Codice: "IT-23", Nome: "Valle d'Aosta", Nomi: map[string]string{
+		"en": "Aosta Valley", "fr": "Vallée d'Aoste", "es": "Valle de Aosta", "ro": "Valea Aosta", "ar": "وادي أوستا", "sq": "Lugina e Aostës"},
+		Alias: []string{"Valle d'Aosta/Vallée d'Aoste", "Val d'Aosta", "VdA"}},
+	{Codice: "IT-34", Nome: "Veneto", Nomi: map[string]string{
+		"en": "Veneto", "fr": "Vénétie", "es": "Véneto", "ro": "Veneto", "ar": "فينيتو", "sq": "Veneto"}},
+}
+
+// index maps every normalised code, name, display name and alias to a region.
+var index = func() map[string]*Regione {
+	m := make(map[string]*Regione)
+	for i := range All {
+		r := &All[i]
+		keys := append([]string{r.Codice, strings.TrimPrefix(r.Codice, "IT-"), r.Nome}, r.Alias...)
+		for _, n := range r.Nomi {
+			keys = append(keys, n)
+		}
+		for _, k := range keys {
+			m[chiave(k)] = r
+		}
+	}
+	return m
+}()
+
+var accenti = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "è", "e", "é", "e", "ê", "e", "ì", "i", "í", "i",
+	"ò", "o", "ó", "o", "ù", "u", "ú", "u", "ü", "u", "ñ", "n", "ț", "t", "ë", "e",
+)
+
+// chiave reduces a name to a lookup key: lower case, without accents,
+// spaces and punctuation ("Friuli Venezia-Giulia" -> "friuliveneziagiulia").
+func chiave(s string) string {
+	s = accenti.Replace(strings.ToLower(strings.TrimSpace(s)))
+	var sb strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// Find resolves a region from its ISO code, official name, display name in
+// any supported language or alias.
+func Find(s string) (Regione, bool) {
+	if r, ok := index[chiave(s)]; ok {
+		return *r, true
+	}
+	return Regione{}, false
+}
+
+// Search returns the region named in free text, such as a scraped heading
+// ("Dote Scuola Regione Lombardia 2025"). Only Italian names and aliases are
+// recognised, as whole words; the longest name found wins.
+func Search(s string) (Regione, bool) {
+	testo := " " + parole(s) + " "
+	best, lung := -1, 0
+	for i := range All {
+		for _, n := range append([]string{All[i].Nome}, All[i].Alias...) {
+			if k := parole(n); len(k) > lung && strings.Contains(testo, " "+k+" ") {
+				best, lung = i, len(k)
+			}
+		}
+	}
+	if best < 0 {
+		return Regione{}, false
+	}
+	return All[best], true
+}
+
+// parole reduces s to lower-case words without accents separated by single
+// spaces ("Friuli-Venezia Giulia" -> "friuli venezia giulia").
+func parole(s string) string {
+	s = accenti.Replace(strings.ToLower(s))
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// Code returns the ISO 3166-2:IT code of the region named s, or "" if s is
+// not a region.
+func Code(s string) string {
+	r, _ := Find(s)
+	return r.Codice
+}
+
+// Canonical returns the official Italian name of the region named s.
+// Unknown names are returned unchanged.
+func Canonical(s string) string {
+	if r, ok := Find(s); ok {
+		return r.Nome
+	}
+	return s
+}
+
+// Same reports whether a and b name the same region.
+func Same(a, b string) bool {
+	ca := Code(a)
+	return ca != "" && ca == Code(b)
+}
+
+// NameIn returns the display name of r in lang, falling back to Italian.
+func (r Regione) NameIn(lang string) string {
+	if n, ok := r.Nomi[lang]; ok {
+		return n
+	}
+	return r.Nome
+}
@@ -0,0 +1,96 @@
+package regions_test
+
+import (
+	"bonusperme/internal/matcher"
+	"bonusperme/internal/regions"
+	"bonusperme/internal/scraper"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	cases := map[string]string{
+		"Friuli-Venezia Giulia":        "IT-36",
+		"Friuli Venezia Giulia":        "IT-36",
+		"friuli venezia-giulia":        "IT-36",
+		"FVG":                          "IT-36",
+		"IT-25":                        "IT-25",
+		"Lombardy":                     "IT-25",
+		"Valle d’Aosta":                "IT-23",
+		"Vallée d'Aoste":               "IT-23",
+		"Trentino-Alto Adige/Südtirol": "IT-32",
+		"Apulia":                       "IT-75",
+		"صقلية":                        "IT-82",
+	}
+	for in, want := range cases {
+		if got := regions.Code(in); got != want {
+			t.Errorf("Code(%q) = %q, atteso %q", in, got, want)
+		}
+	}
+	for _, in := range []string{"", "Padania", "IT-99"} {
+		if _, ok := regions.Find(in); ok {
+			t.Errorf("%q non deve essere una regione", in)
+		}
+	}
+	if regions.Same("", "") {
+		t.Error("due regioni vuote non sono la stessa regione")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	cases := map[string]string{
+		"Dote Scuola Regione Lombardia 2025":        "IT-25",
+		"Bonus regionali in Emilia Romagna":         "IT-45",
+		"Carta Famiglia Friuli Venezia Giulia":      "IT-36",
+		"Contributi della Regione Valle d’Aosta":    "IT-23",
+		"Agevolazioni per le famiglie in Sardegna:": "IT-88",
+	}
+	for in, want := range cases {
+		if r, _ := regions.Search(in); r.Codice != want {
+			t.Errorf("Search(%q) = %q, atteso %q", in, r.Codice, want)
+		}
+	}
+	for _, in := range []string{"Bonus asilo nido", "Bonus Lombardiana", ""} {
+		if r, ok := regions.Search(in); ok {
+			t.Errorf("Search(%q) = %s, attesa nessuna regione", in, r.Nome)
+		}
+	}
+}
+
+// Every name of a region must resolve to that region: an alias or translation
+// shared by two regions would silently match the wrong one.
+func TestAll_NomiUnivoci(t *testing.T) {
+	if len(regions.All) != 20 {
+		t.Fatalf("attese 20 regioni, trovate %d", len(regions.All))
+	}
+	for _, r := range regions.All {
+		names := append([]string{r.Codice, r.Nome}, r.Alias...)
+		for _, n := range r.Nomi {
+			names = append(names, n)
+		}
+		for _, n := range names {
+			if got := regions.Code(n); got != r.Codice {
+				t.Errorf("%q risolve in %q invece di %s", n, got, r.Codice)
+			}
+		}
+		for _, lang := range []string{"en", "fr", "es", "ro", "ar", "sq"} {
+			if r.Nomi[lang] == "" {
+				t.Errorf("%s: nome mancante per la lingua %s", r.Nome, lang)
+			}
+		}
+	}
+}
+
+func TestRegioniNote(t *testing.T) {
+	for _, b := range matcher.GetAllBonusWithRegional() {
+		for _, r := range b.RegioniApplicabili {
+			if _, ok := regions.Find(r); !ok {
+				t.Errorf("bonus %s: regione %q non presente nella tabella", b.ID, r)
+			}
+		}
+	}
+	for _, src := range scraper.RegionalSources {
+		if _, ok := regions.Find(src.Regione); src.Regione != "*" && !ok {
+			t.Errorf("fonte %s: regione %q non presente nella tabella", src.URL, src.Regione)
+		}
+	}
+}
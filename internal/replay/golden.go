@@ -0,0 +1,54 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// update is the -update flag of the tests comparing parsers with golden
+// files. It is only registered in test binaries.
+var update *bool
+
+func init() {
+	if testing.Testing() {
+		update = flag.Bool("update", false, "rewrite the golden files")
+	}
+}
+
+// Golden compares got with the golden file at path. With -update it rewrites
+// the file instead, after an intended change of a parser:
+//
+//	go test ./internal/scraper -update
+func Golden(t testing.TB, path string, got []byte) {
+	t.Helper()
+	if update != nil && *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden mancante (go test -update per crearlo): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("risultato diverso dal golden %s:\n%s", path, got)
+	}
+}
+
+// GoldenJSON compares v, as indented JSON, with the golden file at path.
+func GoldenJSON(t testing.TB, path string, v interface{}) {
+	t.Helper()
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Golden(t, path, append(got, '\n'))
+}
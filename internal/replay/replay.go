@@ -0,0 +1,238 @@
+// Package replay records the responses of third-party sites into fixture
+// files and serves them back offline. Recorder and Player are
+// http.RoundTrippers meant for crawler.Use: tests replay the fixtures in
+// testdata/replay, and a production scrape recorded with HTTP_RECORD_DIR can
+// be reproduced exactly with HTTP_REPLAY_DIR.
+//
+// A fixture is a plain text file: the request line ("GET https://..."), then
+// the response status line, headers, a blank line and the body, byte for
+// byte. Files can be edited by hand; the body length is not checked.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fixture extension.
+const ext = ".http"
+
+// Headers not saved: the body is stored decoded and whole.
+var saltati = map[string]bool{"Content-Length": true, "Transfer-Encoding": true, "Content-Encoding": true, "Set-Cookie": true}
+
+// Recorder forwards requests to Next and saves every response under Dir.
+type Recorder struct {
+	Dir  string
+	Next http.RoundTripper // nil = http.DefaultTransport
+	// Daily saves the responses of each day in Dir/YYYY-MM-DD, so the
+	// scrape of a given day can be replayed.
+	Daily bool
+}
+
+// NewRecorder records into dir through the default transport.
+func NewRecorder(dir string) *Recorder {
+	return &Recorder{Dir: dir}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Recorded responses are always complete, never a 304
+	req = req.Clone(req.Context())
+	req.Header.Del("If-None-Match")
+	req.Header.Del("If-Modified-Since")
+
+	next := r.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	dir := r.Dir
+	if r.Daily {
+		dir = filepath.Join(dir, time.Now().Format("2006-01-02"))
+	}
+	if err := Save(dir, req.Method, req.URL.String(), resp, body); err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	return resp, nil
+}
+
+// Save writes a fixture for the response to method url under dir.
+func Save(dir, method, url string, resp *http.Response, body []byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s\n", method, url)
+	fmt.Fprintf(&buf, "HTTP/1.1 %s\n", statusLine(resp))
+	keys := make([]string, 0, len(resp.Header))
+	for k := range resp.Header {
+		if !saltati[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range resp.Header[k] {
+			fmt.Fprintf(&buf, "%s: %s\n", k, v)
+		}
+	}
+	buf.WriteString("\n")
+	if method != http.MethodHead {
+		buf.Write(body)
+	}
+	return os.WriteFile(filepath.Join(dir, FileName(method, url)), buf.Bytes(), 0o644)
+}
+
+func statusLine(resp *http.Response) string {
+	if resp.Status != "" && strings.HasPrefix(resp.Status, strconv.Itoa(resp.StatusCode)) {
+		return resp.Status
+	}
+	return fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+}
+
+var reNonAlnum = regexp.MustCompile(`[^a-z0-9]+`)
+
+// FileName is the fixture name of a request: readable host and path, plus a
+// hash of the full URL so names never collide.
+func FileName(method, url string) string {
+	h := sha1.Sum([]byte(method + " " + url))
+	name := strings.ToLower(url)
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "https://"), "http://")
+	name = strings.Trim(reNonAlnum.ReplaceAllString(name, "-"), "-")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	if method != http.MethodGet {
+		name = strings.ToLower(method) + "-" + name
+	}
+	return name + "-" + hex.EncodeToString(h[:4]) + ext
+}
+
+// Player serves the fixtures under Dir; no request reaches the network.
+// A HEAD request is answered from the GET fixture when it has none of its
+// own, and a missing robots.txt is a 404. Any other request without a
+// fixture fails.
+type Player struct {
+	Dir string
+
+	once    sync.Once
+	indice  map[string]string // "GET url" -> file
+	errLoad error
+}
+
+// NewPlayer replays the fixtures under dir.
+func NewPlayer(dir string) *Player {
+	return &Player{Dir: dir}
+}
+
+func (p *Player) RoundTrip(req *http.Request) (*http.Response, error) {
+	p.once.Do(p.carica)
+	if p.errLoad != nil {
+		return nil, p.errLoad
+	}
+	url := req.URL.String()
+	file, ok := p.indice[req.Method+" "+url]
+	if !ok && req.Method == http.MethodHead {
+		file, ok = p.indice[http.MethodGet+" "+url]
+	}
+	if !ok {
+		if req.URL.Path == "/robots.txt" {
+			return risposta(req, http.StatusNotFound, http.Header{}, nil), nil
+		}
+		return nil, fmt.Errorf("replay: no fixture for %s %s in %s", req.Method, url, p.Dir)
+	}
+	return leggi(req, file)
+}
+
+// carica indexes the fixtures by their request line.
+func (p *Player) carica() {
+	p.indice = make(map[string]string)
+	files, err := filepath.Glob(filepath.Join(p.Dir, "*"+ext))
+	if err != nil {
+		p.errLoad = err
+		return
+	}
+	if len(files) == 0 {
+		p.errLoad = fmt.Errorf("replay: no fixtures in %s", p.Dir)
+		return
+	}
+	for _, f := range files {
+		fh, err := os.Open(f)
+		if err != nil {
+			p.errLoad = err
+			return
+		}
+		line, _ := bufio.NewReader(fh).ReadString('\n')
+		fh.Close()
+		p.indice[strings.TrimSpace(line)] = f
+	}
+}
+
+// leggi parses a fixture into the response to req.
+func leggi(req *http.Request, file string) (*http.Response, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	tp := textproto.NewReader(bufio.NewReader(bytes.NewReader(data)))
+	if _, err := tp.ReadLine(); err != nil { // request line
+		return nil, fmt.Errorf("replay: %s: %w", file, err)
+	}
+	status, err := tp.ReadLine()
+	if err != nil {
+		return nil, fmt.Errorf("replay: %s: %w", file, err)
+	}
+	proto, codeText, _ := strings.Cut(status, " ")
+	code, err := strconv.Atoi(strings.SplitN(codeText, " ", 2)[0])
+	if err != nil || !strings.HasPrefix(proto, "HTTP/") {
+		return nil, fmt.Errorf("replay: %s: bad status line %q", file, status)
+	}
+	hdr, err := tp.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("replay: %s: %w", file, err)
+	}
+	body, _ := io.ReadAll(tp.R)
+	if req.Method == http.MethodHead {
+		body = nil
+	}
+	resp := risposta(req, code, http.Header(hdr), body)
+	resp.Status = codeText
+	return resp, nil
+}
+
+func risposta(req *http.Request, code int, hdr http.Header, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        hdr,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,89 @@
+package replay
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegistraERigioca(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			t.Error("richiesta condizionale registrata: la fixture non avrebbe il corpo")
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<h1>Bonus nido</h1>\n")
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	client := &http.Client{Transport: NewRecorder(dir)}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/bonus?anno=2025", nil)
+	req.Header.Set("If-None-Match", `"v0"`)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "<h1>Bonus nido</h1>\n" {
+		t.Fatalf("corpo inoltrato = %q", body)
+	}
+	srv.Close()
+
+	// La fixture è testo leggibile
+	data, err := os.ReadFile(filepath.Join(dir, FileName(http.MethodGet, srv.URL+"/bonus?anno=2025")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "GET "+srv.URL+"/bonus?anno=2025\nHTTP/1.1 200 OK\n") {
+		t.Errorf("fixture:\n%s", data)
+	}
+
+	// Il server è chiuso: risponde solo la fixture
+	client = &http.Client{Transport: NewPlayer(dir)}
+	resp, err = client.Get(srv.URL + "/bonus?anno=2025")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 200 || resp.Header.Get("ETag") != `"v1"` || string(body) != "<h1>Bonus nido</h1>\n" {
+		t.Errorf("rigiocata: %d %v %q", resp.StatusCode, resp.Header, body)
+	}
+
+	// HEAD usa la fixture GET, robots.txt mancante è un 404, il resto è un errore
+	resp, err = client.Head(srv.URL + "/bonus?anno=2025")
+	if err != nil || resp.StatusCode != 200 {
+		t.Errorf("HEAD: %v %v", resp, err)
+	}
+	resp, err = client.Get(srv.URL + "/robots.txt")
+	if err != nil || resp.StatusCode != 404 {
+		t.Errorf("robots.txt: %v %v", resp, err)
+	}
+	if _, err := client.Get(srv.URL + "/altro"); err == nil || !strings.Contains(err.Error(), "no fixture") {
+		t.Errorf("richiesta senza fixture: err = %v", err)
+	}
+}
+
+// Le fixture scritte a mano non devono avere una Content-Length esatta
+func TestFixtureAMano(t *testing.T) {
+	dir := t.TempDir()
+	fixture := "GET https://www.example.it/feed\nHTTP/1.1 503 Service Unavailable\nRetry-After: 120\n\nriprovare più tardi"
+	os.WriteFile(filepath.Join(dir, "feed.http"), []byte(fixture), 0o644)
+
+	client := &http.Client{Transport: NewPlayer(dir)}
+	resp, err := client.Get("https://www.example.it/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != 503 || resp.Header.Get("Retry-After") != "120" || string(body) != "riprovare più tardi" {
+		t.Errorf("fixture a mano: %d %v %q", resp.StatusCode, resp.Header, body)
+	}
+}
@@ -0,0 +1,210 @@
+// Package review is the moderation queue for changes proposed by the scraper.
+// A scraped deadline, amount or source link, every scraped bonus missing from
+// the catalogue and every uncertain match between a scraped item and a
+// catalogue bonus becomes a proposal; only approved proposals are applied to
+// the served catalogue. Sources trusted enough can be approved automatically.
+package review
+
+import (
+	"bonusperme/internal/models"
+	"bonusperme/internal/storage"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Proposal kinds.
+const (
+	TipoModifica = "modifica" // change of one field of a catalogue bonus
+	TipoNuovo    = "nuovo"    // scraped bonus not in the catalogue
+	// Low-confidence match of a scraped item to a catalogue bonus. Approving
+	// it merges the item into the bonus, rejecting it treats the item as new.
+	TipoAbbinamento = "abbinamento"
+)
+
+// Proposal states.
+const (
+	StatoInAttesa  = "in_attesa"
+	StatoApprovata = "approvata"
+	StatoRifiutata = "rifiutata"
+)
+
+var (
+	ErrNotFound       = errors.New("proposta non trovata")
+	ErrInvalidEdit    = errors.New("modifica non valida per questa proposta")
+	ErrAlreadyDecided = errors.New("proposta già decisa")
+	ErrUnknownState   = errors.New("stato non valido")
+)
+
+// Proposta is a change proposed by a scraping source.
+type Proposta struct {
+	ID      string `json:"id"`
+	Tipo    string `json:"tipo"`
+	BonusID string `json:"bonus_id"`
+
+	// TipoModifica: the field, its catalogue value and the value to apply
+	Campo   string `json:"campo,omitempty"`
+	Attuale string `json:"attuale,omitempty"`
+	Valore  string `json:"valore,omitempty"`
+	// TipoNuovo: the bonus to publish
+	Bonus *models.Bonus `json:"bonus,omitempty"`
+	// TipoAbbinamento: the scraped name; BonusID and Valore hold the candidate
+	NomeScraped string `json:"nome_scraped,omitempty"`
+	// Confidence of the match between the scraped item and BonusID
+	Confidenza float64 `json:"confidenza,omitempty"`
+
+	Fonte    string `json:"fonte"`
+	FonteURL string `json:"fonte_url,omitempty"`
+	Priorita int    `json:"priorita"`
+
+	Stato      string    `json:"stato"`
+	Automatica bool      `json:"automatica,omitempty"` // approved by the per-source rule
+	Modificata bool      `json:"modificata,omitempty"` // edited by a moderator
+	Nota       string    `json:"nota,omitempty"`
+	Creata     time.Time `json:"creata"`
+	Decisa     time.Time `json:"decisa,omitempty"`
+}
+
+// OnDecision is called after a moderator decision, so the served catalogue
+// can be rebuilt. Set from main.go.
+var OnDecision func()
+
+var mu sync.Mutex
+
+// ChangeID identifies the proposal of value for a field of a bonus. The same
+// scraped value always maps to the same proposal, so decisions stick across cycles.
+func ChangeID(bonusID, campo, valore string) string {
+	return hash(TipoModifica, bonusID, campo, strings.TrimSpace(valore))
+}
+
+// NewBonusID identifies the proposal of a new bonus by its scraped name.
+func NewBonusID(nome string) string {
+	return hash(TipoNuovo, strings.ToLower(strings.TrimSpace(nome)))
+}
+
+// MatchID identifies the match proposal of a scraped name.
+func MatchID(nome string) string {
+	return hash(TipoAbbinamento, strings.ToLower(strings.TrimSpace(nome)))
+}
+
+func hash(parts ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:6])
+}
+
+// Submit returns the stored proposal with p's ID, queuing p when it is new.
+// A new proposal is approved at once when auto is true.
+func Submit(p Proposta, auto bool) (Proposta, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var old Proposta
+	found, err := storage.Get(storage.BucketReview, p.ID, &old)
+	if err != nil || found {
+		return old, err
+	}
+	p.Creata = time.Now()
+	p.Stato = StatoInAttesa
+	if auto {
+		p.Stato = StatoApprovata
+		p.Automatica = true
+		p.Decisa = p.Creata
+	}
+	return p, storage.Put(storage.BucketReview, p.ID, p)
+}
+
+// List returns the proposals in state stato (all when empty), newest first.
+func List(stato string) ([]Proposta, error) {
+	switch stato {
+	case "", StatoInAttesa, StatoApprovata, StatoRifiutata:
+	default:
+		return nil, ErrUnknownState
+	}
+	out := []Proposta{}
+	err := storage.ForEach(storage.BucketReview, func(_ string, data []byte) error {
+		var p Proposta
+		if err := json.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		if stato == "" || p.Stato == stato {
+			out = append(out, p)
+		}
+		return nil
+	})
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Creata.After(out[j].Creata) })
+	return out, err
+}
+
+// Approve approves a proposal.
+func Approve(id, nota string) (Proposta, error) {
+	return decidi(id, func(p *Proposta) error {
+		p.Stato = StatoApprovata
+		p.Automatica = false
+		p.Nota = nota
+		return nil
+	})
+}
+
+// Reject rejects a proposal; the same scraped value will not be proposed again.
+func Reject(id, nota string) (Proposta, error) {
+	return decidi(id, func(p *Proposta) error {
+		p.Stato = StatoRifiutata
+		p.Automatica = false
+		p.Nota = nota
+		return nil
+	})
+}
+
+// Edit replaces the value (TipoModifica), the bonus (TipoNuovo) or the
+// catalogue ID of the candidate (TipoAbbinamento) of a pending proposal.
+// The proposal stays pending until approved.
+func Edit(id, valore string, b *models.Bonus) (Proposta, error) {
+	return decidi(id, func(p *Proposta) error {
+		if p.Stato != StatoInAttesa {
+			return ErrAlreadyDecided
+		}
+		switch {
+		case p.Tipo == TipoModifica && strings.TrimSpace(valore) != "" && b == nil:
+			p.Valore = strings.TrimSpace(valore)
+		case p.Tipo == TipoAbbinamento && strings.TrimSpace(valore) != "" && b == nil:
+			p.Valore = strings.TrimSpace(valore)
+			p.BonusID = p.Valore
+		case p.Tipo == TipoNuovo && b != nil && b.ID != "" && b.Nome != "":
+			p.BonusID = b.ID
+			p.Bonus = b
+		default:
+			return ErrInvalidEdit
+		}
+		p.Modificata = true
+		return nil
+	})
+}
+
+func decidi(id string, fn func(*Proposta) error) (Proposta, error) {
+	mu.Lock()
+	var p Proposta
+	found, err := storage.Get(storage.BucketReview, id, &p)
+	if err == nil && !found {
+		err = ErrNotFound
+	}
+	if err == nil {
+		err = fn(&p)
+	}
+	if err == nil {
+		if p.Stato != StatoInAttesa {
+			p.Decisa = time.Now()
+		}
+		err = storage.Put(storage.BucketReview, id, p)
+	}
+	mu.Unlock()
+
+	if err == nil && p.Stato != StatoInAttesa && OnDecision != nil {
+		OnDecision()
+	}
+	return p, err
+}
@@ -0,0 +1,59 @@
+package review
+
+import (
+	"bonusperme/internal/models"
+	"bonusperme/internal/storage"
+	"testing"
+)
+
+func TestSubmit_Decisioni(t *testing.T) {
+	storage.Use(storage.NewMemory())
+	defer storage.Use(storage.NewMemory())
+	decisioni := 0
+	OnDecision = func() { decisioni++ }
+	defer func() { OnDecision = nil }()
+
+	id := ChangeID("bonus-a", "scadenza", "30 giugno 2026")
+	p, err := Submit(Proposta{ID: id, Tipo: TipoModifica, BonusID: "bonus-a", Campo: "scadenza", Valore: "30 giugno 2026"}, false)
+	if err != nil || p.Stato != StatoInAttesa {
+		t.Fatalf("nuova proposta: %+v, %v", p, err)
+	}
+	// Lo stesso valore riproposto al ciclo successivo è la stessa proposta
+	if again, _ := Submit(Proposta{ID: id, Valore: "altro"}, true); again.Stato != StatoInAttesa || again.Valore != "30 giugno 2026" {
+		t.Errorf("proposta ripetuta = %+v", again)
+	}
+
+	if p, err = Edit(id, "31 luglio 2026", nil); err != nil || p.Valore != "31 luglio 2026" || !p.Modificata {
+		t.Fatalf("Modifica: %+v, %v", p, err)
+	}
+	if _, err := Edit(id, "", &models.Bonus{ID: "x", Nome: "X"}); err != ErrInvalidEdit {
+		t.Errorf("bonus su proposta di modifica: err = %v, atteso ErrInvalidEdit", err)
+	}
+	if decisioni != 0 {
+		t.Errorf("OnDecisione chiamato %d volte prima della decisione", decisioni)
+	}
+
+	if p, err = Approve(id, "verificato su inps.it"); err != nil || p.Stato != StatoApprovata || p.Decisa.IsZero() {
+		t.Fatalf("Approva: %+v, %v", p, err)
+	}
+	if decisioni != 1 {
+		t.Errorf("OnDecisione chiamato %d volte, atteso 1", decisioni)
+	}
+	if _, err := Edit(id, "1 agosto 2026", nil); err != ErrAlreadyDecided {
+		t.Errorf("modifica dopo l'approvazione: err = %v, atteso ErrAlreadyDecided", err)
+	}
+	if _, err := Reject("inesistente", ""); err != ErrNotFound {
+		t.Errorf("proposta inesistente: err = %v, atteso ErrNotFound", err)
+	}
+
+	auto, _ := Submit(Proposta{ID: NewBonusID("Bonus Nuovo"), Tipo: TipoNuovo}, true)
+	if auto.Stato != StatoApprovata || !auto.Automatica {
+		t.Errorf("approvazione automatica = %+v", auto)
+	}
+	if inAttesa, _ := List(StatoInAttesa); len(inAttesa) != 0 {
+		t.Errorf("in attesa = %+v, atteso vuoto", inAttesa)
+	}
+	if tutte, _ := List(""); len(tutte) != 2 {
+		t.Errorf("proposte = %d, attese 2", len(tutte))
+	}
+}
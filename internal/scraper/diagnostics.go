@@ -0,0 +1,243 @@
+package scraper
+
+import (
+	"bonusperme/internal/crawler"
+	"bonusperme/internal/logger"
+	"bonusperme/internal/validity"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// maxHistory is the number of fetches kept per source.
+	maxHistory = 30
+	// baselineSize is the number of past good fetches a new one is compared with.
+	baselineSize = 10
+	// minBaseline is the number of good fetches needed before drift is detected.
+	minBaseline = 3
+	// minSimilarity is the DOM similarity below which the page changed shape.
+	minSimilarity = 0.6
+	// maxShapeKeys bounds the element kinds kept in a shape.
+	maxShapeKeys = 60
+)
+
+// FetchRecord is the outcome of one fetch of a source.
+type FetchRecord struct {
+	Time       time.Time `json:"time"`
+	HTTPStatus int       `json:"http_status"` // 0 = network error or robots.txt
+	LatencyMS  int64     `json:"latency_ms"`
+	Bytes      int       `json:"bytes"`
+	Items      int       `json:"items"`
+	// Fingerprint identifies the set of element kinds of the page
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Error       string `json:"error,omitempty"`
+	// Shape counts the elements of the page by tag and first class
+	Shape map[string]int `json:"shape,omitempty"`
+}
+
+// ok reports whether the fetch returned a page that was parsed.
+func (r FetchRecord) ok() bool {
+	return r.Error == "" && r.HTTPStatus == 200
+}
+
+// fetchSource downloads a source page and records how the fetch went.
+func fetchSource(url string) ([]byte, FetchRecord) {
+	rec := FetchRecord{Time: time.Now()}
+	body, err := crawler.Get(url)
+	rec.LatencyMS = time.Since(rec.Time).Milliseconds()
+	if err != nil {
+		var se *crawler.StatusError
+		if errors.As(err, &se) {
+			rec.HTTPStatus = se.Code
+		}
+		rec.Error = err.Error()
+		return nil, rec
+	}
+	rec.HTTPStatus = 200
+	rec.Bytes = len(body)
+	rec.Shape = shape(body)
+	rec.Fingerprint = fingerprint(rec.Shape)
+	return body, rec
+}
+
+// shape counts the structural elements of an HTML page, keyed by tag and
+// first class ("div.card", "h3", "a"). Only the most frequent kinds are kept.
+func shape(body []byte) map[string]int {
+	doc, err := html.Parse(strings.NewReader(string(body)))
+	if err != nil {
+		return nil
+	}
+	counts := make(map[string]int)
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "svg":
+				return
+			}
+			key := n.Data
+			if class := strings.Fields(getAttr(n, "class")); len(class) > 0 {
+				key += "." + class[0]
+			}
+			counts[key]++
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(doc)
+
+	if len(counts) > maxShapeKeys {
+		keys := make([]string, 0, len(counts))
+		for k := range counts {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if counts[keys[i]] != counts[keys[j]] {
+				return counts[keys[i]] > counts[keys[j]]
+			}
+			return keys[i] < keys[j]
+		})
+		for _, k := range keys[maxShapeKeys:] {
+			delete(counts, k)
+		}
+	}
+	return counts
+}
+
+func fingerprint(s map[string]int) string {
+	if len(s) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := fnv.New64a()
+	h.Write([]byte(strings.Join(keys, " ")))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// similarity is the weighted Jaccard index of two shapes: 1 for the same
+// page structure, 0 for pages with no element kind in common.
+func similarity(a, b map[string]int) float64 {
+	var inter, union int
+	for k, x := range a {
+		y := b[k]
+		inter += min(x, y)
+		union += max(x, y)
+	}
+	for k, y := range b {
+		if _, ok := a[k]; !ok {
+			union += y
+		}
+	}
+	if union == 0 {
+		return 1
+	}
+	return float64(inter) / float64(union)
+}
+
+// drift compares a fetch with the history of its source and returns why the
+// parser looks broken: the yield or the page structure deviate sharply from
+// the last good fetches. Failed fetches are not drift.
+func drift(rec FetchRecord, history []FetchRecord) []string {
+	if !rec.ok() {
+		return nil
+	}
+	var base []FetchRecord
+	for i := len(history) - 1; i >= 0 && len(base) < baselineSize; i-- {
+		if history[i].ok() && history[i].Items > 0 {
+			base = append(base, history[i])
+		}
+	}
+	if len(base) < minBaseline {
+		return nil
+	}
+
+	var motivi []string
+	items := make([]int, len(base))
+	for i, r := range base {
+		items[i] = r.Items
+	}
+	sort.Ints(items)
+	usual := items[len(items)/2]
+	switch {
+	case rec.Items == 0:
+		motivi = append(motivi, fmt.Sprintf("nessun elemento estratto (di solito %d)", usual))
+	case rec.Items*2 < usual:
+		motivi = append(motivi, fmt.Sprintf("elementi estratti %d, di solito %d", rec.Items, usual))
+	case rec.Items > 3*usual && rec.Items-usual >= 10:
+		motivi = append(motivi, fmt.Sprintf("elementi estratti %d, di solito %d", rec.Items, usual))
+	}
+
+	// The page is compared with the closest recent structure, so a redesign
+	// is reported once and then becomes the new baseline
+	if rec.Shape != nil {
+		best, confrontati := 0.0, 0
+		for _, r := range base[:min(len(base), 5)] {
+			if r.Shape != nil {
+				best = max(best, similarity(rec.Shape, r.Shape))
+				confrontati++
+			}
+		}
+		if confrontati > 0 && best < minSimilarity {
+			motivi = append(motivi, fmt.Sprintf("struttura della pagina cambiata (somiglianza %.0f%%)", best*100))
+		}
+	}
+	return motivi
+}
+
+// recordFetch stores the outcome of a fetch in the status of the source,
+// keeping a rolling history, and raises a parser drift alert when needed.
+// err is the error of the whole fetch and parse, if any.
+func recordFetch(name, regione string, rec FetchRecord, err error) {
+	cache.mu.Lock()
+	prev := cache.sourcesStatus[name]
+	motivi := drift(rec, prev.History)
+	history := append(append([]FetchRecord(nil), prev.History...), rec)
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	// Only recent shapes are compared, older ones would just weigh on storage
+	for i := 0; i < len(history)-baselineSize; i++ {
+		history[i].Shape = nil
+	}
+	status := SourceStatus{
+		LastFetch:  rec.Time,
+		Success:    err == nil && rec.ok() && rec.Items > 0,
+		BonusFound: rec.Items,
+		Regione:    regione,
+		Drift:      motivi,
+		History:    history,
+	}
+	switch {
+	case err != nil:
+		status.Error = err.Error()
+	case rec.Error != "":
+		status.Error = rec.Error
+	case rec.Items == 0:
+		status.Error = "no bonuses found"
+	}
+	cache.sourcesStatus[name] = status
+	cache.mu.Unlock()
+
+	// A drift is reported when it starts, not at every cycle until it is fixed
+	if len(motivi) > 0 && len(prev.Drift) == 0 {
+		logger.Warn("scraper: parser drift", map[string]interface{}{"source": name, "reasons": motivi})
+		validity.AddAlert(validity.Alert{
+			Fonte:     name,
+			NewStato:  "parser_drift",
+			Motivo:    "Parser di " + name + " da verificare: " + strings.Join(motivi, "; "),
+			Timestamp: time.Now(),
+			Urgenza:   "alta",
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package scraper
+
+import (
+	"bonusperme/internal/storage"
+	"bonusperme/internal/validity"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDrift(t *testing.T) {
+	pagina := map[string]int{"div.card": 12, "h3": 12, "a": 40, "p": 30}
+	storia := []FetchRecord{
+		{HTTPStatus: 200, Items: 12, Shape: pagina},
+		{HTTPStatus: 503, Error: "HTTP 503"},
+		{HTTPStatus: 200, Items: 11, Shape: pagina},
+		{HTTPStatus: 200, Items: 12, Shape: pagina},
+	}
+	ridisegnata := map[string]int{"section.tile": 12, "h2": 12, "a": 40}
+
+	casi := []struct {
+		nome   string
+		rec    FetchRecord
+		storia []FetchRecord
+		motivi int
+	}{
+		{"come al solito", FetchRecord{HTTPStatus: 200, Items: 12, Shape: pagina}, storia, 0},
+		{"qualche bonus in meno", FetchRecord{HTTPStatus: 200, Items: 9, Shape: pagina}, storia, 0},
+		{"nessun bonus", FetchRecord{HTTPStatus: 200, Items: 0, Shape: pagina}, storia, 1},
+		{"resa dimezzata", FetchRecord{HTTPStatus: 200, Items: 4, Shape: pagina}, storia, 1},
+		{"resa esplosa", FetchRecord{HTTPStatus: 200, Items: 60, Shape: pagina}, storia, 1},
+		{"pagina ridisegnata", FetchRecord{HTTPStatus: 200, Items: 12, Shape: ridisegnata}, storia, 1},
+		{"ridisegnata e vuota", FetchRecord{HTTPStatus: 200, Items: 0, Shape: ridisegnata}, storia, 2},
+		{"errore di rete", FetchRecord{Error: "timeout"}, storia, 0},
+		{"storia troppo corta", FetchRecord{HTTPStatus: 200, Items: 0}, storia[:2], 0},
+	}
+	for _, c := range casi {
+		if got := drift(c.rec, c.storia); len(got) != c.motivi {
+			t.Errorf("%s: motivi = %q, attesi %d", c.nome, got, c.motivi)
+		}
+	}
+
+	if s := similarity(pagina, pagina); s != 1 {
+		t.Errorf("somiglianza di una pagina con se stessa = %v", s)
+	}
+	if fingerprint(pagina) == fingerprint(ridisegnata) || fingerprint(pagina) != fingerprint(map[string]int{"a": 1, "p": 1, "h3": 1, "div.card": 1}) {
+		t.Error("l'impronta deve dipendere solo dai tipi di elemento")
+	}
+}
+
+// TestParserDrift scrapes a regional portal until it has a baseline, then
+// serves it redesigned: the drift is reported once, in the status and in the
+// admin alerts.
+func TestParserDrift(t *testing.T) {
+	storage.Use(storage.NewMemory())
+	defer storage.Use(storage.NewMemory())
+
+	var ridisegnato atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ridisegnato.Load() {
+			w.Write([]byte(`<html><body><main class="app"><div id="root"></div></main></body></html>`))
+			return
+		}
+		w.Write([]byte(fixture(t, "regione_lombardia.html")))
+	}))
+	defer srv.Close()
+
+	const nome = "Regione Lombardia (drift)"
+	defer func() {
+		cache.mu.Lock()
+		delete(cache.sourcesStatus, nome)
+		cache.mu.Unlock()
+	}()
+	sources := []RegionalSource{{Name: nome, Regione: "Lombardia", URL: srv.URL, Tipo: "regionale", Priority: 1, Parser: portaleRegionale("https://www.regione.lombardia.it")}}
+	alertDrift := func() int {
+		n := 0
+		for _, a := range validity.GetAlerts() {
+			if a.Fonte == nome && a.NewStato == "parser_drift" {
+				n++
+			}
+		}
+		return n
+	}
+
+	for i := 0; i < minBaseline; i++ {
+		scrapeRegional(sources)
+	}
+	stato := GetScraperStatus()["sources"].(map[string]SourceStatus)[nome]
+	if len(stato.History) != minBaseline || len(stato.Drift) != 0 || alertDrift() != 0 {
+		t.Fatalf("prima del cambiamento: %+v", stato)
+	}
+	if r := stato.History[0]; r.HTTPStatus != 200 || r.Items != 3 || r.Bytes == 0 || r.Fingerprint == "" || r.Shape != nil {
+		t.Errorf("record esposto = %+v", r)
+	}
+
+	ridisegnato.Store(true)
+	scrapeRegional(sources)
+	scrapeRegional(sources)
+	stato = GetScraperStatus()["sources"].(map[string]SourceStatus)[nome]
+	if stato.Success || len(stato.Drift) != 2 {
+		t.Errorf("dopo il cambiamento: success = %v, drift = %q", stato.Success, stato.Drift)
+	}
+	if n := alertDrift(); n != 1 {
+		t.Errorf("alert di parser drift = %d, atteso 1", n)
+	}
+	for _, a := range validity.GetAlerts() {
+		if a.Fonte == nome && !strings.Contains(a.Motivo, "struttura della pagina") {
+			t.Errorf("motivo = %q", a.Motivo)
+		}
+	}
+}
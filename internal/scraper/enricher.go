@@ -1,16 +1,37 @@
 package scraper
 
 import (
+	"bonusperme/internal/config"
+	"bonusperme/internal/datasource"
+	"bonusperme/internal/deadline"
+	"bonusperme/internal/history"
+	"bonusperme/internal/logger"
 	"bonusperme/internal/matcher"
 	"bonusperme/internal/models"
+	"bonusperme/internal/review"
 	"log"
 	"strings"
 	"time"
 )
 
 // EnrichBonusData merges scraped bonus data with the hardcoded bonus list.
-// Hardcoded bonuses serve as the authoritative base; scraped data supplements them.
+// Hardcoded bonuses serve as the authoritative base; scraped data supplements them
+// only through proposals approved in the review queue.
 func EnrichBonusData(scraped []models.Bonus, hardcoded []models.Bonus) []models.Bonus {
+	result, _ := enrich(scraped, hardcoded)
+	return result
+}
+
+// origini maps "bonusID/campo" to the scraping source that set the field.
+type origini map[string]string
+
+func (o origini) fonte(bonusID, campo string) string {
+	return o[bonusID+"/"+campo]
+}
+
+// enrich is EnrichBonusData that also reports which fields came from scraped data.
+func enrich(scraped []models.Bonus, hardcoded []models.Bonus) ([]models.Bonus, origini) {
+	orig := make(origini)
 	// 1. Start with hardcoded as base (they have complete data + proper IDs for scoring)
 	result := make([]models.Bonus, len(hardcoded))
 	copy(result, hardcoded)
@@ -26,19 +47,72 @@ func EnrichBonusData(scraped []models.Bonus, hardcoded []models.Bonus) []models.
 		}
 	}
 
-	// 3. Build lookup by normalized name
+	// 3. Build lookup by normalized name; the resolver matches the catalogue
+	// on name similarity, links and keywords
 	existing := make(map[string]int) // normalized name -> index in result
+	byID := make(map[string]int)
 	for i, b := range result {
 		existing[normalizeName(b.Nome)] = i
+		byID[b.ID] = i
 	}
+	res := newResolver(result)
 
 	// 4. Merge scraped data
 	for _, s := range scraped {
 		norm := normalizeName(s.Nome)
-		if idx, ok := existing[norm]; ok {
+		idx, ok := existing[norm]
+		ris := Risoluzione{Confidenza: punteggioNome, Motivo: "nome"}
+		if !ok || !stessaArea(&s, &result[idx]) {
+			idx, ris = res.risolvi(&s)
+		}
+
+		// Uncertain matches wait for a moderator instead of becoming duplicates
+		if idx >= 0 && ris.Confidenza >= SogliaRevisione && ris.Confidenza < SogliaAbbinamento {
+			p, ok := proponi(review.Proposta{
+				ID:          review.MatchID(s.Nome),
+				Tipo:        review.TipoAbbinamento,
+				BonusID:     ris.BonusID,
+				Valore:      ris.BonusID,
+				NomeScraped: s.Nome,
+				Confidenza:  ris.Confidenza,
+			}, &s)
+			switch {
+			case ok:
+				// The moderator may have chosen another bonus
+				if idx, ok = byID[p.Valore]; !ok {
+					logger.Warn("scraper: approved match points to an unknown bonus", map[string]interface{}{
+						"proposal": p.ID, "bonus_id": p.Valore,
+					})
+					continue
+				}
+				ris.Confidenza = 1
+			case p.Stato == review.StatoRifiutata:
+				idx = -1
+			default:
+				continue
+			}
+		}
+
+		if idx >= 0 && ris.Confidenza >= SogliaAbbinamento {
 			// Update existing bonus with scraped data (only non-empty fields)
-			mergeBonus(&result[idx], &s)
-		} else {
+			dst := &result[idx]
+			accetta := func(campo, attuale, valore string) (string, bool) {
+				p, ok := proponi(review.Proposta{
+					ID:          review.ChangeID(dst.ID, campo, valore),
+					Tipo:        review.TipoModifica,
+					BonusID:     dst.ID,
+					Campo:       campo,
+					Attuale:     attuale,
+					Valore:      valore,
+					NomeScraped: s.Nome,
+					Confidenza:  ris.Confidenza,
+				}, &s)
+				return p.Valore, ok
+			}
+			for _, campo := range mergeBonus(dst, &s, accetta) {
+				orig[dst.ID+"/"+campo] = fonteScraper(&s)
+			}
+		} else if s.ID != "" && s.Nome != "" {
 			// New bonus from scraper
 			s.UltimoAggiornamento = now
 			if s.Stato == "" {
@@ -47,8 +121,23 @@ func EnrichBonusData(scraped []models.Bonus, hardcoded []models.Bonus) []models.
 			if s.Categoria == "" {
 				s.Categoria = "altro"
 			}
-			result = append(result, s)
+			nuovo := s
+			p, ok := proponi(review.Proposta{
+				ID:      review.NewBonusID(s.Nome),
+				Tipo:    review.TipoNuovo,
+				BonusID: s.ID,
+				Bonus:   &nuovo,
+			}, &s)
+			if !ok || p.Bonus == nil {
+				continue
+			}
+			// The approved version, possibly edited by a moderator
+			b := *p.Bonus
+			b.UltimoAggiornamento = now
+			result = append(result, b)
+			orig[b.ID+"/"+history.CampoBonus] = fonteScraper(&s)
 			existing[norm] = len(result) - 1
+			byID[b.ID] = len(result) - 1
 		}
 	}
 
@@ -61,7 +150,18 @@ func EnrichBonusData(scraped []models.Bonus, hardcoded []models.Bonus) []models.
 	}
 
 	log.Printf("[enricher] Result: %d hardcoded + %d scraped -> %d unique bonuses", len(hardcoded), len(scraped), len(valid))
-	return valid
+	return valid, orig
+}
+
+// fonteScraper names the source of a scraped bonus in the catalogue history.
+func fonteScraper(b *models.Bonus) string {
+	if b.FonteNome != "" {
+		return b.FonteNome
+	}
+	if b.Fonte != "" {
+		return b.Fonte
+	}
+	return "scraper"
 }
 
 // ensureHardcodedBase is a compile-time check that matcher.GetAllBonus is accessible.
@@ -71,18 +171,90 @@ func normalizeName(s string) string {
 	return strings.ToLower(strings.TrimSpace(s))
 }
 
-func mergeBonus(dst *models.Bonus, src *models.Bonus) {
+// mergeBonus copies scraped data into dst and returns the history names of
+// the fields it changed. Every change is submitted to accetta, which returns
+// the value to apply and whether the change is approved.
+func mergeBonus(dst *models.Bonus, src *models.Bonus, accetta func(campo, attuale, valore string) (string, bool)) []string {
+	var changed []string
+	apply := func(campo string, field *string, valore string) {
+		if v, ok := accetta(campo, *field, valore); ok && v != *field {
+			*field = v
+			changed = append(changed, campo)
+		}
+	}
 	// Only update if scraped data has something new
 	if src.Importo != "" && src.Importo != "Vedi sito ufficiale" && dst.Importo == "" {
-		dst.Importo = src.Importo
+		apply("importo", &dst.Importo, src.Importo)
 	}
-	if src.Scadenza != "" && src.Scadenza != "Verificare sul sito ufficiale" {
-		dst.Scadenza = src.Scadenza
+	if src.Scadenza != "" && src.Scadenza != "Verificare sul sito ufficiale" && src.Scadenza != dst.Scadenza {
+		prima := dst.Scadenza
+		apply("scadenza", &dst.Scadenza, src.Scadenza)
+		// The catalogue deadline described the old text
+		if dst.Scadenza != prima {
+			dst.Termine = nil
+			deadline.Populate(dst)
+		}
 	}
 	if src.FonteURL != "" && dst.FonteURL == "" {
-		dst.FonteURL = src.FonteURL
+		apply("fonte_url", &dst.FonteURL, src.FonteURL)
 	}
 	if src.UltimoAggiornamento != "" {
 		dst.UltimoAggiornamento = src.UltimoAggiornamento
 	}
+	// The funding status is operational data, like the update date: it feeds
+	// the validity check instead of the review queue
+	if src.Fondi != nil {
+		dst.Fondi = src.Fondi
+	}
+	return changed
+}
+
+// prioritaBackup is the priority of sources not listed in GetSources.
+const prioritaBackup = 3
+
+// sourcePriority returns the Priority of the source a scraped bonus comes
+// from: the source with the same name, else the best source of the same type,
+// else the trust of the official data source it comes from.
+func sourcePriority(b *models.Bonus) int {
+	for _, src := range RegionalSources {
+		if src.Name == b.FonteNome {
+			return src.Priority
+		}
+	}
+	best := 0
+	for _, src := range GetSources() {
+		if src.Name == b.FonteNome {
+			return src.Priority
+		}
+		if src.Type == b.Fonte && (best == 0 || src.Priority < best) {
+			best = src.Priority
+		}
+	}
+	if best == 0 {
+		best = datasource.Trust(b.Fonte)
+	}
+	if best == 0 {
+		return prioritaBackup
+	}
+	return best
+}
+
+// proponi submits a change scraped from src to the review queue and reports
+// whether it is approved. Sources with Priority up to
+// REVIEW_AUTO_APPROVE_PRIORITY are approved automatically.
+func proponi(p review.Proposta, src *models.Bonus) (review.Proposta, bool) {
+	p.Fonte = fonteScraper(src)
+	p.FonteURL = src.FonteURL
+	p.Priorita = sourcePriority(src)
+	// Uncertain matches are never approved automatically
+	auto := p.Tipo != review.TipoAbbinamento && p.Priorita > 0 && p.Priorita <= config.Cfg.ReviewAutoApprovePriority
+
+	stored, err := review.Submit(p, auto)
+	if err != nil {
+		logger.Warn("scraper: review queue unavailable, change not applied", map[string]interface{}{
+			"bonus_id": p.BonusID, "error": err.Error(),
+		})
+		return p, false
+	}
+	return stored, stored.Stato == review.StatoApprovata
 }
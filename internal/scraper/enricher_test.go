@@ -0,0 +1,58 @@
+package scraper
+
+import (
+	"bonusperme/internal/config"
+	"bonusperme/internal/models"
+	"bonusperme/internal/review"
+	"bonusperme/internal/storage"
+	"testing"
+)
+
+func TestEnrich_CodaRevisione(t *testing.T) {
+	storage.Use(storage.NewMemory())
+	defer storage.Use(storage.NewMemory())
+	config.Cfg.ReviewAutoApprovePriority = 1
+	defer func() { config.Cfg.ReviewAutoApprovePriority = 0 }()
+
+	catalogo := []models.Bonus{
+		{ID: "bonus-nido", Nome: "Bonus Nido", Scadenza: "31 dicembre 2026"},
+		{ID: "assegno-unico", Nome: "Assegno Unico", Scadenza: "31 dicembre 2026"},
+	}
+	scraped := []models.Bonus{
+		// Fonte editoriale (priorità 2): in coda
+		{ID: "x1", Nome: "Bonus Nido", Scadenza: "30 giugno 2026", FonteNome: "Ti Consiglio", Fonte: "editorial"},
+		{ID: "bonus-nuovo", Nome: "Bonus Nuovo", FonteNome: "Ti Consiglio", Fonte: "editorial"},
+		// Fonte primaria (priorità 1): approvata automaticamente
+		{ID: "x2", Nome: "Assegno Unico", Scadenza: "28 febbraio 2027", FonteNome: "INPS Famiglie", Fonte: "inps"},
+	}
+
+	got, orig := enrich(scraped, catalogo)
+	if len(got) != 2 {
+		t.Fatalf("bonus = %d, attesi 2: un bonus nuovo non approvato è stato pubblicato", len(got))
+	}
+	if got[0].Scadenza != "31 dicembre 2026" {
+		t.Errorf("scadenza non approvata applicata: %q", got[0].Scadenza)
+	}
+	if got[1].Scadenza != "28 febbraio 2027" || orig.fonte("assegno-unico", "scadenza") != "INPS Famiglie" {
+		t.Errorf("scadenza da fonte primaria non applicata: %q", got[1].Scadenza)
+	}
+
+	inAttesa, _ := review.List(review.StatoInAttesa)
+	if len(inAttesa) != 2 {
+		t.Fatalf("proposte in attesa = %+v, attese 2", inAttesa)
+	}
+	for _, p := range inAttesa {
+		if p.Tipo == review.TipoModifica {
+			review.Edit(p.ID, "15 luglio 2026", nil)
+		}
+		review.Approve(p.ID, "")
+	}
+
+	got, _ = enrich(scraped, catalogo)
+	if len(got) != 3 || got[2].ID != "bonus-nuovo" || got[2].Categoria != "altro" {
+		t.Errorf("bonus nuovo approvato non pubblicato: %+v", got)
+	}
+	if got[0].Scadenza != "15 luglio 2026" {
+		t.Errorf("scadenza modificata e approvata = %q, attesa 15 luglio 2026", got[0].Scadenza)
+	}
+}
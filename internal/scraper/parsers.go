@@ -1,12 +1,11 @@
 package scraper
 
 import (
-	"bonusperme/internal/config"
+	"bonusperme/internal/crawler"
+	"bonusperme/internal/extract"
 	"bonusperme/internal/models"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"regexp"
 	"strings"
 	"time"
@@ -14,16 +13,6 @@ import (
 	"golang.org/x/net/html"
 )
 
-var httpClient = &http.Client{
-	Timeout: 30 * time.Second,
-	CheckRedirect: func(req *http.Request, via []*http.Request) error {
-		if len(via) >= 3 {
-			return fmt.Errorf("too many redirects")
-		}
-		return nil
-	},
-}
-
 var bonusKeywords = []string{"bonus", "assegno", "detrazione", "agevolazione", "contributo", "carta", "esonero"}
 
 var (
@@ -32,51 +21,43 @@ var (
 	dateRegex   = regexp.MustCompile(`(\d{1,2})\s+(gennaio|febbraio|marzo|aprile|maggio|giugno|luglio|agosto|settembre|ottobre|novembre|dicembre)\s+(\d{4})`)
 )
 
-func fetchURL(url string) ([]byte, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("User-Agent", config.Cfg.UserAgent)
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("status %d", resp.StatusCode)
-	}
-	return io.ReadAll(io.LimitReader(resp.Body, 2*1024*1024))
-}
-
 // ParseSource fetches a source URL and parses the HTML for bonus information.
 // It never panics — panics are recovered and logged.
-func ParseSource(src Source) (bonuses []models.Bonus) {
+func ParseSource(src Source) []models.Bonus {
+	bonuses, _ := parseSource(src)
+	return bonuses
+}
+
+// parseSource is ParseSource, also returning how the fetch went.
+func parseSource(src Source) (bonuses []models.Bonus, rec FetchRecord) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("[scraper] panic in parser %s: %v", src.Name, r)
 			bonuses = nil
+			rec.Items = 0
 		}
 	}()
 
-	body, err := fetchURL(src.URL)
-	if err != nil {
-		log.Printf("[scraper] fetch error %s: %v", src.Name, err)
-		return nil
+	body, rec := fetchSource(src.URL)
+	if rec.Error != "" {
+		log.Printf("[scraper] fetch error %s: %s", src.Name, rec.Error)
+		return nil, rec
 	}
 
 	switch src.Parser {
 	case "inps":
-		return parseINPS(body, src)
+		bonuses = parseINPS(body, src)
+		extract.Enrich(bonuses, crawler.Get)
 	case "ade":
-		return parseADE(body, src)
+		bonuses = parseADE(body, src)
+		extract.Enrich(bonuses, crawler.Get)
 	case "editorial":
-		return parseEditorial(body, src)
+		bonuses = parseEditorial(body, src)
 	default:
-		return parseGeneric(body, src)
+		bonuses = parseGeneric(body, src)
 	}
+	rec.Items = len(bonuses)
+	return bonuses, rec
 }
 
 // parseINPS extracts bonus data from INPS pages.
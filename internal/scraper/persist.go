@@ -0,0 +1,65 @@
+package scraper
+
+import (
+	"bonusperme/internal/logger"
+	"bonusperme/internal/matcher"
+	"bonusperme/internal/models"
+	"bonusperme/internal/storage"
+	"time"
+)
+
+const cacheKey = "cache"
+
+// cacheSnapshot is the persisted form of the scraper cache. Only the raw
+// scraped data is stored: enrichment is recomputed against the current catalogue.
+type cacheSnapshot struct {
+	Scraped       []models.Bonus          `json:"scraped"`
+	SourcesStatus map[string]SourceStatus `json:"sources_status"`
+	LastUpdate    time.Time               `json:"last_update"`
+	UpdateCount   int                     `json:"update_count"`
+}
+
+// snapshot copies the cache state. The caller must hold c.mu.
+func (c *BonusCache) snapshot() cacheSnapshot {
+	s := cacheSnapshot{
+		Scraped:       append([]models.Bonus(nil), c.scraped...),
+		SourcesStatus: make(map[string]SourceStatus, len(c.sourcesStatus)),
+		LastUpdate:    c.lastUpdate,
+		UpdateCount:   c.updateCount,
+	}
+	for k, v := range c.sourcesStatus {
+		s.SourcesStatus[k] = v
+	}
+	return s
+}
+
+func saveCache(s cacheSnapshot) {
+	if err := storage.Put(storage.BucketScraper, cacheKey, s); err != nil {
+		logger.Warn("scraper: cannot store cache", map[string]interface{}{"error": err.Error()})
+	}
+}
+
+// Restore loads the scraper cache saved by a previous run.
+func Restore() {
+	var s cacheSnapshot
+	found, err := storage.Get(storage.BucketScraper, cacheKey, &s)
+	if err != nil {
+		logger.Error("scraper: cannot restore cache", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	if !found {
+		return
+	}
+	enriched := EnrichBonusData(s.Scraped, matcher.GetAllBonusWithRegional())
+
+	cache.mu.Lock()
+	cache.bonus = enriched
+	cache.scraped = s.Scraped
+	cache.lastUpdate = s.LastUpdate
+	cache.updateCount = s.UpdateCount
+	if s.SourcesStatus != nil {
+		cache.sourcesStatus = s.SourcesStatus
+	}
+	cache.mu.Unlock()
+	logger.Info("scraper: cache restored", map[string]interface{}{"total": len(enriched), "last_update": s.LastUpdate})
+}
@@ -1,36 +1,36 @@
 package scraper
 
 import (
-	"bonusperme/internal/config"
 	"bonusperme/internal/logger"
 	"bonusperme/internal/matcher"
 	"bonusperme/internal/models"
+	"bonusperme/internal/regions"
 	sentryutil "bonusperme/internal/sentry"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"net/url"
 	"strings"
 	"time"
+
+	"golang.org/x/net/html"
 )
 
 // RegionalSource describes a source for regional bonus data.
 type RegionalSource struct {
-	Regione string
-	URL     string
-	Tipo    string // "regionale" | "aggregatore"
-	Parser  func(body string, regione string) ([]models.Bonus, error)
+	Name     string
+	Regione  string // "*" for aggregators covering every region
+	URL      string
+	Tipo     string // "regionale" | "aggregatore"
+	Priority int    // same scale as Source.Priority
+	Parser   func(body string, regione string) ([]models.Bonus, error)
 }
 
 // RegionalSources lists the sources for regional data.
 var RegionalSources = []RegionalSource{
-	{Regione: "*", URL: "https://www.ticonsiglio.com/bonus-regionali/", Tipo: "aggregatore", Parser: parseRegionalAggregator},
-	{Regione: "Piemonte", URL: "https://www.regione.piemonte.it/web/temi/diritti-politiche-sociali", Tipo: "regionale", Parser: parseRegionalGeneric},
-	{Regione: "Lombardia", URL: "https://www.regione.lombardia.it/wps/portal/istituzionale/HP/servizi-e-informazioni/cittadini/scuola-formazione-e-lavoro/dote-scuola", Tipo: "regionale", Parser: parseRegionalGeneric},
-	{Regione: "Emilia-Romagna", URL: "https://www.regione.emilia-romagna.it/", Tipo: "regionale", Parser: parseRegionalGeneric},
-}
-
-var regionalClient = &http.Client{
-	Timeout: 15 * time.Second,
+	{Name: "Ti Consiglio Regionali", Regione: "*", URL: "https://www.ticonsiglio.com/bonus-regionali/", Tipo: "aggregatore", Priority: 2, Parser: parseRegionalAggregator},
+	{Name: "Regione Piemonte", Regione: "Piemonte", URL: "https://www.regione.piemonte.it/web/temi/diritti-politiche-sociali", Tipo: "regionale", Priority: 1, Parser: portaleRegionale("https://www.regione.piemonte.it")},
+	{Name: "Regione Lombardia", Regione: "Lombardia", URL: "https://www.regione.lombardia.it/wps/portal/istituzionale/HP/servizi-e-informazioni/cittadini/scuola-formazione-e-lavoro/dote-scuola", Tipo: "regionale", Priority: 1, Parser: portaleRegionale("https://www.regione.lombardia.it")},
+	{Name: "Regione Emilia-Romagna", Regione: "Emilia-Romagna", URL: "https://www.regione.emilia-romagna.it/", Tipo: "regionale", Priority: 1, Parser: portaleRegionale("https://www.regione.emilia-romagna.it")},
 }
 
 // FetchRegionalBonuses tries scraping, falls back to hardcoded data.
@@ -39,11 +39,11 @@ func FetchRegionalBonuses(regione string, hardcoded []models.Bonus) []models.Bon
 	scrapingOK := false
 
 	for _, src := range RegionalSources {
-		if src.Regione != regione && src.Regione != "*" {
+		if src.Regione != "*" && !regions.Same(src.Regione, regione) {
 			continue
 		}
 
-		result, err := tryRegionalSource(src, regione)
+		result, _, err := tryRegionalSource(src, regione)
 		if err != nil {
 			logger.Warn("scraper_regional: source failed", map[string]interface{}{
 				"regione": regione, "source": src.URL, "error": err.Error(),
@@ -88,57 +88,349 @@ func FetchRegionalBonuses(regione string, hardcoded []models.Bonus) []models.Bon
 	return hardcoded
 }
 
-func tryRegionalSource(src RegionalSource, regione string) ([]models.Bonus, error) {
-	req, err := http.NewRequest("GET", src.URL, nil)
-	if err != nil {
-		return nil, err
+// scrapeRegional fetches every regional source once, for the scrape cycle.
+// Aggregators return the bonuses of all regions. The outcome of each source
+// is recorded in the cache under its name.
+func scrapeRegional(sources []RegionalSource) []models.Bonus {
+	var all []models.Bonus
+	for _, src := range sources {
+		bonuses, rec, err := tryRegionalSource(src, src.Regione)
+		if err != nil {
+			logger.Warn("scraper_regional: source failed", map[string]interface{}{
+				"source": src.Name, "regione": src.Regione, "error": err.Error(),
+			})
+			sentryutil.CaptureError(err, map[string]string{
+				"component": "scraper_regional",
+				"regione":   src.Regione,
+				"source":    src.URL,
+			})
+		}
+		recordFetch(src.Name, src.Regione, rec, err)
+
+		for j := range bonuses {
+			bonuses[j].FonteAggiornamento = "scraping"
+		}
+		all = append(all, bonuses...)
+		logger.Info("scraper_regional: source complete", map[string]interface{}{"source": src.Name, "found": len(bonuses)})
+	}
+	return all
+}
+
+// tryRegionalSource fetches and parses a regional source. A parser panic is
+// reported as an error, like in ParseSource.
+func tryRegionalSource(src RegionalSource, regione string) (bonuses []models.Bonus, rec FetchRecord, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			bonuses, err = nil, fmt.Errorf("panic nel parser: %v", r)
+			rec.Items = 0
+		}
+	}()
+
+	body, rec := fetchSource(src.URL)
+	if rec.Error != "" {
+		return nil, rec, errors.New(rec.Error)
+	}
+
+	bonuses, err = src.Parser(string(body), regione)
+	for i := range bonuses {
+		bonuses[i].Fonte = src.Tipo
+		bonuses[i].FonteNome = src.Name
+		bonuses[i].FonteURL = src.URL
 	}
-	req.Header.Set("User-Agent", config.Cfg.UserAgent)
-	req.Header.Set("Accept-Language", "it-IT,it;q=0.9")
+	rec.Items = len(bonuses)
+	return bonuses, rec, err
+}
+
+// Parsers. Both read the page as a flat sequence of blocks: a heading or
+// link naming a bonus starts an item, the paragraphs that follow describe it.
+// A parser that finds nothing returns an error, so the caller falls back to
+// the hardcoded data.
 
-	resp, err := regionalClient.Do(req)
+// parseRegionalAggregator reads a page listing the bonuses of every region
+// under headings naming the region ("Bonus Regione Lombardia"). Only the
+// bonuses of regione are returned, all of them when regione is "*".
+func parseRegionalAggregator(body string, regione string) ([]models.Bonus, error) {
+	blocks, err := leggiBlocchi(body)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	p := newRegionalParser("https://www.ticonsiglio.com")
+	livello := livelloSezioni(blocks)
+	sezione := ""
+	for _, b := range blocks {
+		if b.titolo() && b.tag <= livello {
+			// The section of a region, or a wider one naming no region
+			sezione = ""
+			if r, ok := regions.Search(b.testo); ok && b.tag == livello {
+				sezione = r.Nome
+			}
+			p.chiudi()
+			continue
+		}
+		// An item naming its region belongs to it wherever it is listed
+		reg := sezione
+		if r, ok := regions.Search(b.nome()); ok && (b.titolo() || b.ancora != "") {
+			reg = r.Nome
+		}
+		if regione != "*" && !regions.Same(reg, regione) {
+			p.chiudi()
+			continue
+		}
+		p.aggiungi(b, reg)
+	}
+	return p.risultato(regione)
+}
+
+// livelloSezioni returns the heading tag that groups a page by region: the
+// highest level whose headings name at least two regions.
+func livelloSezioni(blocks []blocco) string {
+	for _, tag := range []string{"h2", "h3", "h4"} {
+		visti := make(map[string]bool)
+		for _, b := range blocks {
+			if r, ok := regions.Search(b.testo); ok && b.tag == tag {
+				visti[r.Codice] = true
+			}
+		}
+		if len(visti) >= 2 {
+			return tag
+		}
+	}
+	return ""
+}
+
+// portaleRegionale returns the parser of the bonus pages of a regional portal.
+// base resolves the relative links of the portal.
+func portaleRegionale(base string) func(body string, regione string) ([]models.Bonus, error) {
+	return func(body string, regione string) ([]models.Bonus, error) {
+		blocks, err := leggiBlocchi(body)
+		if err != nil {
+			return nil, err
+		}
+		p := newRegionalParser(base)
+		for _, b := range blocks {
+			p.aggiungi(b, regions.Canonical(regione))
+		}
+		return p.risultato(regione)
+	}
+}
+
+// regionalKeywords extends bonusKeywords with the names regional measures use.
+var regionalKeywords = []string{"contributi", "dote", "buono", "voucher", "sostegno", "fondo", "pacchetto scuola", "misura unica"}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+func containsRegionalKeyword(textLower string) bool {
+	if containsBonusKeyword(textLower) {
+		return true
 	}
+	for _, kw := range regionalKeywords {
+		if strings.Contains(textLower, kw) {
+			return true
+		}
+	}
+	return false
+}
 
-	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+// regionalParser accumulates the items of a page.
+type regionalParser struct {
+	base    *url.URL
+	now     string
+	bonuses []models.Bonus
+	seen    map[string]bool
+	cur     *models.Bonus // item the following paragraphs describe
+}
+
+func newRegionalParser(base string) *regionalParser {
+	u, _ := url.Parse(base)
+	return &regionalParser{base: u, now: time.Now().Format("2 January 2006"), seen: make(map[string]bool)}
+}
+
+// aggiungi adds b to the page: a new item of region reg when it names a
+// bonus, else the description of the current item.
+func (p *regionalParser) aggiungi(b blocco, reg string) {
+	nome := b.nome()
+	lower := strings.ToLower(nome)
+	item := nome != "" && reg != "" && containsRegionalKeyword(lower) && len(nome) > 10 && len(nome) < 200
+	if !item {
+		if b.titolo() {
+			p.cur = nil
+		} else if p.cur != nil {
+			p.descrivi(b.testo)
+		}
+		return
+	}
+
+	slug := slugify(nome)
+	if p.seen[slug] {
+		p.cur = nil
+		return
+	}
+	p.seen[slug] = true
+	p.bonuses = append(p.bonuses, models.Bonus{
+		ID:                  slug,
+		Nome:                nome,
+		Categoria:           categorize(lower),
+		Requisiti:           []string{"Residenza in " + reg, "Consultare il sito ufficiale della regione per i requisiti aggiornati"},
+		ComeRichiederlo:     []string{"Domanda sul portale della regione"},
+		LinkUfficiale:       p.risolvi(b.link),
+		Ente:                "Regione " + reg,
+		RegioniApplicabili:  []string{reg},
+		UltimoAggiornamento: p.now,
+		Stato:               "attivo",
+	})
+	p.cur = &p.bonuses[len(p.bonuses)-1]
+	// A list item may describe the bonus after its link
+	if resto := strings.TrimLeft(strings.TrimPrefix(b.testo, nome), " :-–"); !b.titolo() && resto != "" {
+		p.descrivi(resto)
+	}
+}
+
+// descrivi takes description, amount and deadline of the current item from
+// a paragraph following it.
+func (p *regionalParser) descrivi(testo string) {
+	b := p.cur
+	if b.Descrizione == "" && len(testo) > 20 {
+		b.Descrizione = tronca(testo, 300)
+	}
+	if b.Importo == "" {
+		if m := amountRegex.FindStringSubmatch(strings.ToLower(testo)); len(m) > 1 {
+			b.Importo = "€" + strings.TrimRight(m[1], ".,")
+		}
+	}
+	if b.Scadenza == "" {
+		if m := dateRegex.FindStringSubmatch(strings.ToLower(testo)); len(m) > 3 {
+			b.Scadenza = m[1] + " " + m[2] + " " + m[3]
+		}
+	}
+}
+
+func (p *regionalParser) chiudi() { p.cur = nil }
+
+func (p *regionalParser) risultato(regione string) ([]models.Bonus, error) {
+	if len(p.bonuses) == 0 {
+		return nil, fmt.Errorf("nessun bonus trovato per %s", regione)
+	}
+	for i := range p.bonuses {
+		if p.bonuses[i].Descrizione == "" {
+			p.bonuses[i].Descrizione = "Misura regionale. Verificare sul sito ufficiale della regione per dettagli aggiornati."
+		}
+	}
+	return p.bonuses, nil
+}
+
+// risolvi makes a link of the page absolute.
+func (p *regionalParser) risolvi(link string) string {
+	if link == "" || p.base == nil {
+		return link
+	}
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return p.base.ResolveReference(u).String()
+}
+
+// blocco is a heading, list item or paragraph of a page, with its first link.
+type blocco struct {
+	tag    string
+	testo  string
+	link   string
+	ancora string // text of the link
+}
+
+func (b blocco) titolo() bool {
+	return b.tag == "h2" || b.tag == "h3" || b.tag == "h4"
+}
+
+// nome is the bonus name b may carry: the heading, or the text of the link
+// of a list item or paragraph.
+func (b blocco) nome() string {
+	if b.titolo() {
+		return b.testo
+	}
+	return b.ancora
+}
+
+// leggiBlocchi flattens the content of a page into blocks, skipping the
+// navigation, header and footer of the site.
+func leggiBlocchi(body string) ([]blocco, error) {
+	doc, err := html.Parse(strings.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
+	var out []blocco
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "nav", "header", "footer", "aside", "script", "style", "form":
+				return
+			case "h2", "h3", "h4", "p", "li", "dd":
+				b := blocco{tag: n.Data, testo: spazi(getTextContent(n))}
+				if a := primoLink(n); a != nil {
+					b.link, b.ancora = getAttr(a, "href"), spazi(getTextContent(a))
+				}
+				if b.testo != "" {
+					out = append(out, b)
+				}
+				return
+			case "a":
+				// Cards of the portals are links wrapping a heading
+				if h := primoTitolo(n); h != nil {
+					out = append(out, blocco{tag: h.Data, testo: spazi(getTextContent(h)), link: getAttr(n, "href")})
+					for c := h.NextSibling; c != nil; c = c.NextSibling {
+						visit(c)
+					}
+					return
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(doc)
+	return out, nil
+}
 
-	return src.Parser(string(body), regione)
+func primoLink(n *html.Node) *html.Node {
+	if n.Type == html.ElementNode && n.Data == "a" && getAttr(n, "href") != "" {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if a := primoLink(c); a != nil {
+			return a
+		}
+	}
+	return nil
 }
 
-// Parsers — placeholder implementations that trigger fallback.
-// When a real parser is implemented, it returns actual bonuses.
+func primoTitolo(n *html.Node) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (c.Data == "h2" || c.Data == "h3" || c.Data == "h4") {
+			return c
+		}
+	}
+	return nil
+}
 
-func parseRegionalAggregator(body string, regione string) ([]models.Bonus, error) {
-	// TiConsiglio.com aggregated page — not yet implemented
-	_ = body
-	_ = regione
-	return nil, fmt.Errorf("parser aggregatore non ancora implementato")
+func spazi(s string) string {
+	return strings.Join(strings.Fields(s), " ")
 }
 
-func parseRegionalGeneric(body string, regione string) ([]models.Bonus, error) {
-	// Generic regional page parser — not yet implemented
-	_ = body
-	_ = regione
-	return nil, fmt.Errorf("parser regionale non ancora implementato per %s", regione)
+func tronca(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(r[:n])) + "…"
 }
 
 // GetHardcodedRegionalForRegione returns hardcoded regionals for a specific region.
 func GetHardcodedRegionalForRegione(regione string) []models.Bonus {
 	all := matcher.GetRegionalBonus()
 	var result []models.Bonus
-	regioneLower := strings.ToLower(regione)
 	for _, b := range all {
 		for _, r := range b.RegioniApplicabili {
-			if strings.ToLower(r) == regioneLower {
+			if regions.Same(r, regione) {
 				result = append(result, b)
 				break
 			}
@@ -0,0 +1,154 @@
+package scraper
+
+import (
+	"bonusperme/internal/config"
+	"bonusperme/internal/matcher"
+	"bonusperme/internal/models"
+	"bonusperme/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func fixture(t *testing.T, nome string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", nome))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func nomi(bonuses []models.Bonus) []string {
+	var out []string
+	for _, b := range bonuses {
+		out = append(out, b.RegioniApplicabili[0]+": "+b.Nome)
+	}
+	return out
+}
+
+func TestParseRegionalAggregator(t *testing.T) {
+	body := fixture(t, "ticonsiglio_regionali.html")
+
+	tutti, err := parseRegionalAggregator(body, "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	attesi := []string{
+		"Lombardia: Dote Scuola Materiale Didattico",
+		"Lombardia: Misura Unica Affitto Lombardia",
+		"Piemonte: Voucher Scuola Piemonte 2025",
+		"Piemonte: Fondo sostegno affitto Piemonte",
+		"Emilia-Romagna: Contributo Rette Nido Emilia-Romagna",
+	}
+	if got := nomi(tutti); strings.Join(got, "\n") != strings.Join(attesi, "\n") {
+		t.Fatalf("bonus trovati:\n%s\nattesi:\n%s", strings.Join(got, "\n"), strings.Join(attesi, "\n"))
+	}
+	dote := tutti[0]
+	if dote.Importo != "€200" || dote.Scadenza != "30 giugno 2025" || !strings.HasPrefix(dote.Descrizione, "Contributo per libri") {
+		t.Errorf("dettagli della Dote Scuola non letti: %+v", dote)
+	}
+	if tutti[2].LinkUfficiale != "https://www.ticonsiglio.com/voucher-scuola-piemonte/" || !strings.HasPrefix(tutti[2].Descrizione, "iscrizione") {
+		t.Errorf("voce di elenco: link %q, descrizione %q", tutti[2].LinkUfficiale, tutti[2].Descrizione)
+	}
+
+	solo, err := parseRegionalAggregator(body, "lombardia")
+	if err != nil || len(solo) != 2 {
+		t.Errorf("Lombardia: %v, %v", nomi(solo), err)
+	}
+	if _, err := parseRegionalAggregator(body, "Sicilia"); err == nil {
+		t.Error("nessun bonus siciliano: attesa la caduta sui dati del catalogo")
+	}
+}
+
+func TestPortaliRegionali(t *testing.T) {
+	fixtures := map[string]string{
+		"Piemonte":       "regione_piemonte.html",
+		"Lombardia":      "regione_lombardia.html",
+		"Emilia-Romagna": "regione_emilia_romagna.html",
+	}
+	attesi := map[string]int{"Piemonte": 2, "Lombardia": 3, "Emilia-Romagna": 2}
+	for _, src := range RegionalSources {
+		if src.Regione == "*" {
+			continue
+		}
+		nome, ok := fixtures[src.Regione]
+		if !ok {
+			t.Errorf("fonte %s senza fixture", src.Name)
+			continue
+		}
+		bonuses, err := src.Parser(fixture(t, nome), src.Regione)
+		if err != nil {
+			t.Errorf("%s: %v", src.Name, err)
+			continue
+		}
+		if len(bonuses) != attesi[src.Regione] {
+			t.Errorf("%s: trovati %v, attesi %d", src.Name, nomi(bonuses), attesi[src.Regione])
+		}
+		for _, b := range bonuses {
+			if b.RegioniApplicabili[0] != src.Regione || b.Ente != "Regione "+src.Regione {
+				t.Errorf("%s: %q attribuito a %v", src.Name, b.Nome, b.RegioniApplicabili)
+			}
+			if !strings.HasPrefix(b.LinkUfficiale, "https://") {
+				t.Errorf("%s: link non assoluto %q", src.Name, b.LinkUfficiale)
+			}
+			if strings.Contains(b.Nome, "trasparente") || strings.Contains(b.Nome, "Istruzione") {
+				t.Errorf("%s: voce di navigazione presa come bonus: %q", src.Name, b.Nome)
+			}
+		}
+	}
+}
+
+func TestScrapeRegional(t *testing.T) {
+	storage.Use(storage.NewMemory())
+	defer storage.Use(storage.NewMemory())
+	config.Cfg.ReviewAutoApprovePriority = 1
+	defer func() { config.Cfg.ReviewAutoApprovePriority = 0 }()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/lombardia" {
+			w.Write([]byte(fixture(t, "regione_lombardia.html")))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	sources := []RegionalSource{
+		{Name: "Regione Lombardia", Regione: "Lombardia", URL: srv.URL + "/lombardia", Tipo: "regionale", Priority: 1, Parser: portaleRegionale("https://www.regione.lombardia.it")},
+		{Name: "Regione Piemonte", Regione: "Piemonte", URL: srv.URL + "/piemonte", Tipo: "regionale", Priority: 1, Parser: portaleRegionale("https://www.regione.piemonte.it")},
+	}
+	scraped := scrapeRegional(sources)
+	if len(scraped) != 3 {
+		t.Fatalf("bonus raccolti = %d, attesi 3", len(scraped))
+	}
+	stati := GetScraperStatus()["sources"].(map[string]SourceStatus)
+	if s := stati["Regione Lombardia"]; !s.Success || s.BonusFound != 3 || s.Regione != "Lombardia" {
+		t.Errorf("stato Lombardia = %+v", s)
+	}
+	if s := stati["Regione Piemonte"]; s.Success || s.Error == "" {
+		t.Errorf("stato Piemonte = %+v, atteso errore", s)
+	}
+
+	// I dati regionali passano dallo stesso arricchimento dei nazionali:
+	// la fonte ufficiale (priorità 1) aggiorna la scadenza del bonus in catalogo
+	got, _ := enrich(scraped, matcher.GetAllBonusWithRegional())
+	dote := false
+	for _, b := range got {
+		if b.ID == "dote-scuola-lombardia" {
+			dote = true
+			if b.Scadenza != "30 giugno 2025" {
+				t.Errorf("scadenza della Dote Scuola non aggiornata: %q", b.Scadenza)
+			}
+		}
+		if b.ID == "voucher-scuola-piemonte" && b.Scadenza != "Bando annuale" {
+			t.Errorf("bonus piemontese modificato da dati lombardi: %q", b.Scadenza)
+		}
+	}
+	if !dote {
+		t.Error("dote-scuola-lombardia assente dal catalogo servito")
+	}
+}
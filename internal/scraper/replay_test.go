@@ -0,0 +1,41 @@
+package scraper
+
+import (
+	"bonusperme/internal/crawler"
+	"bonusperme/internal/models"
+	"bonusperme/internal/replay"
+	"path/filepath"
+	"testing"
+)
+
+// fixtures is the recording of the third-party sites shared by the tests of
+// every package that reads them.
+const fixtures = "../../testdata/replay"
+
+// TestParserReplay runs every source on the recorded responses and compares
+// the bonuses found with testdata/golden. After an intended change of a
+// parser, regenerate them with: go test ./internal/scraper -update
+func TestParserReplay(t *testing.T) {
+	crawler.Use(replay.NewPlayer(fixtures))
+	defer crawler.Use(nil)
+
+	for _, src := range GetSources() {
+		t.Run(src.Name, func(t *testing.T) {
+			bonuses := ParseSource(src)
+			if len(bonuses) == 0 {
+				t.Fatalf("nessun bonus da %s", src.URL)
+			}
+			confrontaGolden(t, slugify(src.Name), bonuses)
+		})
+	}
+}
+
+// confrontaGolden compares bonuses, minus the date of the scrape, with
+// testdata/golden/<nome>.json.
+func confrontaGolden(t *testing.T, nome string, bonuses []models.Bonus) {
+	t.Helper()
+	for i := range bonuses {
+		bonuses[i].UltimoAggiornamento = ""
+	}
+	replay.GoldenJSON(t, filepath.Join("testdata", "golden", nome+".json"), bonuses)
+}
@@ -0,0 +1,239 @@
+package scraper
+
+import (
+	"bonusperme/internal/models"
+	"bonusperme/internal/regions"
+	"bonusperme/internal/validity"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Scraped names rarely match the catalogue exactly ("Bonus asilo nido 2025"
+// against "Bonus Asilo Nido"), so every scraped item is scored against the
+// catalogue on several signals and resolved to the best candidate.
+
+// Confidence thresholds of a resolution.
+const (
+	// SogliaAbbinamento is the confidence from which a scraped item enriches
+	// the catalogue bonus it resolves to.
+	SogliaAbbinamento = 0.8
+	// SogliaRevisione is the confidence from which a match is held for review
+	// instead of proposing the item as a new bonus.
+	SogliaRevisione = 0.5
+)
+
+// Signal scores. Name and link equality are near certain; a keyword phrase
+// is strong unless it points to several bonuses.
+const (
+	punteggioNome          = 1.0
+	punteggioLink          = 0.95
+	punteggioParoleChiave  = 0.9
+	punteggioChiaveAmbigua = 0.6
+)
+
+// Risoluzione is the catalogue bonus a scraped item resolves to.
+type Risoluzione struct {
+	BonusID    string  `json:"bonus_id"`
+	Confidenza float64 `json:"confidenza"` // 0..1
+	Motivo     string  `json:"motivo"`     // "nome", "link", "parole_chiave" or "token"
+}
+
+// resolver indexes a catalogue for matching.
+type resolver struct {
+	catalogo []models.Bonus
+	nomi     map[string]int // name key -> index
+	link     map[string]int // URL -> index, -1 when several bonuses share it
+	token    [][]string
+	chiavi   map[string][]int // keyword phrase -> indexes
+}
+
+func newResolver(catalogo []models.Bonus) *resolver {
+	r := &resolver{
+		catalogo: catalogo,
+		nomi:     make(map[string]int),
+		link:     make(map[string]int),
+		token:    make([][]string, len(catalogo)),
+		chiavi:   make(map[string][]int),
+	}
+	// Listing pages of the sources are shared by all their items
+	listing := make(map[string]bool)
+	for _, src := range GetSources() {
+		listing[normURL(src.URL)] = true
+	}
+	for i := range catalogo {
+		b := &catalogo[i]
+		r.nomi[chiaveNome(b.Nome)] = i
+		r.token[i] = tokenSignificativi(b.Nome)
+		for _, u := range []string{b.LinkUfficiale, b.FonteURL} {
+			if u = normURL(u); u == "" || listing[u] {
+				continue
+			}
+			if j, ok := r.link[u]; ok && j != i {
+				r.link[u] = -1
+			} else {
+				r.link[u] = i
+			}
+		}
+		for _, kw := range validity.Keywords(b.ID) {
+			k := chiaveNome(kw)
+			r.chiavi[k] = append(r.chiavi[k], i)
+		}
+	}
+	return r
+}
+
+// Resolve resolves a scraped item to a bonus of catalogo. It returns the
+// index of the bonus, or -1 when no bonus scores above zero.
+func Resolve(s *models.Bonus, catalogo []models.Bonus) (int, Risoluzione) {
+	return newResolver(catalogo).risolvi(s)
+}
+
+func (r *resolver) risolvi(s *models.Bonus) (int, Risoluzione) {
+	best, ris := -1, Risoluzione{}
+	candidato := func(i int, score float64, motivo string) {
+		if score > ris.Confidenza && stessaArea(s, &r.catalogo[i]) {
+			best = i
+			ris = Risoluzione{BonusID: r.catalogo[i].ID, Confidenza: score, Motivo: motivo}
+		}
+	}
+
+	nome := chiaveNome(s.Nome)
+	if i, ok := r.nomi[nome]; ok {
+		candidato(i, punteggioNome, "nome")
+	}
+	// The item's own link; FonteURL of a scraped item is the listing page
+	if u := normURL(s.LinkUfficiale); u != "" && u != normURL(s.FonteURL) {
+		if i, ok := r.link[u]; ok && i >= 0 {
+			candidato(i, punteggioLink, "link")
+		}
+	}
+
+	// The longest keyword phrase contained in the name wins
+	lunghezza, trovati := 0, []int(nil)
+	for kw, idx := range r.chiavi {
+		if !contieneFrase(nome, kw) {
+			continue
+		}
+		switch {
+		case len(kw) > lunghezza:
+			lunghezza, trovati = len(kw), append([]int(nil), idx...)
+		case len(kw) == lunghezza:
+			trovati = append(trovati, idx...)
+		}
+	}
+	if len(trovati) > 0 {
+		score := punteggioParoleChiave
+		if !stessoIndice(trovati) {
+			score = punteggioChiaveAmbigua
+		}
+		sort.Ints(trovati)
+		candidato(trovati[0], score, "parole_chiave")
+	}
+
+	tok := tokenSignificativi(s.Nome)
+	for i := range r.catalogo {
+		candidato(i, dice(tok, r.token[i]), "token")
+	}
+	return best, ris
+}
+
+// stessaArea reports whether scraped item s may refer to catalogue bonus b:
+// an item scraped for a region only matches bonuses of that region.
+func stessaArea(s, b *models.Bonus) bool {
+	if len(s.RegioniApplicabili) == 0 {
+		return true
+	}
+	for _, rs := range s.RegioniApplicabili {
+		for _, rb := range b.RegioniApplicabili {
+			if regions.Same(rs, rb) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var reAnno = regexp.MustCompile(`^(?:19|20)[0-9]{2}$`)
+
+var accenti = strings.NewReplacer("à", "a", "è", "e", "é", "e", "ì", "i", "ò", "o", "ù", "u")
+
+// chiaveNome reduces a name to lowercase words without punctuation and years:
+// "Bonus Asilo Nido 2025/2026" -> "bonus asilo nido".
+func chiaveNome(s string) string {
+	s = accenti.Replace(strings.ToLower(s))
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9')
+	})
+	out := words[:0]
+	for _, w := range words {
+		if !reAnno.MatchString(w) {
+			out = append(out, w)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// paroleGeneriche are words shared by most bonus names; they carry no
+// information about which bonus a name refers to.
+var paroleGeneriche = map[string]bool{
+	"bonus": true, "assegno": true, "detrazione": true, "agevolazione": true, "agevolazioni": true,
+	"contributo": true, "carta": true, "esonero": true, "incentivo": true, "nuovo": true,
+	"di": true, "del": true, "della": true, "dei": true, "degli": true, "delle": true,
+	"per": true, "il": true, "lo": true, "la": true, "le": true, "gli": true, "i": true,
+	"e": true, "a": true, "al": true, "in": true, "con": true, "da": true,
+}
+
+func tokenSignificativi(nome string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, w := range strings.Fields(chiaveNome(nome)) {
+		if !paroleGeneriche[w] && !seen[w] {
+			seen[w] = true
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// dice is the Sørensen–Dice coefficient of two token sets.
+func dice(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := make(map[string]bool, len(a))
+	for _, t := range a {
+		set[t] = true
+	}
+	comuni := 0
+	for _, t := range b {
+		if set[t] {
+			comuni++
+		}
+	}
+	return 2 * float64(comuni) / float64(len(a)+len(b))
+}
+
+func contieneFrase(nome, frase string) bool {
+	return frase != "" && strings.Contains(" "+nome+" ", " "+frase+" ")
+}
+
+func stessoIndice(idx []int) bool {
+	for _, i := range idx[1:] {
+		if i != idx[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// normURL reduces a URL for comparison: no scheme, "www.", fragment or trailing slash.
+func normURL(u string) string {
+	u = strings.ToLower(strings.TrimSpace(u))
+	if i := strings.Index(u, "#"); i >= 0 {
+		u = u[:i]
+	}
+	u = strings.TrimPrefix(strings.TrimPrefix(u, "https://"), "http://")
+	u = strings.TrimPrefix(u, "www.")
+	return strings.TrimSuffix(u, "/")
+}
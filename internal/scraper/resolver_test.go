@@ -0,0 +1,70 @@
+package scraper
+
+import (
+	"bonusperme/internal/matcher"
+	"bonusperme/internal/models"
+	"bonusperme/internal/review"
+	"bonusperme/internal/storage"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	catalogo := matcher.GetAllBonus()
+	adi := ""
+	for _, b := range catalogo {
+		if b.ID == "adi" {
+			adi = b.LinkUfficiale
+		}
+	}
+
+	cases := []struct {
+		scraped models.Bonus
+		id      string
+		min     float64
+		max     float64
+	}{
+		{models.Bonus{Nome: "Bonus asilo nido 2025"}, "bonus-nido", SogliaAbbinamento, 1},
+		{models.Bonus{Nome: "Bonus nido: domande dal 1 marzo"}, "bonus-nido", SogliaAbbinamento, 1},
+		{models.Bonus{Nome: "Bonus elettrodomestici 2025/2026"}, "bonus-mobili", SogliaAbbinamento, 1},
+		{models.Bonus{Nome: "Domanda online", LinkUfficiale: adi + "#domanda"}, "adi", SogliaAbbinamento, 1},
+		// Somiglianza parziale: da rivedere
+		{models.Bonus{Nome: "Bonus asilo privato"}, "bonus-nido", SogliaRevisione, SogliaAbbinamento},
+	}
+	for _, c := range cases {
+		_, ris := Resolve(&c.scraped, catalogo)
+		if ris.BonusID != c.id || ris.Confidenza < c.min || ris.Confidenza >= c.max+0.01 {
+			t.Errorf("Resolve(%q) = %+v, atteso %s con confidenza in [%.2f, %.2f]", c.scraped.Nome, ris, c.id, c.min, c.max)
+		}
+	}
+
+	// La pagina elenco della fonte è condivisa da tutte le voci: non identifica un bonus
+	s := models.Bonus{Nome: "Bonus tredicesima", LinkUfficiale: GetSources()[3].URL}
+	if _, ris := Resolve(&s, catalogo); ris.Confidenza >= SogliaRevisione {
+		t.Errorf("Resolve(%q) = %+v, atteso nessun abbinamento", s.Nome, ris)
+	}
+}
+
+func TestEnrich_AbbinamentoIncerto(t *testing.T) {
+	storage.Use(storage.NewMemory())
+	defer storage.Use(storage.NewMemory())
+
+	catalogo := []models.Bonus{{ID: "bonus-nido", Nome: "Bonus Asilo Nido", Scadenza: "31 dicembre 2026"}}
+	scraped := []models.Bonus{{ID: "bonus-asilo-privato", Nome: "Bonus asilo privato", Scadenza: "30 giugno 2026", FonteNome: "INPS Genitori"}}
+
+	got, _ := enrich(scraped, catalogo)
+	if len(got) != 1 || got[0].Scadenza != "31 dicembre 2026" {
+		t.Fatalf("abbinamento incerto applicato o duplicato: %+v", got)
+	}
+	inAttesa, _ := review.List(review.StatoInAttesa)
+	if len(inAttesa) != 1 || inAttesa[0].Tipo != review.TipoAbbinamento || inAttesa[0].BonusID != "bonus-nido" || inAttesa[0].Confidenza == 0 {
+		t.Fatalf("proposte in attesa = %+v, atteso un abbinamento a bonus-nido", inAttesa)
+	}
+
+	// Rifiutato: la voce diventa la proposta di un bonus nuovo, non un duplicato pubblicato
+	review.Reject(inAttesa[0].ID, "non è il bonus nido")
+	got, _ = enrich(scraped, catalogo)
+	inAttesa, _ = review.List(review.StatoInAttesa)
+	if len(got) != 1 || len(inAttesa) != 1 || inAttesa[0].Tipo != review.TipoNuovo {
+		t.Errorf("dopo il rifiuto: bonus %d, proposte %+v", len(got), inAttesa)
+	}
+}
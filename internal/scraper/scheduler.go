@@ -3,6 +3,7 @@ package scraper
 import (
 	"bonusperme/internal/config"
 	"bonusperme/internal/datasource"
+	"bonusperme/internal/history"
 	"bonusperme/internal/logger"
 	"bonusperme/internal/matcher"
 	"bonusperme/internal/models"
@@ -18,12 +19,17 @@ type SourceStatus struct {
 	Success    bool      `json:"success"`
 	BonusFound int       `json:"bonus_found"`
 	Error      string    `json:"error,omitempty"`
+	Regione    string    `json:"regione,omitempty"` // regional sources only; "*" for aggregators
+	// Drift lists why the last fetch looks like a broken parser, if it does
+	Drift   []string      `json:"drift,omitempty"`
+	History []FetchRecord `json:"history,omitempty"` // oldest first
 }
 
 // BonusCache holds the cached bonus data and source status information.
 type BonusCache struct {
 	mu            sync.RWMutex
 	bonus         []models.Bonus
+	scraped       []models.Bonus
 	lastUpdate    time.Time
 	updateCount   int
 	sourcesStatus map[string]SourceStatus
@@ -45,6 +51,15 @@ func StartScheduler() {
 	}
 
 	go func() {
+		// A cache restored from storage is served until it is due for refresh,
+		// so restarts do not hit the sources again
+		cache.mu.RLock()
+		last := cache.lastUpdate
+		cache.mu.RUnlock()
+		if wait := time.Until(last.Add(config.Cfg.ScraperInterval)); wait > 0 {
+			logger.Info("scraper: restored cache still fresh, delaying first scrape", map[string]interface{}{"wait": wait.String()})
+			time.Sleep(wait)
+		}
 		RunScrape()
 		ticker := time.NewTicker(config.Cfg.ScraperInterval)
 		defer ticker.Stop()
@@ -61,34 +76,23 @@ func RunScrape() {
 	var allScraped []models.Bonus
 
 	// 1. Legacy scraper sources
-	for i, src := range sources {
-		if i > 0 {
-			time.Sleep(2 * time.Second) // polite delay between sources
-		}
-
+	for _, src := range sources {
 		logger.Info("scraper: fetching source", map[string]interface{}{"source": src.Name, "url": src.URL})
-		bonuses := ParseSource(src)
-
-		status := SourceStatus{
-			LastFetch:  time.Now(),
-			Success:    true,
-			BonusFound: len(bonuses),
-		}
+		bonuses, rec := parseSource(src)
+		recordFetch(src.Name, "", rec, nil)
 
 		if len(bonuses) == 0 {
-			status.Error = "no bonuses found"
 			sentryutil.CaptureError(fmt.Errorf("scraper: 0 bonuses from %s", src.Name), map[string]string{"source": src.Name})
 		}
 
-		cache.mu.Lock()
-		cache.sourcesStatus[src.Name] = status
-		cache.mu.Unlock()
-
 		allScraped = append(allScraped, bonuses...)
 		logger.Info("scraper: source complete", map[string]interface{}{"source": src.Name, "found": len(bonuses)})
 	}
 
-	// 2. Official data sources via datasource.Manager
+	// 2. Regional sources, merged into the regional bonuses of the catalogue
+	allScraped = append(allScraped, scrapeRegional(RegionalSources)...)
+
+	// 3. Official data sources via datasource.Manager
 	mgr := datasource.NewManager()
 	officialBonuses := mgr.FetchAll()
 	if len(officialBonuses) > 0 {
@@ -96,14 +100,25 @@ func RunScrape() {
 		logger.Info("scraper: official datasources", map[string]interface{}{"found": len(officialBonuses)})
 	}
 
-	hardcoded := matcher.GetAllBonus()
-	enriched := EnrichBonusData(allScraped, hardcoded)
+	hardcoded := matcher.GetAllBonusWithRegional()
+	enriched, orig := enrich(allScraped, hardcoded)
 
 	cache.mu.Lock()
+	prev := cache.bonus
 	cache.bonus = enriched
+	cache.scraped = allScraped
 	cache.lastUpdate = time.Now()
 	cache.updateCount++
+	ciclo := cache.updateCount
+	snap := cache.snapshot()
 	cache.mu.Unlock()
+	saveCache(snap)
+
+	// Before the first cycle the plain catalogue was being served
+	if len(prev) == 0 {
+		prev, _ = enrich(nil, hardcoded)
+	}
+	recordHistory(prev, enriched, orig, ciclo)
 
 	logger.Info("scraper: cache updated", map[string]interface{}{"total": len(enriched), "cycle": cache.updateCount})
 
@@ -112,13 +127,44 @@ func RunScrape() {
 	}
 }
 
-// GetCachedBonus returns the cached list of bonuses.
+// RefreshCatalog re-applies the last scraped data to the current catalogue.
+// Called after a catalogue hot reload so changes are served without a new scrape.
+func RefreshCatalog() {
+	cache.mu.Lock()
+	if len(cache.bonus) == 0 {
+		cache.mu.Unlock()
+		return
+	}
+	prev := cache.bonus
+	enriched, orig := enrich(cache.scraped, matcher.GetAllBonusWithRegional())
+	cache.bonus = enriched
+	ciclo := cache.updateCount
+	cache.mu.Unlock()
+	logger.Info("scraper: cache refreshed from catalogue", map[string]interface{}{"total": len(enriched)})
+
+	recordHistory(prev, enriched, orig, ciclo)
+}
+
+// recordHistory stores the changes between two versions of the served catalogue.
+func recordHistory(prev, next []models.Bonus, orig origini, ciclo int) {
+	revs := history.Compare(prev, next, orig.fonte, ciclo, time.Now())
+	if len(revs) == 0 {
+		return
+	}
+	if err := history.Record(revs); err != nil {
+		logger.Warn("scraper: cannot record catalogue history", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	logger.Info("scraper: catalogue changes recorded", map[string]interface{}{"revisions": len(revs), "cycle": ciclo})
+}
+
+// GetCachedBonus returns the cached list of national and regional bonuses.
 // Falls back to hardcoded if cache is empty.
 func GetCachedBonus() []models.Bonus {
 	cache.mu.RLock()
 	defer cache.mu.RUnlock()
 	if len(cache.bonus) == 0 {
-		return matcher.GetAllBonus()
+		return matcher.GetAllBonusWithRegional()
 	}
 	result := make([]models.Bonus, len(cache.bonus))
 	copy(result, cache.bonus)
@@ -130,8 +176,13 @@ func GetScraperStatus() map[string]interface{} {
 	cache.mu.RLock()
 	defer cache.mu.RUnlock()
 
+	// Page shapes are internal to drift detection: the fingerprint is enough
 	sources := make(map[string]SourceStatus)
 	for k, v := range cache.sourcesStatus {
+		v.History = append([]FetchRecord(nil), v.History...)
+		for i := range v.History {
+			v.History[i].Shape = nil
+		}
 		sources[k] = v
 	}
 
@@ -141,5 +192,6 @@ func GetScraperStatus() map[string]interface{} {
 		"bonus_count":  len(cache.bonus),
 		"update_count": cache.updateCount,
 		"sources":      sources,
+		"datasources":  datasource.Statuses(),
 	}
 }
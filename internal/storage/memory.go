@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+)
+
+// Memory is an in-memory Backend, used when no storage file is configured.
+type Memory struct {
+	mu      sync.RWMutex
+	buckets map[string]map[string][]byte
+}
+
+// NewMemory returns an empty in-memory backend.
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]map[string][]byte)}
+}
+
+func (m *Memory) Put(bucket, key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.buckets[bucket] == nil {
+		m.buckets[bucket] = make(map[string][]byte)
+	}
+	m.buckets[bucket][key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (m *Memory) Get(bucket, key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.buckets[bucket][key]
+	if !ok {
+		return nil, nil
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (m *Memory) Delete(bucket, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.buckets[bucket], key)
+	return nil
+}
+
+func (m *Memory) ForEach(bucket string, fn func(key string, data []byte) error) error {
+	m.mu.RLock()
+	keys := make([]string, 0, len(m.buckets[bucket]))
+	for k := range m.buckets[bucket] {
+		keys = append(keys, k)
+	}
+	snapshot := make(map[string][]byte, len(keys))
+	for _, k := range keys {
+		snapshot[k] = m.buckets[bucket][k]
+	}
+	m.mu.RUnlock()
+
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := fn(k, append([]byte(nil), snapshot[k]...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Close() error { return nil }
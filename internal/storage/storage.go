@@ -0,0 +1,117 @@
+// Package storage persists catalogue and operational state (scraper cache,
+// catalogue history, review queue, validity verdicts, admin alerts, Gazzetta
+// Ufficiale acts already read, pages cached for conditional requests, aggregate
+// analytics) across restarts.
+//
+// Values are stored as JSON under a bucket and a key. The store must never
+// hold personal data: user profiles are rejected by Put.
+package storage
+
+import (
+	"bonusperme/internal/models"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// Buckets used by the subsystems.
+const (
+	BucketScraper    = "scraper"
+	BucketValidity   = "validity"
+	BucketAlerts     = "alerts"
+	BucketAnalytics  = "analytics"
+	BucketHistory    = "history"
+	BucketReview     = "review"
+	BucketGazzetta   = "gazzetta"
+	BucketCrawler    = "crawler"
+	BucketDatasource = "datasource"
+	BucketFondi      = "fondi"
+)
+
+// ErrPersonalData is returned when a caller tries to store a user profile.
+var ErrPersonalData = errors.New("storage: i profili utente non possono essere salvati")
+
+// Backend is a key-value store of raw JSON documents grouped in buckets.
+type Backend interface {
+	Put(bucket, key string, data []byte) error
+	Get(bucket, key string) ([]byte, error) // nil, nil when the key is missing
+	Delete(bucket, key string) error
+	ForEach(bucket string, fn func(key string, data []byte) error) error
+	Close() error
+}
+
+var (
+	mu      sync.RWMutex
+	backend Backend = NewMemory()
+)
+
+// Init opens the file-based store at path. An empty path keeps the in-memory
+// store, so state is lost on restart.
+func Init(path string) error {
+	if path == "" {
+		return nil
+	}
+	b, err := OpenBolt(path)
+	if err != nil {
+		return err
+	}
+	Use(b)
+	return nil
+}
+
+// Use replaces the current backend, closing the previous one.
+func Use(b Backend) {
+	mu.Lock()
+	old := backend
+	backend = b
+	mu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+}
+
+// Close closes the current backend.
+func Close() error {
+	mu.RLock()
+	defer mu.RUnlock()
+	return backend.Close()
+}
+
+func current() Backend {
+	mu.RLock()
+	defer mu.RUnlock()
+	return backend
+}
+
+// Put stores v as JSON under bucket/key.
+func Put(bucket, key string, v interface{}) error {
+	switch v.(type) {
+	case models.UserProfile, *models.UserProfile, []models.UserProfile:
+		return ErrPersonalData
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return current().Put(bucket, key, data)
+}
+
+// Get decodes the value stored under bucket/key into v.
+// It reports whether the key was found.
+func Get(bucket, key string, v interface{}) (bool, error) {
+	data, err := current().Get(bucket, key)
+	if err != nil || data == nil {
+		return false, err
+	}
+	return true, json.Unmarshal(data, v)
+}
+
+// Delete removes bucket/key.
+func Delete(bucket, key string) error {
+	return current().Delete(bucket, key)
+}
+
+// ForEach calls fn for every key of bucket, in key order.
+func ForEach(bucket string, fn func(key string, data []byte) error) error {
+	return current().ForEach(bucket, fn)
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"bonusperme/internal/models"
+	"path/filepath"
+	"testing"
+)
+
+type stato struct {
+	Stato string `json:"stato"`
+	N     int    `json:"n"`
+}
+
+func TestBolt_Riapertura(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dati", "test.db")
+	if err := Init(path); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	defer Use(NewMemory())
+
+	if err := Put(BucketValidity, "bonus-a", stato{"attivo", 1}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := Put(BucketValidity, "bonus-b", stato{"scaduto", 2}); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := Delete(BucketValidity, "bonus-b"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	// Chiude e riapre il file come dopo un riavvio
+	Use(NewMemory())
+	if err := Init(path); err != nil {
+		t.Fatalf("riapertura: %v", err)
+	}
+	var got stato
+	found, err := Get(BucketValidity, "bonus-a", &got)
+	if err != nil || !found {
+		t.Fatalf("bonus-a non ritrovato dopo la riapertura (found=%v, err=%v)", found, err)
+	}
+	if got != (stato{"attivo", 1}) {
+		t.Errorf("valore = %+v, atteso {attivo 1}", got)
+	}
+	if found, _ := Get(BucketValidity, "bonus-b", &got); found {
+		t.Error("bonus-b cancellato ma ancora presente")
+	}
+	if found, err := Get("inesistente", "x", &got); found || err != nil {
+		t.Errorf("bucket inesistente: found=%v, err=%v", found, err)
+	}
+}
+
+func TestForEach_Ordine(t *testing.T) {
+	for name, b := range map[string]Backend{"memory": NewMemory(), "bolt": nil} {
+		t.Run(name, func(t *testing.T) {
+			if b == nil {
+				bb, err := OpenBolt(filepath.Join(t.TempDir(), "test.db"))
+				if err != nil {
+					t.Fatalf("OpenBolt: %v", err)
+				}
+				b = bb
+			}
+			Use(b)
+			defer Use(NewMemory())
+
+			for _, k := range []string{"c", "a", "b"} {
+				Put(BucketScraper, k, k)
+			}
+			var keys []string
+			ForEach(BucketScraper, func(k string, _ []byte) error {
+				keys = append(keys, k)
+				return nil
+			})
+			if len(keys) != 3 || keys[0] != "a" || keys[1] != "b" || keys[2] != "c" {
+				t.Errorf("chiavi = %v, atteso [a b c]", keys)
+			}
+		})
+	}
+}
+
+func TestPut_RifiutaProfili(t *testing.T) {
+	defer Use(NewMemory())
+	Use(NewMemory())
+	p := models.UserProfile{Eta: 30, ISEE: 12000}
+	for _, v := range []interface{}{p, &p, []models.UserProfile{p}} {
+		if err := Put(BucketAnalytics, "profilo", v); err != ErrPersonalData {
+			t.Errorf("Put(%T) = %v, atteso ErrPersonalData", v, err)
+		}
+	}
+}
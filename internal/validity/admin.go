@@ -13,7 +13,7 @@ func AdminAlertsHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if !checkAdminKey(r) {
+	if !CheckAdminKey(r) {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -31,7 +31,7 @@ func AdminBonusStatusHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if !checkAdminKey(r) {
+	if !CheckAdminKey(r) {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -60,7 +60,8 @@ func AdminBonusStatusHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(entries)
 }
 
-func checkAdminKey(r *http.Request) bool {
+// CheckAdminKey reports whether r carries the ADMIN_API_KEY.
+func CheckAdminKey(r *http.Request) bool {
 	key := config.Cfg.AdminAPIKey
 	if key == "" {
 		return true // no key configured = open access (dev mode)
@@ -16,6 +16,7 @@ type Alert struct {
 	Motivo    string    `json:"motivo"`
 	Timestamp time.Time `json:"timestamp"`
 	Urgenza   string    `json:"urgenza"`
+	Fonte     string    `json:"fonte,omitempty"` // scraping source, for parser drift alerts
 }
 
 var (
@@ -26,11 +27,14 @@ var (
 // AddAlert appends an alert to the ring buffer (max 100).
 func AddAlert(a Alert) {
 	alertsMu.Lock()
-	defer alertsMu.Unlock()
 	alerts = append(alerts, a)
 	if len(alerts) > maxAlerts {
 		alerts = alerts[len(alerts)-maxAlerts:]
 	}
+	snapshot := make([]Alert, len(alerts))
+	copy(snapshot, alerts)
+	alertsMu.Unlock()
+	saveAlerts(snapshot)
 }
 
 // GetAlerts returns a copy of recent alerts (newest first).
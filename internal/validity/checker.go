@@ -1,6 +1,7 @@
 package validity
 
 import (
+	"bonusperme/internal/deadline"
 	"bonusperme/internal/logger"
 	"bonusperme/internal/models"
 	"time"
@@ -13,6 +14,7 @@ func RunCheck(bonuses []models.Bonus) {
 	checked := 0
 
 	for _, b := range bonuses {
+		applyFondi(&b)
 		stato, motivo := evaluate(b, now, currentYear)
 		SetStatus(b.ID, stato, motivo)
 		checked++
@@ -23,26 +25,34 @@ func RunCheck(bonuses []models.Bonus) {
 	})
 }
 
-// evaluate applies 6 rules in priority order and returns (stato, motivo).
+// evaluate applies 7 rules in priority order and returns (stato, motivo).
 func evaluate(b models.Bonus, now time.Time, currentYear int) (string, string) {
-	tipo := b.TipoScadenza
+	t := deadline.Of(b)
+	tipo := t.Tipo
 
-	// Rule 1: permanente + AnnoConferma >= current year → attivo
-	if tipo == "permanente" && b.AnnoConferma >= currentYear {
-		return "attivo", "Bonus permanente confermato per " + itoa(b.AnnoConferma)
+	// Rule 0: published funding status → fondi_esauriti, apertura_imminente...
+	if b.Fondi != nil {
+		if stato, motivo := statoFondi(*b.Fondi, now); stato != "" {
+			return stato, motivo
+		}
 	}
 
-	// Rule 2: data_fissa and past → scaduto
-	if tipo == "data_fissa" && !b.ScadenzaDomanda.IsZero() && now.After(b.ScadenzaDomanda) {
-		return "scaduto", "Scadenza superata: " + b.ScadenzaDomanda.Format("02/01/2006")
+	// Rule 1: recurring deadline + AnnoConferma >= current year → attivo
+	if deadline.Recurring(t) && b.AnnoConferma >= currentYear {
+		return "attivo", "Bonus permanente confermato per " + itoa(b.AnnoConferma)
 	}
 
-	// Rule 3: data_fissa within 30 days → in_scadenza
-	if tipo == "data_fissa" && !b.ScadenzaDomanda.IsZero() {
-		daysLeft := int(b.ScadenzaDomanda.Sub(now).Hours() / 24)
-		if daysLeft <= 30 && daysLeft >= 0 {
-			return "in_scadenza", "Scade tra " + itoa(daysLeft) + " giorni"
+	// Rule 2: deadline passed or funds exhausted → scaduto
+	if deadline.Expired(t, now) {
+		if t.Tipo == deadline.TipoEsaurimento {
+			return "fondi_esauriti", "Fondi esauriti"
 		}
+		return "scaduto", "Scadenza superata: " + deadline.Closing(t, now).Format("02/01/2006")
+	}
+
+	// Rule 3: closing within 30 days → in_scadenza
+	if daysLeft, ok := deadline.DaysLeft(t, now); ok && daysLeft <= 30 {
+		return "in_scadenza", "Scade tra " + itoa(daysLeft) + " giorni"
 	}
 
 	// Rule 4: AnnoConferma < current year → da_verificare
@@ -0,0 +1,186 @@
+package validity
+
+import (
+	"bonusperme/internal/logger"
+	"bonusperme/internal/matcher"
+	"bonusperme/internal/models"
+	"bonusperme/internal/storage"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fondiAdmin holds the funding status set by admins, by bonus id. It wins
+// over the one read by the data sources until removed.
+var fondiAdmin sync.Map // map[string]models.Fondi
+
+// Bonuses returns the bonuses AdminFondiHandler can update, with the funding
+// status read by the data sources; main points it at the scraper cache.
+var Bonuses = matcher.GetAllBonusWithRegional
+
+// SetFondi stores the funding status of a bonus set by an admin.
+func SetFondi(bonusID string, f models.Fondi) error {
+	f.Fonte = "admin"
+	if f.Aggiornato.IsZero() {
+		f.Aggiornato = time.Now()
+	}
+	if err := storage.Put(storage.BucketFondi, bonusID, f); err != nil {
+		return err
+	}
+	fondiAdmin.Store(bonusID, f)
+	return nil
+}
+
+// DeleteFondi removes the funding status set by an admin for a bonus.
+func DeleteFondi(bonusID string) error {
+	if err := storage.Delete(storage.BucketFondi, bonusID); err != nil {
+		return err
+	}
+	fondiAdmin.Delete(bonusID)
+	return nil
+}
+
+// applyFondi sets on b the funding status set by an admin, if any.
+func applyFondi(b *models.Bonus) {
+	if v, ok := fondiAdmin.Load(b.ID); ok {
+		f := v.(models.Fondi)
+		b.Fondi = &f
+	}
+}
+
+func restoreFondi() int {
+	n := 0
+	err := storage.ForEach(storage.BucketFondi, func(id string, data []byte) error {
+		var f models.Fondi
+		if err := json.Unmarshal(data, &f); err != nil {
+			logger.Warn("validity: invalid stored funding status", map[string]interface{}{"bonus_id": id, "error": err.Error()})
+			return nil
+		}
+		fondiAdmin.Store(id, f)
+		n++
+		return nil
+	})
+	if err != nil {
+		logger.Error("validity: cannot restore funding statuses", map[string]interface{}{"error": err.Error()})
+	}
+	return n
+}
+
+// rivaluta recomputes the validity state of a bonus after its admin funding
+// status changed. It reports false for an unknown bonus.
+func rivaluta(bonusID string) bool {
+	for _, b := range Bonuses() {
+		if b.ID != bonusID {
+			continue
+		}
+		applyFondi(&b)
+		now := time.Now()
+		stato, motivo := evaluate(b, now, now.Year())
+		SetStatus(b.ID, stato, motivo)
+		return true
+	}
+	return false
+}
+
+func bonusEsiste(bonusID string) bool {
+	for _, b := range Bonuses() {
+		if b.ID == bonusID {
+			return true
+		}
+	}
+	return false
+}
+
+// statoFondi evaluates a funding status at now. It returns an empty stato
+// when the funds tell nothing about the availability of the bonus.
+func statoFondi(f models.Fondi, now time.Time) (string, string) {
+	switch {
+	case f.Esauriti || (f.Stanziati > 0 && f.Utilizzati >= f.Stanziati):
+		return "fondi_esauriti", "Fondi esauriti"
+	case !f.Chiusura.IsZero() && now.After(f.Chiusura):
+		return "scaduto", "Sportello chiuso il " + f.Chiusura.Format("02/01/2006")
+	case !f.Apertura.IsZero() && now.Before(f.Apertura) && f.Apertura.Sub(now) <= 30*24*time.Hour:
+		quando := f.Apertura.Format("02/01/2006")
+		if f.Apertura.Hour() != 0 || f.Apertura.Minute() != 0 {
+			quando += " alle " + f.Apertura.Format("15:04")
+		}
+		if f.ClickDay {
+			return "apertura_imminente", "Click day il " + quando
+		}
+		return "apertura_imminente", "Domande dal " + quando
+	case f.Stanziati > 0 && f.Utilizzati >= 0.8*f.Stanziati:
+		return "fondi_in_esaurimento", fmt.Sprintf("Utilizzato il %.0f%% dei fondi", 100*f.Utilizzati/f.Stanziati)
+	}
+	return "", ""
+}
+
+// AdminFondiHandler serves the funding status set by admins:
+//
+//	GET    /api/admin/fondi        every status set
+//	PUT    /api/admin/fondi/{id}   set the status of a bonus (JSON models.Fondi)
+//	DELETE /api/admin/fondi/{id}   back to the status read by the data sources
+//
+// The validity state of the bonus is updated at once.
+func AdminFondiHandler(w http.ResponseWriter, r *http.Request) {
+	if !CheckAdminKey(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/fondi"), "/")
+	if id == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		fondi := make(map[string]models.Fondi)
+		fondiAdmin.Range(func(k, v interface{}) bool {
+			fondi[k.(string)] = v.(models.Fondi)
+			return true
+		})
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(fondi)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		if !bonusEsiste(id) {
+			http.Error(w, "Bonus non trovato", http.StatusNotFound)
+			return
+		}
+		var f models.Fondi
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&f); err != nil {
+			http.Error(w, "JSON non valido", http.StatusBadRequest)
+			return
+		}
+		if f.Stanziati < 0 || f.Utilizzati < 0 || (!f.Apertura.IsZero() && !f.Chiusura.IsZero() && f.Chiusura.Before(f.Apertura)) {
+			http.Error(w, "Stato dei fondi non valido", http.StatusBadRequest)
+			return
+		}
+		if err := SetFondi(id, f); err != nil {
+			logger.Error("validity: cannot store funding status", map[string]interface{}{"bonus_id": id, "error": err.Error()})
+			http.Error(w, "Stato dei fondi non salvato", http.StatusInternalServerError)
+			return
+		}
+		rivaluta(id)
+		v, _ := fondiAdmin.Load(id)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	case http.MethodDelete:
+		if err := DeleteFondi(id); err != nil {
+			logger.Error("validity: cannot delete funding status", map[string]interface{}{"bonus_id": id, "error": err.Error()})
+			http.Error(w, "Stato dei fondi non eliminato", http.StatusInternalServerError)
+			return
+		}
+		// A bonus no longer listed only had a stale entry to remove
+		rivaluta(id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
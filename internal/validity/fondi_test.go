@@ -0,0 +1,101 @@
+package validity
+
+import (
+	"bonusperme/internal/models"
+	"bonusperme/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatoFondi(t *testing.T) {
+	now := time.Date(2025, time.November, 10, 12, 0, 0, 0, time.UTC)
+	anno := now.Year()
+	casi := []struct {
+		fondi         models.Fondi
+		stato, motivo string
+	}{
+		{models.Fondi{Esauriti: true}, "fondi_esauriti", "Fondi esauriti"},
+		{models.Fondi{Stanziati: 50e6, Utilizzati: 50e6}, "fondi_esauriti", "Fondi esauriti"},
+		{models.Fondi{Stanziati: 50e6, Utilizzati: 45e6}, "fondi_in_esaurimento", "Utilizzato il 90% dei fondi"},
+		{models.Fondi{ClickDay: true, Apertura: time.Date(2025, time.November, 18, 10, 0, 0, 0, time.UTC)}, "apertura_imminente", "Click day il 18/11/2025 alle 10:00"},
+		{models.Fondi{Apertura: time.Date(2025, time.November, 20, 0, 0, 0, 0, time.UTC)}, "apertura_imminente", "Domande dal 20/11/2025"},
+		{models.Fondi{Chiusura: time.Date(2025, time.October, 31, 18, 0, 0, 0, time.UTC)}, "scaduto", "Sportello chiuso il 31/10/2025"},
+		// Apertura lontana e fondi ancora ampi: decidono le altre regole
+		{models.Fondi{Stanziati: 50e6, Utilizzati: 1e6, Apertura: time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)}, "attivo", "Bonus permanente confermato per 2025"},
+	}
+	for _, c := range casi {
+		f := c.fondi
+		b := models.Bonus{ID: "prova", Scadenza: "In vigore", AnnoConferma: anno, Fondi: &f}
+		if stato, motivo := evaluate(b, now, anno); stato != c.stato || motivo != c.motivo {
+			t.Errorf("evaluate(%+v) = %q, %q; atteso %q, %q", c.fondi, stato, motivo, c.stato, c.motivo)
+		}
+	}
+
+	avvisi := GenerateAvvisi([]models.Bonus{
+		{ID: "esaurito", Scaduto: true, StatoValidita: "fondi_esauriti"},
+		{ID: "click", StatoValidita: "apertura_imminente", MotivoStato: "Click day il 18/11/2025 alle 10:00", Fondi: &models.Fondi{ClickDay: true}},
+		{ID: "scaduto", Scaduto: true, StatoValidita: "scaduto"},
+	})
+	if len(avvisi) != 2 || avvisi[0].Tipo != "danger" || !strings.HasPrefix(avvisi[1].Messaggio, "Click day il 18/11/2025 alle 10:00 — ") {
+		t.Errorf("avvisi = %+v", avvisi)
+	}
+}
+
+func TestAdminFondi(t *testing.T) {
+	storage.Use(storage.NewMemory())
+	defer storage.Use(storage.NewMemory())
+	defer DeleteFondi("bonus-elettrodomestici")
+	prev := Bonuses
+	defer func() { Bonuses = prev }()
+	Bonuses = func() []models.Bonus {
+		return []models.Bonus{{ID: "bonus-elettrodomestici", Scadenza: "Fino ad esaurimento fondi",
+			Fondi: &models.Fondi{Stanziati: 50e6, Utilizzati: 1e6, Fonte: "mise"}}}
+	}
+	stato := func() string {
+		v, _ := statusCache.Load("bonus-elettrodomestici")
+		vs, _ := v.(validityStatus)
+		return vs.StatoValidita
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/fondi/bonus-elettrodomestici", strings.NewReader(`{"esauriti":true,"fonte":"mise"}`))
+	w := httptest.NewRecorder()
+	AdminFondiHandler(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"fonte":"admin"`) {
+		t.Fatalf("PUT: %d %s", w.Code, w.Body.String())
+	}
+
+	// Lo stato impostato vince su quello letto dalle fonti e vale subito
+	bonuses := []models.Bonus{{ID: "bonus-elettrodomestici", Fondi: &models.Fondi{Stanziati: 50e6, Fonte: "mise"}}}
+	ApplyStatus(bonuses)
+	if b := bonuses[0]; b.Fondi.Fonte != "admin" || b.StatoValidita != "fondi_esauriti" || !b.Scaduto {
+		t.Errorf("bonus = %+v, fondi %+v", b, b.Fondi)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/admin/fondi/bonus-elettrodomestici", strings.NewReader(`{"stanziati":-1}`))
+	w = httptest.NewRecorder()
+	AdminFondiHandler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("fondi negativi: status = %d, atteso 400", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/admin/fondi/inesistente", strings.NewReader(`{"esauriti":true}`))
+	w = httptest.NewRecorder()
+	AdminFondiHandler(w, req)
+	if _, salvato := fondiAdmin.Load("inesistente"); w.Code != http.StatusNotFound || salvato {
+		t.Errorf("bonus sconosciuto: status = %d, atteso 404 (salvato = %v)", w.Code, salvato)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/admin/fondi/bonus-elettrodomestici", nil)
+	w = httptest.NewRecorder()
+	AdminFondiHandler(w, req)
+	if found, _ := storage.Get(storage.BucketFondi, "bonus-elettrodomestici", &models.Fondi{}); w.Code != http.StatusNoContent || found {
+		t.Errorf("DELETE: %d, ancora salvato = %v", w.Code, found)
+	}
+	// Tolto lo stato dell'admin torna a valere quello delle fonti, subito
+	if s := stato(); s == "fondi_esauriti" || s == "" {
+		t.Errorf("stato dopo DELETE = %q, atteso rivalutato dai fondi della fonte", s)
+	}
+}
@@ -0,0 +1,150 @@
+package validity
+
+import (
+	"bonusperme/internal/crawler"
+	"bonusperme/internal/logger"
+	"bonusperme/internal/models"
+	"bonusperme/internal/normattiva"
+	"bonusperme/internal/storage"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// guFeedURL lists the acts of the latest issues of the Gazzetta Ufficiale,
+// general series.
+var guFeedURL = "https://www.gazzettaufficiale.it/rss/SG"
+
+const (
+	// maxAttiGU bounds the act pages read in one run.
+	maxAttiGU = 40
+	// conservaAttiGU is how long an act already read is remembered.
+	conservaAttiGU = 90 * 24 * time.Hour
+)
+
+// attoLetto is the record of a Gazzetta Ufficiale act already read.
+type attoLetto struct {
+	Titolo    string    `json:"titolo"`
+	Modifiche int       `json:"modifiche"`
+	Bonus     []string  `json:"bonus,omitempty"`
+	Letto     time.Time `json:"letto"`
+}
+
+// fetchGU downloads a Gazzetta Ufficiale page. A variable so tests can
+// serve fixtures.
+var fetchGU = crawler.Get
+
+// RunGUCheck reads the acts newly published in the Gazzetta Ufficiale and
+// raises an alert for every bonus whose normative references cite an
+// article they amend or repeal. Unlike the news check this is a legal-grade
+// signal: it comes from the text of the act itself.
+func RunGUCheck(bonuses []models.Bonus) {
+	body, err := fetchGU(guFeedURL)
+	if err != nil {
+		logger.Warn("gu check: feed fetch failed", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	var doc rssDocument
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		logger.Warn("gu check: invalid feed", map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	letti, segnalati := 0, 0
+	for _, item := range doc.Channel.Items {
+		if letti == maxAttiGU {
+			break
+		}
+		key := strings.TrimSpace(item.Link)
+		if key == "" {
+			continue
+		}
+		var prec attoLetto
+		if found, err := storage.Get(storage.BucketGazzetta, key, &prec); err != nil || found {
+			continue
+		}
+
+		page, err := fetchGU(key)
+		if err != nil {
+			// Retried at the next run
+			logger.Warn("gu check: act fetch failed", map[string]interface{}{"act": item.Title, "error": err.Error()})
+			continue
+		}
+		letti++
+		mods := normattiva.Amendments(item.Title + ".\n" + normattiva.HTMLText(page))
+		colpiti := segnalaModifiche(bonuses, item.Title, mods)
+		segnalati += len(colpiti)
+
+		atto := attoLetto{Titolo: item.Title, Modifiche: len(mods), Bonus: colpiti, Letto: time.Now()}
+		if err := storage.Put(storage.BucketGazzetta, key, atto); err != nil {
+			logger.Warn("gu check: cannot store act", map[string]interface{}{"error": err.Error()})
+		}
+	}
+	dimenticaAttiGU()
+
+	logger.Info("gu check completed", map[string]interface{}{
+		"acts_read": letti, "bonuses_flagged": segnalati,
+	})
+}
+
+// segnalaModifiche raises an alert for every bonus citing a provision changed
+// by the act titolo, and returns their IDs.
+func segnalaModifiche(bonuses []models.Bonus, titolo string, mods []normattiva.Modifica) []string {
+	if len(mods) == 0 {
+		return nil
+	}
+	var colpiti []string
+	for _, b := range bonuses {
+		var toccate []string
+		abrogato := false
+		for _, r := range normattiva.ParseAll(b.RiferimentiNormativi) {
+			for _, m := range mods {
+				if r.Affects(m.Riferimento) {
+					toccate = append(toccate, m.Riferimento.String())
+					abrogato = abrogato || m.Abrogazione
+					break
+				}
+			}
+		}
+		if len(toccate) == 0 {
+			continue
+		}
+
+		stato, verbo, urgenza := "da_verificare", "modifica", "media"
+		if abrogato {
+			stato, verbo, urgenza = "potenzialmente_scaduto", "abroga", "alta"
+		}
+		motivo := fmt.Sprintf("Gazzetta Ufficiale: %s %s %s", strings.TrimSpace(titolo), verbo, strings.Join(toccate, "; "))
+		SetStatus(b.ID, stato, motivo)
+		AddAlert(Alert{
+			BonusID:   b.ID,
+			BonusNome: b.Nome,
+			OldStato:  b.StatoValidita,
+			NewStato:  stato,
+			Motivo:    motivo,
+			Timestamp: time.Now(),
+			Urgenza:   urgenza,
+		})
+		colpiti = append(colpiti, b.ID)
+	}
+	return colpiti
+}
+
+// dimenticaAttiGU drops the acts read more than conservaAttiGU ago; they are
+// no longer in the feed.
+func dimenticaAttiGU() {
+	var vecchi []string
+	limite := time.Now().Add(-conservaAttiGU)
+	storage.ForEach(storage.BucketGazzetta, func(key string, data []byte) error {
+		var a attoLetto
+		if err := json.Unmarshal(data, &a); err == nil && a.Letto.Before(limite) {
+			vecchi = append(vecchi, key)
+		}
+		return nil
+	})
+	for _, k := range vecchi {
+		storage.Delete(storage.BucketGazzetta, k)
+	}
+}
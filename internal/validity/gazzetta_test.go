@@ -0,0 +1,77 @@
+package validity
+
+import (
+	"bonusperme/internal/models"
+	"bonusperme/internal/storage"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const feedGU = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel>
+<item><title>DECRETO-LEGGE 27 marzo 2026, n. 41 Disposizioni urgenti per le famiglie.</title><link>https://gu.example/atto/1</link></item>
+<item><title>DECRETO 20 marzo 2026 Nomina del commissario.</title><link>https://gu.example/atto/2</link></item>
+</channel></rss>`
+
+const attoGU = `<html><body><div>
+<p>1. All'articolo 1 della legge 30 dicembre 2024, n. 207, sono apportate le seguenti modificazioni:</p>
+<p>a) al comma 177, le parole «euro 3.000» sono sostituite dalle seguenti: «euro 3.600»;</p>
+<p>b) il comma 207 è abrogato.</p>
+<p>2. Si applica l'articolo 3 del decreto legislativo 29 dicembre 2021, n. 230.</p>
+</div></body></html>`
+
+func TestRunGUCheck(t *testing.T) {
+	storage.Use(storage.NewMemory())
+	defer storage.Use(storage.NewMemory())
+
+	pagine := map[string]string{
+		guFeedURL:                   feedGU,
+		"https://gu.example/atto/1": attoGU,
+		"https://gu.example/atto/2": "<html><body><p>Il dott. Rossi è nominato commissario.</p></body></html>",
+	}
+	lette := 0
+	defer func(f func(string) ([]byte, error)) { fetchGU = f }(fetchGU)
+	fetchGU = func(url string) ([]byte, error) {
+		lette++
+		if p, ok := pagine[url]; ok {
+			return []byte(p), nil
+		}
+		return nil, fmt.Errorf("HTTP 404")
+	}
+
+	bonuses := []models.Bonus{
+		{ID: "bonus-nido", Nome: "Bonus Nido", RiferimentiNormativi: []string{"Legge di Bilancio 2025, art. 1 comma 177"}},
+		{ID: "bonus-nascita", Nome: "Carta nuovi nati", RiferimentiNormativi: []string{"Legge di Bilancio 2025, art. 1 commi 206-208"}},
+		{ID: "assegno-unico", Nome: "Assegno Unico", RiferimentiNormativi: []string{"D.Lgs. 29 dicembre 2021, n. 230"}},
+		{ID: "bonus-mamma", Nome: "Bonus mamme", RiferimentiNormativi: []string{"Legge di Bilancio 2024, art. 1 commi 180-182"}},
+	}
+	prima := len(GetAlerts())
+	RunGUCheck(bonuses)
+
+	perBonus := make(map[string]Alert)
+	for _, a := range GetAlerts()[:len(GetAlerts())-prima] {
+		if a.BonusNome != "" {
+			perBonus[a.BonusID] = a
+		}
+	}
+	if a, ok := perBonus["bonus-nido"]; !ok || a.NewStato != "da_verificare" || !strings.Contains(a.Motivo, "DECRETO-LEGGE 27 marzo 2026") {
+		t.Errorf("bonus-nido: avviso = %+v", a)
+	}
+	if a := perBonus["bonus-nascita"]; a.NewStato != "potenzialmente_scaduto" || a.Urgenza != "alta" {
+		t.Errorf("bonus-nascita: comma abrogato, avviso = %+v", a)
+	}
+	// Una semplice citazione non è una modifica; altre leggi non c'entrano
+	for _, id := range []string{"assegno-unico", "bonus-mamma"} {
+		if a, ok := perBonus[id]; ok {
+			t.Errorf("%s: avviso inatteso %+v", id, a)
+		}
+	}
+
+	// Gli atti già letti non vengono riletti
+	lette = 0
+	RunGUCheck(bonuses)
+	if lette != 1 {
+		t.Errorf("seconda esecuzione: %d pagine scaricate, atteso solo il feed", lette)
+	}
+}
@@ -1,12 +1,10 @@
 package validity
 
 import (
-	"bonusperme/internal/config"
+	"bonusperme/internal/crawler"
 	"bonusperme/internal/logger"
 	"bonusperme/internal/models"
 	"encoding/xml"
-	"io"
-	"net/http"
 	"strings"
 	"time"
 )
@@ -50,6 +48,11 @@ var bonusKeywords = map[string][]string{
 	"bonus-acqua-potabile":  {"bonus acqua potabile", "credito acqua"},
 }
 
+// Keywords returns the phrases that identify a bonus in news and scraped text.
+func Keywords(bonusID string) []string {
+	return bonusKeywords[bonusID]
+}
+
 // Signal keywords — positive = conferma, negative = scadenza.
 var confermaKeywords = []string{"confermato", "prorogato", "rinnovo", "esteso", "rifinanziato", "confermata", "prorogata"}
 var scadenzaKeywords = []string{"scaduto", "eliminato", "abolito", "non rinnovato", "soppresso", "terminato", "scadenza superata"}
@@ -195,28 +198,11 @@ func fetchAllFeeds() []fetchedArticle {
 }
 
 func fetchFeed(f rssFeed) []fetchedArticle {
-	client := &http.Client{Timeout: 15 * time.Second}
-	req, err := http.NewRequest("GET", f.URL, nil)
-	if err != nil {
-		return nil
-	}
-	req.Header.Set("User-Agent", config.Cfg.UserAgent)
-
-	resp, err := client.Do(req)
+	body, err := crawler.Get(f.URL)
 	if err != nil {
 		logger.Warn("news: feed fetch failed", map[string]interface{}{"feed": f.Name, "error": err.Error()})
 		return nil
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil
-	}
-
-	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20)) // 1MB max
-	if err != nil {
-		return nil
-	}
 
 	var doc rssDocument
 	if err := xml.Unmarshal(body, &doc); err != nil {
@@ -0,0 +1,25 @@
+package validity
+
+import (
+	"bonusperme/internal/crawler"
+	"bonusperme/internal/replay"
+	"path/filepath"
+	"testing"
+)
+
+// TestFeedReplay reads every news feed from the recorded responses in
+// testdata/replay and compares the articles with testdata/golden/news.json.
+// A feed that is down (LeggiOggi, recorded with a 503) yields no articles.
+func TestFeedReplay(t *testing.T) {
+	crawler.Use(replay.NewPlayer("../../testdata/replay"))
+	defer crawler.Use(nil)
+
+	perFeed := make(map[string][]fetchedArticle)
+	for _, f := range rssFeeds {
+		perFeed[f.Name] = fetchFeed(f)
+	}
+	if len(perFeed["LeggiOggi"]) != 0 {
+		t.Errorf("feed non disponibile: %d articoli", len(perFeed["LeggiOggi"]))
+	}
+	replay.GoldenJSON(t, filepath.Join("testdata", "golden", "news.json"), perFeed)
+}
@@ -0,0 +1,53 @@
+package validity
+
+import (
+	"bonusperme/internal/logger"
+	"bonusperme/internal/storage"
+	"encoding/json"
+)
+
+const alertsKey = "recent"
+
+// Restore loads the validity verdicts, the alert history and the funding
+// statuses set by admins saved by a previous run, so they survive restarts.
+func Restore() {
+	n := 0
+	err := storage.ForEach(storage.BucketValidity, func(id string, data []byte) error {
+		var vs validityStatus
+		if err := json.Unmarshal(data, &vs); err != nil {
+			logger.Warn("validity: invalid stored status", map[string]interface{}{"bonus_id": id, "error": err.Error()})
+			return nil
+		}
+		statusCache.Store(id, vs)
+		n++
+		return nil
+	})
+	if err != nil {
+		logger.Error("validity: cannot restore statuses", map[string]interface{}{"error": err.Error()})
+	}
+
+	var saved []Alert
+	if _, err := storage.Get(storage.BucketAlerts, alertsKey, &saved); err != nil {
+		logger.Error("validity: cannot restore alerts", map[string]interface{}{"error": err.Error()})
+	}
+	if len(saved) > maxAlerts {
+		saved = saved[len(saved)-maxAlerts:]
+	}
+	alertsMu.Lock()
+	alerts = saved
+	alertsMu.Unlock()
+	fondi := restoreFondi()
+	logger.Info("validity: state restored", map[string]interface{}{"statuses": n, "alerts": len(saved), "fondi": fondi})
+}
+
+func saveStatus(id string, vs validityStatus) {
+	if err := storage.Put(storage.BucketValidity, id, vs); err != nil {
+		logger.Warn("validity: cannot store status", map[string]interface{}{"bonus_id": id, "error": err.Error()})
+	}
+}
+
+func saveAlerts(a []Alert) {
+	if err := storage.Put(storage.BucketAlerts, alertsKey, a); err != nil {
+		logger.Warn("validity: cannot store alerts", map[string]interface{}{"error": err.Error()})
+	}
+}
@@ -1,9 +1,9 @@
 package validity
 
 import (
+	"bonusperme/internal/deadline"
 	"bonusperme/internal/models"
 	"fmt"
-	"math"
 	"sync"
 	"time"
 )
@@ -11,21 +11,23 @@ import (
 var statusCache sync.Map // map[string]validityStatus
 
 type validityStatus struct {
-	StatoValidita string
-	MotivoStato   string
-	UpdatedAt     time.Time
+	StatoValidita string    `json:"stato_validita"`
+	MotivoStato   string    `json:"motivo_stato"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
-// ApplyStatus patches StatoValidita and MotivoStato from cache onto bonuses.
-// Also syncs the Scaduto bool based on validity state.
+// ApplyStatus patches StatoValidita and MotivoStato from cache onto bonuses,
+// and the funding status set by admins. Also syncs the Scaduto bool based on
+// validity state.
 func ApplyStatus(bonuses []models.Bonus) {
 	for i := range bonuses {
+		applyFondi(&bonuses[i])
 		if v, ok := statusCache.Load(bonuses[i].ID); ok {
 			vs := v.(validityStatus)
 			bonuses[i].StatoValidita = vs.StatoValidita
 			bonuses[i].MotivoStato = vs.MotivoStato
 			// Sync Scaduto with validity
-			if vs.StatoValidita == "scaduto" || vs.StatoValidita == "potenzialmente_scaduto" {
+			if vs.StatoValidita == "scaduto" || vs.StatoValidita == "potenzialmente_scaduto" || vs.StatoValidita == "fondi_esauriti" {
 				bonuses[i].Scaduto = true
 			}
 		}
@@ -36,18 +38,28 @@ func ApplyStatus(bonuses []models.Bonus) {
 func GenerateAvvisi(bonuses []models.Bonus) []models.Avviso {
 	var avvisi []models.Avviso
 	for _, b := range bonuses {
+		// Exhausted funds may be refinanced: say why the bonus is closed
+		if b.StatoValidita == "fondi_esauriti" {
+			avvisi = append(avvisi, models.Avviso{
+				BonusID:   b.ID,
+				Tipo:      "danger",
+				Messaggio: "Fondi esauriti — Nuove domande solo in caso di rifinanziamento",
+			})
+			continue
+		}
 		if b.Scaduto {
 			continue // already shown as expired
 		}
+		// A deadline counted from the user's event replaces the generic warning
+		if sp := b.ScadenzaPersonale; sp != nil && sp.Evento != "" {
+			if a, ok := avvisoPersonale(b.ID, *sp); ok {
+				avvisi = append(avvisi, a)
+			}
+			continue
+		}
 		switch b.StatoValidita {
 		case "in_scadenza":
-			days := 0
-			if !b.ScadenzaDomanda.IsZero() {
-				days = int(math.Ceil(time.Until(b.ScadenzaDomanda).Hours() / 24))
-				if days < 0 {
-					days = 0
-				}
-			}
+			days, _ := deadline.DaysLeft(deadline.Of(b), time.Now())
 			msg := "Questo bonus scade a breve"
 			if days > 0 {
 				msg = fmt.Sprintf("Scade tra %d giorni — Fai domanda subito", days)
@@ -57,6 +69,22 @@ func GenerateAvvisi(bonuses []models.Bonus) []models.Avviso {
 				Tipo:      "warning",
 				Messaggio: msg,
 			})
+		case "apertura_imminente":
+			msg := b.MotivoStato
+			if b.Fondi != nil && b.Fondi.ClickDay {
+				msg += " — I fondi vanno a chi fa domanda per primo: prepara SPID e documenti"
+			}
+			avvisi = append(avvisi, models.Avviso{
+				BonusID:   b.ID,
+				Tipo:      "info",
+				Messaggio: msg,
+			})
+		case "fondi_in_esaurimento":
+			avvisi = append(avvisi, models.Avviso{
+				BonusID:   b.ID,
+				Tipo:      "warning",
+				Messaggio: "Fondi quasi esauriti — Fai domanda subito",
+			})
 		case "da_verificare":
 			avvisi = append(avvisi, models.Avviso{
 				BonusID:   b.ID,
@@ -74,17 +102,38 @@ func GenerateAvvisi(bonuses []models.Bonus) []models.Avviso {
 	return avvisi
 }
 
+// avvisoPersonale warns about a personal deadline within 30 days or passed.
+func avvisoPersonale(bonusID string, sp models.ScadenzaPersonale) (models.Avviso, bool) {
+	data := sp.Data
+	if d, err := time.Parse("2006-01-02", sp.Data); err == nil {
+		data = d.Format("02/01/2006")
+	}
+	switch {
+	case sp.GiorniMancanti < 0:
+		return models.Avviso{BonusID: bonusID, Tipo: "danger", Messaggio: "Il termine per fare domanda è scaduto il " + data}, true
+	case sp.GiorniMancanti == 0:
+		return models.Avviso{BonusID: bonusID, Tipo: "warning", Messaggio: "Oggi è l'ultimo giorno per fare domanda"}, true
+	case sp.GiorniMancanti == 1:
+		return models.Avviso{BonusID: bonusID, Tipo: "warning", Messaggio: "Ti resta 1 giorno per fare domanda (entro il " + data + ")"}, true
+	case sp.GiorniMancanti <= 30:
+		return models.Avviso{BonusID: bonusID, Tipo: "warning", Messaggio: fmt.Sprintf("Ti restano %d giorni per fare domanda (entro il %s)", sp.GiorniMancanti, data)}, true
+	}
+	return models.Avviso{}, false
+}
+
 // SetStatus stores a validity status in cache (used by checker and news).
 func SetStatus(bonusID, stato, motivo string) {
 	old := ""
 	if v, ok := statusCache.Load(bonusID); ok {
 		old = v.(validityStatus).StatoValidita
 	}
-	statusCache.Store(bonusID, validityStatus{
+	vs := validityStatus{
 		StatoValidita: stato,
 		MotivoStato:   motivo,
 		UpdatedAt:     time.Now(),
-	})
+	}
+	statusCache.Store(bonusID, vs)
+	saveStatus(bonusID, vs)
 	// Generate alert if status changed
 	if old != "" && old != stato {
 		AddAlert(Alert{
@@ -100,9 +149,9 @@ func SetStatus(bonusID, stato, motivo string) {
 
 func alertUrgency(stato string) string {
 	switch stato {
-	case "scaduto", "potenzialmente_scaduto":
+	case "scaduto", "potenzialmente_scaduto", "fondi_esauriti":
 		return "alta"
-	case "in_scadenza":
+	case "in_scadenza", "apertura_imminente", "fondi_in_esaurimento":
 		return "media"
 	default:
 		return "bassa"
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"bonusperme/internal/catalog"
 	"bonusperme/internal/config"
+	"bonusperme/internal/crawler"
 	"bonusperme/internal/handlers"
 	"bonusperme/internal/i18n"
 	"bonusperme/internal/linkcheck"
@@ -9,17 +11,26 @@ import (
 	"bonusperme/internal/matcher"
 	"bonusperme/internal/middleware"
 	"bonusperme/internal/models"
+	"bonusperme/internal/replay"
+	"bonusperme/internal/review"
 	"bonusperme/internal/scraper"
 	sentryutil "bonusperme/internal/sentry"
+	"bonusperme/internal/storage"
 	"bonusperme/internal/validity"
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 )
 
+// shutdownTimeout bounds the wait for in-flight requests on SIGTERM.
+const shutdownTimeout = 15 * time.Second
+
 func main() {
 	// Load configuration from .env and environment variables
 	config.Load()
@@ -28,8 +39,38 @@ func main() {
 	sentryutil.Init()
 	defer sentryutil.Flush()
 
+	// Third-party responses: replay a recording offline, or record them
+	switch {
+	case config.Cfg.HTTPReplayDir != "":
+		crawler.Use(replay.NewPlayer(config.Cfg.HTTPReplayDir))
+		logger.Info("crawler: replaying recorded responses, no network", map[string]interface{}{"dir": config.Cfg.HTTPReplayDir})
+	case config.Cfg.HTTPRecordDir != "":
+		crawler.Use(&replay.Recorder{Dir: config.Cfg.HTTPRecordDir, Daily: true})
+		logger.Info("crawler: recording responses", map[string]interface{}{"dir": config.Cfg.HTTPRecordDir})
+	}
+
+	// Load bonus catalogue: embedded by default, CATALOG_DIR for hot-reloadable data files
+	if err := catalog.Init(config.Cfg.CatalogDir); err != nil {
+		logger.Error("catalog: cannot load CATALOG_DIR, using embedded catalogue", map[string]interface{}{"error": err.Error()})
+	}
+	catalog.OnReload = scraper.RefreshCatalog
+	// Moderator decisions on scraper proposals are applied without a new scrape
+	review.OnDecision = scraper.RefreshCatalog
+	// Admin funding statuses are checked against the scraped bonuses too
+	validity.Bonuses = scraper.GetCachedBonus
+	catalog.StartWatcher(config.Cfg.CatalogDir, config.Cfg.CatalogReloadInterval)
+
+	// Open persistent storage and restore operational state
+	if err := storage.Init(config.Cfg.StoragePath); err != nil {
+		logger.Error("storage: cannot open STORAGE_PATH, state will not survive restarts", map[string]interface{}{"error": err.Error()})
+	}
+	defer storage.Close()
+	validity.Restore()
+	scraper.Restore()
+
 	// Initialize persistent counter
 	handlers.InitCounter()
+	handlers.InitAnalytics()
 
 	// Wire scraper callback to track last update time
 	scraper.OnScrapeComplete = func(t time.Time) {
@@ -58,6 +99,7 @@ func main() {
 	mux.HandleFunc("/api/health", handlers.HealthDetailedHandler)
 	mux.HandleFunc("/api/parse-isee", handlers.ParseISEEHandler)
 	mux.HandleFunc("/api/calendar", handlers.CalendarHandler)
+	mux.HandleFunc("/api/calendar/", handlers.CalendarFeedHandler)
 	mux.HandleFunc("/api/simulate", handlers.SimulateHandler)
 	mux.HandleFunc("/api/report", handlers.ReportHandler)
 	mux.HandleFunc("/api/notify-signup", handlers.NotifySignupHandler)
@@ -76,6 +118,13 @@ func main() {
 	// Admin routes (protected by ADMIN_API_KEY)
 	mux.HandleFunc("/api/admin/alerts", validity.AdminAlertsHandler)
 	mux.HandleFunc("/api/admin/bonus-status", validity.AdminBonusStatusHandler)
+	mux.HandleFunc("/api/admin/fondi", validity.AdminFondiHandler)
+	mux.HandleFunc("/api/admin/fondi/", validity.AdminFondiHandler)
+	mux.HandleFunc("/api/admin/history", handlers.AdminHistoryHandler)
+	mux.HandleFunc("/api/admin/review", handlers.AdminReviewHandler)
+	mux.HandleFunc("/api/admin/review/", handlers.AdminReviewHandler)
+	mux.HandleFunc("/api/admin/datasources", handlers.AdminDatasourcesHandler)
+	mux.HandleFunc("/api/admin/datasources/", handlers.AdminDatasourcesHandler)
 
 	// Pages
 	mux.HandleFunc("/per-caf", handlers.PerCAFHandler)
@@ -195,7 +244,49 @@ func main() {
 		}()
 	}
 
-	logger.Info("server starting", map[string]interface{}{"port": config.Cfg.Port})
-	fmt.Printf("BonusPerMe running on http://localhost:%s\n", config.Cfg.Port)
-	log.Fatal(http.ListenAndServe(":"+config.Cfg.Port, handler))
+	if config.Cfg.GUCheckEnabled {
+		go func() {
+			time.Sleep(45 * time.Second)
+			validity.RunGUCheck(matcher.GetAllBonusWithRegional())
+		}()
+
+		go func() {
+			ticker := time.NewTicker(config.Cfg.GUCheckInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				validity.RunGUCheck(matcher.GetAllBonusWithRegional())
+			}
+		}()
+	}
+
+	srv := &http.Server{Addr: ":" + config.Cfg.Port, Handler: handler}
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("server starting", map[string]interface{}{"port": config.Cfg.Port})
+		fmt.Printf("BonusPerMe running on http://localhost:%s\n", config.Cfg.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+	}()
+
+	// On SIGTERM (docker stop) finish the requests in flight, then store the
+	// analytics; the deferred calls close the database and flush Sentry. A
+	// server that fails to start returns the same way, never with log.Fatal.
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-serveErr:
+		logger.Error("server: listen failed", map[string]interface{}{"error": err.Error()})
+		sentryutil.CaptureError(err, map[string]string{"component": "server"})
+		handlers.FlushAnalytics()
+		return
+	case <-stop:
+	}
+	logger.Info("server shutting down", nil)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Warn("server: shutdown incomplete", map[string]interface{}{"error": err.Error()})
+	}
+	handlers.FlushAnalytics()
 }